@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	flock "github.com/srikrsna/flock/pkg"
+	flockSQL "github.com/srikrsna/flock/sql"
+)
+
+// columnIssue is a mismatch between the columns an entry maps and the ones its query selects
+type columnIssue struct {
+	entry string
+	// field is the destination column mapping the source column, empty for the columns nothing maps
+	field   string
+	message string
+	// A warning doesn't fail the run
+	warning bool
+}
+
+func (i columnIssue) String() string {
+	if i.field == "" {
+		return fmt.Sprintf("%s: %s", i.entry, i.message)
+	}
+
+	return fmt.Sprintf("%s.%s: %s", i.entry, i.field, i.message)
+}
+
+// checkSourceColumns reads the columns selected by the query of every entry and checks them against the columns
+// the entry maps. The queries are those of the entries, with their params bound
+func checkSourceColumns(ctx context.Context, db *sql.DB, fl *flock.Flock, queries []string, args [][]interface{}) ([]columnIssue, error) {
+	selected := make([][]string, len(fl.Entries))
+	for i, e := range fl.Entries {
+		cols, err := flockSQL.QueryColumns(ctx, db, queries[i], args[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the columns of the query of %s: %v", e.Name, err)
+		}
+		selected[i] = cols
+	}
+
+	return columnIssues(fl, selected), nil
+}
+
+// columnIssues compares the columns mapped by every entry, by the fields of its targets, the params of their calls and
+// functions and their where clauses, with the columns selected by its query. A column only matching in case is NULL in
+// the rows, so it is an error
+func columnIssues(fl *flock.Flock, selected [][]string) []columnIssue {
+	issues := make([]columnIssue, 0)
+	for i, e := range fl.Entries {
+		exact := make(map[string]bool, len(selected[i]))
+		folded := make(map[string]string, len(selected[i]))
+		for _, c := range selected[i] {
+			exact[c] = true
+			folded[strings.ToLower(c)] = c
+		}
+
+		used := make(map[string]bool)
+		check := func(field, column string) {
+			if exact[column] {
+				used[column] = true
+				return
+			}
+			if c, ok := folded[strings.ToLower(column)]; ok {
+				used[c] = true
+				issues = append(issues, columnIssue{e.Name, field, fmt.Sprintf("column %s is selected as %s", column, c), false})
+				return
+			}
+			issues = append(issues, columnIssue{e.Name, field, fmt.Sprintf("column %s isn't selected by the query", column), false})
+		}
+		for _, t := range e.Destinations() {
+			// The fields of a target are reported along with the table they are inserted into
+			prefix := ""
+			if len(e.Targets) > 0 {
+				prefix = t.Name + "."
+			}
+			for _, f := range t.Fields {
+				params := make([]*flock.FuncParameter, 0)
+				switch {
+				case f.Call != nil:
+					params = append(params, f.Call.Parameters...)
+				case f.Literal == nil && f.Param == nil:
+					check(prefix+f.Key, f.Value)
+				}
+				for _, fun := range f.Functions {
+					params = append(params, fun.Parameters...)
+				}
+				for _, p := range params {
+					if v, key := p.Value(); key {
+						check(prefix+f.Key, v.(string))
+					}
+				}
+			}
+			if t.Where != nil {
+				checkWhere(t, func(field, column string) { check(prefix+field, column) })
+			}
+		}
+
+		for _, c := range selected[i] {
+			if !used[c] {
+				issues = append(issues, columnIssue{e.Name, "", fmt.Sprintf("column %s is selected but not mapped", c), true})
+			}
+		}
+	}
+
+	return issues
+}
+
+// checkWhere checks the names of the where clause of the target that aren't its fields, they are source columns
+func checkWhere(t *flock.Target, check func(field, column string)) {
+	fields := make(map[string]bool, len(t.Fields))
+	for _, f := range t.Fields {
+		fields[f.Key] = true
+	}
+	name := func(n string) {
+		if !fields[n] {
+			check("where", n)
+		}
+	}
+
+	for _, o := range t.Where.Operands() {
+		switch {
+		case o.Args != nil:
+			for _, p := range o.Args.Parameters {
+				if v, key := p.Value(); key {
+					name(v.(string))
+				}
+			}
+		case o.Name != "":
+			name(o.Name)
+		}
+	}
+}
+
+// columnsError returns the errors among the issues as one error
+func columnsError(issues []columnIssue) error {
+	errs := make([]string, 0)
+	for _, i := range issues {
+		if !i.warning {
+			errs = append(errs, i.String())
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("source columns don't match the schema: %s", strings.Join(errs, "; "))
+}
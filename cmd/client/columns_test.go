@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	flock "github.com/srikrsna/flock/pkg"
+)
+
+func TestColumnIssues(t *testing.T) {
+	fl, err := flock.ParseSchema(strings.NewReader("Users {\n `SELECT * FROM Users`\n {\n" +
+		` - id = Id
+		  - email = Email
+		  - name = First | Join Last
+		  - phone = Phone
+		  - tenant = "acme"
+		  - code = Format("%s-%s" Id Region) per row
+		 } where Active and email != null and not Blank(Notes)
+		}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues := columnIssues(fl, [][]string{{"Id", "email", "First", "Last", "Notes", "Extra"}})
+	want := []columnIssue{
+		{"Users", "email", "column Email is selected as email", false},
+		{"Users", "phone", "column Phone isn't selected by the query", false},
+		{"Users", "code", "column Region isn't selected by the query", false},
+		{"Users", "where", "column Active isn't selected by the query", false},
+		{"Users", "", "column Extra is selected but not mapped", true},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("got %v, want %v", issues, want)
+	}
+
+	err = columnsError(issues)
+	if err == nil || strings.Contains(err.Error(), "Extra") || !strings.Contains(err.Error(), "Users.phone") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := columnsError(issues[4:]); err != nil {
+		t.Errorf("warnings failed the run: %v", err)
+	}
+}
+
+func TestColumnIssuesTargets(t *testing.T) {
+	fl, err := flock.ParseSchema(strings.NewReader("Users {\n `SELECT * FROM Users`\n" +
+		` into users {
+		  - id = Id
+		 }
+		 into user_profiles {
+		  - user_id = Id
+		  - bio = Bio
+		 } where Active
+		}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issues := columnIssues(fl, [][]string{{"Id", "bio"}})
+	want := []columnIssue{
+		{"Users", "user_profiles.bio", "column Bio is selected as bio", false},
+		{"Users", "user_profiles.where", "column Active isn't selected by the query", false},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("got %v, want %v", issues, want)
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pb "github.com/srikrsna/flock/protos"
+	"google.golang.org/grpc"
+)
+
+// idBatchSize is the number of mappings sent in a message by the import
+const idBatchSize = 1000
+
+// Formats of the ID mapping files
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+)
+
+// idsCommand is the ids subcommand, it returns the exit code of the process
+func idsCommand(args []string) int {
+	usage := "usage: client ids export|import -table <table> [flags]"
+	if len(args) == 0 || args[0] != "export" && args[0] != "import" {
+		fmt.Fprintln(os.Stderr, usage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("ids "+args[0], flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	server := fs.String("server", "localhost:8081", "address of the flock server")
+	table := fs.String("table", "", "legacy table of the mappings")
+	url := fs.String("url", "", "destination database, needed by the sql ID mapper")
+	database := fs.String("database", "", "driver of the destination database")
+	dialect := fs.String("dialect", "", "dialect of the destination database, the driver when empty")
+	format := fs.String("format", "", "csv or ndjson, taken from the extension of the file and csv otherwise")
+	file := fs.String("file", "", "file to write to or read from, stdout or stdin when empty")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if *table == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	f, err := idFormat(*format, *file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	req := &pb.IDsRequest{Table: *table, Url: *url, Database: *database, Dialect: *dialect}
+
+	if args[0] == "export" {
+		err = exportIDs(*server, req, f, *file)
+	} else {
+		err = importIDs(*server, req, f, *file)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed: %v\n", args[0], err)
+		return 1
+	}
+
+	return 0
+}
+
+// idFormat returns the format of the file, the extension is used when it isn't given
+func idFormat(format, file string) (string, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file)), ".")
+		if format != formatNDJSON && format != "jsonl" {
+			return formatCSV, nil
+		}
+		return formatNDJSON, nil
+	}
+
+	switch format = strings.ToLower(format); format {
+	case formatCSV, formatNDJSON:
+		return format, nil
+	case "jsonl":
+		return formatNDJSON, nil
+	}
+
+	return "", fmt.Errorf("unknown format: %s", format)
+}
+
+func exportIDs(server string, req *pb.IDsRequest, format, file string) error {
+	conn, err := grpc.Dial(server, grpc.WithInsecure())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	stream, err := pb.NewFlockClient(conn).ExportIDs(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+	if file != "" {
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	w := newIDWriter(out, format)
+	count := 0
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		for _, m := range res.Mappings {
+			if err := w.write(m); err != nil {
+				return err
+			}
+		}
+		count += len(res.Mappings)
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "exported %d mappings of %s\n", count, req.Table)
+	return nil
+}
+
+func importIDs(server string, req *pb.IDsRequest, format, file string) error {
+	in := io.Reader(os.Stdin)
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	conn, err := grpc.Dial(server, grpc.WithInsecure())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	stream, err := pb.NewFlockClient(conn).ImportIDs(context.Background())
+	if err != nil {
+		return err
+	}
+
+	// The first message names the table even when there is nothing to import
+	batch := &pb.ImportIDsRequest{Table: req}
+	err = readIDs(in, format, func(m *pb.IDMapping) error {
+		batch.Mappings = append(batch.Mappings, m)
+		if len(batch.Mappings) < idBatchSize {
+			return nil
+		}
+		err := stream.Send(batch)
+		batch = &pb.ImportIDsRequest{}
+		return err
+	})
+	if err != nil {
+		stream.CloseSend()
+		return err
+	}
+	if batch.Table != nil || len(batch.Mappings) > 0 {
+		if err := stream.Send(batch); err != nil {
+			return err
+		}
+	}
+
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "imported %d of %d mappings of %s, the rest were already mapped\n", res.Imported, res.Received, req.Table)
+	return nil
+}
+
+// idWriter writes mappings in a format
+type idWriter struct {
+	csv  *csv.Writer
+	json *bufio.Writer
+}
+
+func newIDWriter(w io.Writer, format string) *idWriter {
+	if format == formatNDJSON {
+		return &idWriter{json: bufio.NewWriter(w)}
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"old_id", "new_id"})
+	return &idWriter{csv: cw}
+}
+
+func (w *idWriter) write(m *pb.IDMapping) error {
+	if w.csv != nil {
+		return w.csv.Write([]string{m.OldId, m.NewId})
+	}
+
+	b, err := json.Marshal(idLine{m.OldId, m.NewId})
+	if err != nil {
+		return err
+	}
+	w.json.Write(b)
+	return w.json.WriteByte('\n')
+}
+
+func (w *idWriter) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+
+	return w.json.Flush()
+}
+
+// idLine is a line of an NDJSON file
+type idLine struct {
+	Old string `json:"old_id"`
+	New string `json:"new_id"`
+}
+
+// readIDs calls fn for every mapping of the file. A CSV file may start with an old_id,new_id header
+func readIDs(r io.Reader, format string, fn func(*pb.IDMapping) error) error {
+	if format == formatNDJSON {
+		s := bufio.NewScanner(r)
+		s.Buffer(nil, 1<<20)
+		for line := 1; s.Scan(); line++ {
+			if strings.TrimSpace(s.Text()) == "" {
+				continue
+			}
+			var m idLine
+			if err := json.Unmarshal(s.Bytes(), &m); err != nil {
+				return fmt.Errorf("line %d: %v", line, err)
+			}
+			if err := fn(&pb.IDMapping{OldId: m.Old, NewId: m.New}); err != nil {
+				return err
+			}
+		}
+		return s.Err()
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 2
+	cr.TrimLeadingSpace = true
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if line == 1 && rec[0] == "old_id" && rec[1] == "new_id" {
+			continue
+		}
+		if err := fn(&pb.IDMapping{OldId: rec[0], NewId: rec[1]}); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	pb "github.com/srikrsna/flock/protos"
+)
+
+func TestIDFiles(t *testing.T) {
+	mappings := []*pb.IDMapping{
+		{OldId: "1", NewId: "0f8fad5b-d9cb-469f-a165-70867728950e"},
+		{OldId: "a,b", NewId: "7c9e6679-7425-40de-944b-e07fc1f90ae7"},
+	}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"ids.csv", "old_id,new_id\n1,0f8fad5b-d9cb-469f-a165-70867728950e\n\"a,b\",7c9e6679-7425-40de-944b-e07fc1f90ae7\n"},
+		{"ids.ndjson", `{"old_id":"1","new_id":"0f8fad5b-d9cb-469f-a165-70867728950e"}` + "\n" + `{"old_id":"a,b","new_id":"7c9e6679-7425-40de-944b-e07fc1f90ae7"}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			format, err := idFormat("", tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			w := newIDWriter(&buf, format)
+			for _, m := range mappings {
+				if err := w.write(m); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.flush(); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got %q, want %q", buf.String(), tt.want)
+			}
+
+			got := make([]*pb.IDMapping, 0)
+			if err := readIDs(&buf, format, func(m *pb.IDMapping) error {
+				got = append(got, m)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, mappings) {
+				t.Errorf("read %v, want %v", got, mappings)
+			}
+		})
+	}
+
+	// The header is optional and a bad line is an error
+	err := readIDs(strings.NewReader("1,2\n3\n"), formatCSV, func(*pb.IDMapping) error { return nil })
+	if err == nil {
+		t.Error("expected an error for a line with one field")
+	}
+	if _, err := idFormat("xml", ""); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
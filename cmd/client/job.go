@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	flockpb "github.com/srikrsna/flock/protos"
+	"gopkg.in/yaml.v2"
+)
+
+// jobSpec describes a run started from the command line instead of the UI
+type jobSpec struct {
+	// Address of the flock server
+	Server      string     `json:"server"`
+	Source      connection `json:"source"`
+	Destination connection `json:"destination"`
+	// Paths of the .fl schema and of the plugin, relative to the spec file. The plugin is optional
+	Schema string                 `json:"schema"`
+	Plugin string                 `json:"plugin"`
+	Params map[string]interface{} `json:"params"`
+
+	Dialect     string          `json:"dialect"`
+	RunID       string          `json:"run_id"`
+	Scope       string          `json:"scope"`
+	CommitEvery int64           `json:"commit_every"`
+	DeadLetter  *deadLetterSpec `json:"dead_letter"`
+	// InferOrder also orders the entries by the foreign keys of the destination
+	InferOrder bool   `json:"infer_order"`
+	Tuning     tuning `json:"tuning"`
+}
+
+type connection struct {
+	URL      string `json:"url"`
+	Database string `json:"database"`
+}
+
+type deadLetterSpec struct {
+	Sink      string `json:"sink"`
+	Table     string `json:"table"`
+	MaxErrors int64  `json:"max_errors"`
+}
+
+// tuning overrides the limits of the client, zero values keep the defaults
+type tuning struct {
+	BatchSize       int `json:"batch_size"`
+	BatchesInFlight int `json:"batches_in_flight"`
+	ChunkSize       int `json:"chunk_size"`
+}
+
+// loadJobSpec reads the spec file, files ending in .yaml or .yml are YAML and the others JSON
+func loadJobSpec(path string) (*jobSpec, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// YAML is converted to JSON so that both formats share the same field names and params have the same types
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("invalid job spec %s: %v", path, err)
+		}
+		if data, err = json.Marshal(jsonValue(v)); err != nil {
+			return nil, fmt.Errorf("invalid job spec %s: %v", path, err)
+		}
+	}
+
+	spec := &jobSpec{}
+	if err := json.Unmarshal(data, spec); err != nil {
+		return nil, fmt.Errorf("invalid job spec %s: %v", path, err)
+	}
+	if err := spec.validate(); err != nil {
+		return nil, fmt.Errorf("invalid job spec %s: %v", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	if !filepath.IsAbs(spec.Schema) {
+		spec.Schema = filepath.Join(dir, spec.Schema)
+	}
+	if spec.Plugin != "" && !filepath.IsAbs(spec.Plugin) {
+		spec.Plugin = filepath.Join(dir, spec.Plugin)
+	}
+
+	return spec, nil
+}
+
+// jsonValue replaces the maps decoded from YAML, whose keys can be anything, with maps keyed by strings
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = jsonValue(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range v {
+			v[i] = jsonValue(e)
+		}
+	}
+
+	return v
+}
+
+func (s *jobSpec) validate() error {
+	switch {
+	case s.Server == "":
+		return fmt.Errorf("missing server")
+	case s.Source.URL == "" || s.Source.Database == "":
+		return fmt.Errorf("missing source url or database")
+	case s.Destination.URL == "" || s.Destination.Database == "":
+		return fmt.Errorf("missing destination url or database")
+	case s.Schema == "":
+		return fmt.Errorf("missing schema")
+	case s.Tuning.BatchSize < 0 || s.Tuning.BatchesInFlight < 0 || s.Tuning.ChunkSize < 0:
+		return fmt.Errorf("tuning options can't be negative")
+	}
+
+	return nil
+}
+
+// options returns the settings of the run passed on to the server
+func (s *jobSpec) options() (runOptions, error) {
+	scope, err := parseScope(s.Scope)
+	if err != nil {
+		return runOptions{}, err
+	}
+	var deadLetter *flockpb.DeadLetter
+	if s.DeadLetter != nil {
+		if deadLetter, err = parseDeadLetter(s.DeadLetter.Sink, s.DeadLetter.Table, s.DeadLetter.MaxErrors); err != nil {
+			return runOptions{}, err
+		}
+	}
+
+	return runOptions{
+		dialect:     s.Dialect,
+		runID:       s.RunID,
+		scope:       scope,
+		commitEvery: s.CommitEvery,
+		deadLetter:  deadLetter,
+		inferOrder:  s.InferOrder,
+		limits:      limits{s.Tuning.BatchSize, s.Tuning.BatchesInFlight, s.Tuning.ChunkSize},
+	}, nil
+}
+
+// runSummary adds up the responses of a run
+type runSummary struct {
+	runID    string
+	batches  int64
+	received int64
+	inserted int64
+	skipped  int64
+	rejected int64
+	filtered int64
+	// failed is the response of the batch that failed the run, if any
+	failed *flockpb.BatchInsertResponse
+}
+
+func (s *runSummary) add(res *flockpb.BatchInsertResponse) {
+	if res == nil {
+		return
+	}
+	s.batches++
+	s.received += res.RowsReceived
+	s.inserted += res.RowsInserted
+	s.skipped += res.RowsSkipped
+	s.rejected += res.RowsRejected
+	s.filtered += res.RowsFiltered
+	if !res.Success && s.failed == nil {
+		s.failed = res
+	}
+}
+
+func (s *runSummary) print(w io.Writer) {
+	fmt.Fprintf(w, "run %s: %d batches, %d rows received, %d inserted, %d skipped, %d rejected, %d filtered\n", s.runID, s.batches, s.received, s.inserted, s.skipped, s.rejected, s.filtered)
+	if f := s.failed; f != nil {
+		fmt.Fprintf(w, "batch %s of %s failed", f.BatchId, f.TableName)
+		if e := f.Error; e != nil {
+			fmt.Fprintf(w, " (%s", e.Code)
+			if e.Row >= 0 {
+				fmt.Fprintf(w, ", row %d", e.Row)
+			}
+			if e.Column != "" {
+				fmt.Fprintf(w, ", column %s", e.Column)
+			}
+			fmt.Fprintf(w, "): %s", e.Message)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// runJob runs the job of the spec file, printing its progress and summary to w
+func runJob(path string, w io.Writer) error {
+	spec, err := loadJobSpec(path)
+	if err != nil {
+		return err
+	}
+	opts, err := spec.options()
+	if err != nil {
+		return err
+	}
+	schema, err := ioutil.ReadFile(spec.Schema)
+	if err != nil {
+		return err
+	}
+	var plugin []byte
+	if spec.Plugin != "" {
+		if plugin, err = ioutil.ReadFile(spec.Plugin); err != nil {
+			return err
+		}
+	}
+	if spec.Params == nil {
+		spec.Params = make(map[string]interface{})
+	}
+	if opts.params, err = json.Marshal(spec.Params); err != nil {
+		return err
+	}
+	params, err := checkParams(schema, spec.Params)
+	if err != nil {
+		return err
+	}
+
+	sum := &runSummary{runID: spec.RunID}
+	ch := make(chan progress)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for v := range ch {
+			sum.runID = v.runID
+			switch r := v.res.(type) {
+			case *flockpb.Commit:
+				var rows int64
+				for _, n := range r.Rows {
+					rows += n
+				}
+				fmt.Fprintf(w, "[%3.0f%%] committed %d rows of %d tables\n", v.percentage*100, rows, len(r.Rows))
+			case *flockpb.BatchInsertResponse:
+				sum.add(r)
+				fmt.Fprintf(w, "[%3.0f%%] table %d, batch %d of %s: %d inserted, %d skipped, %d rejected, %d filtered (%v)\n",
+					v.percentage*100, v.tables, v.chunks, r.GetTableName(), r.GetRowsInserted(), r.GetRowsSkipped(), r.GetRowsRejected(), r.GetRowsFiltered(), v.execTime.Round(time.Millisecond))
+			}
+		}
+	}()
+
+	err = runFlockClient(spec.Server, spec.Source.URL, spec.Source.Database, spec.Destination.URL, spec.Destination.Database, opts, schema, plugin, params, ch)
+	// runFlockClient waits for its responses before returning, nothing is sent on ch anymore
+	close(ch)
+	<-done
+
+	if err == nil && sum.failed != nil {
+		err = fmt.Errorf("batch %s of %s failed", sum.failed.BatchId, sum.failed.TableName)
+	}
+	sum.print(w)
+
+	return err
+}
+
+// runCommand is the run subcommand, it returns the exit code of the process
+func runCommand(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: client run <job.yaml|job.json>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	if err := runJob(fs.Arg(0), os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "run failed: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadJobSpec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	want := &jobSpec{
+		Server:      "localhost:50051",
+		Source:      connection{URL: "postgres://source", Database: "postgres"},
+		Destination: connection{URL: "sqlserver://destination", Database: "mssql"},
+		Schema:      filepath.Join(dir, "migration.fl"),
+		Plugin:      "/plugins/plugin.go",
+		Params:      map[string]interface{}{"id": float64(1), "names": []interface{}{"a", "b"}, "opts": map[string]interface{}{"deep": true}},
+		Scope:       "table",
+		DeadLetter:  &deadLetterSpec{Sink: "file", MaxErrors: 10},
+		InferOrder:  true,
+		Tuning:      tuning{BatchSize: 50},
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr bool
+	}{
+		{"YAML", "job.yaml", `
+server: localhost:50051
+source: {url: "postgres://source", database: postgres}
+destination: {url: "sqlserver://destination", database: mssql}
+schema: migration.fl
+plugin: /plugins/plugin.go
+params:
+  id: 1
+  names: [a, b]
+  opts: {deep: true}
+scope: table
+dead_letter: {sink: file, max_errors: 10}
+infer_order: true
+tuning: {batch_size: 50}
+`, false},
+		{"JSON", "job.json", `{
+	"server": "localhost:50051",
+	"source": {"url": "postgres://source", "database": "postgres"},
+	"destination": {"url": "sqlserver://destination", "database": "mssql"},
+	"schema": "migration.fl",
+	"plugin": "/plugins/plugin.go",
+	"params": {"id": 1, "names": ["a", "b"], "opts": {"deep": true}},
+	"scope": "table",
+	"dead_letter": {"sink": "file", "max_errors": 10},
+	"infer_order": true,
+	"tuning": {"batch_size": 50}
+}`, false},
+		{"MissingServer", "job.json", `{"schema": "migration.fl", "plugin": "plugin.go"}`, true},
+		{"Invalid", "job.yml", "server: [", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := loadJobSpec(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadJobSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, want) {
+				t.Errorf("loadJobSpec() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestJobSpecOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The plugin is optional, the built-in functions need none
+	path := filepath.Join(dir, "job.yaml")
+	content := `
+server: localhost:50051
+source: {url: "postgres://source", database: postgres}
+destination: {url: "postgres://destination", database: postgres}
+schema: migration.fl
+tuning: {batch_size: 50, chunk_size: 1000}
+`
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	spec, err := loadJobSpec(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Plugin != "" {
+		t.Errorf("unexpected plugin: %s", spec.Plugin)
+	}
+
+	opts, err := spec.options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (limits{50, batchesInFlight, 1000}); opts.limits.orDefaults() != want {
+		t.Errorf("got limits %+v, want %+v", opts.limits.orDefaults(), want)
+	}
+	if rowLimit != 100 || gobLimit != 60000 {
+		t.Errorf("the defaults changed: %d rows, %d bytes", rowLimit, gobLimit)
+	}
+}
@@ -3,12 +3,17 @@ package main
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/gob"
 	"fmt"
+	"io"
 	"math"
+	"os"
+	"strings"
 	"time"
 
 	_ "github.com/denisenkom/go-mssqldb"
+	"github.com/golang/protobuf/proto"
 	"github.com/google/uuid"
 	flock "github.com/srikrsna/flock/pkg"
 	pb "github.com/srikrsna/flock/protos"
@@ -24,15 +29,82 @@ type progress struct {
 	chunks     int
 	tables     int
 	percentage float64
-	execTime time.Duration
-	res interface{}
+	execTime   time.Duration
+	res        interface{}
+	runID      string
 }
 
-var gobLimit = 60000 // Data limit in bytes to accomodate for the gRPC data transfer limit
-var rowLimit = 100   // Number of rows that will be sent at a time
+var gobLimit = 60000    // Data limit in bytes to accomodate for the gRPC data transfer limit
+var rowLimit = 100      // Number of rows that will be sent at a time
+var batchesInFlight = 4 // Number of row batches read from the source ahead of the one being sent
+
+// limits are the sizes a run is read and sent in, zero values keep the defaults above
+type limits struct {
+	batchSize       int
+	batchesInFlight int
+	chunkSize       int
+}
+
+// orDefaults returns the limits with the defaults in place of the zero values
+func (l limits) orDefaults() limits {
+	if l.batchSize <= 0 {
+		l.batchSize = rowLimit
+	}
+	if l.batchesInFlight <= 0 {
+		l.batchesInFlight = batchesInFlight
+	}
+	if l.chunkSize <= 0 {
+		l.chunkSize = gobLimit
+	}
+
+	return l
+}
 
 var records = make(map[string]int)
 
+// runOptions are the settings of a run that are passed on to the server
+type runOptions struct {
+	dialect string
+	// A run is resumed by starting it again with the same ID
+	runID       string
+	scope       pb.TransactionScope
+	commitEvery int64
+	// Rejected rows fail the run when nil
+	deadLetter *pb.DeadLetter
+	// params are the params of the run as given, in JSON. The server checks them again for the fields taking their values
+	params []byte
+	// inferOrder loads the tables referenced by the foreign keys of the destination before the ones referencing them
+	inferOrder bool
+	// limits only concern the client
+	limits limits
+}
+
+// parseScope returns the transaction scope for its name, an empty name is the run scope
+func parseScope(name string) (pb.TransactionScope, error) {
+	if name == "" {
+		return pb.TransactionScope_RUN, nil
+	}
+	scope, ok := pb.TransactionScope_value[strings.ToUpper(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown transaction scope: %s", name)
+	}
+
+	return pb.TransactionScope(scope), nil
+}
+
+// parseDeadLetter returns the dead letter settings for the name of the sink, rejected rows fail the run when it is empty
+func parseDeadLetter(sink, table string, maxErrors int64) (*pb.DeadLetter, error) {
+	if sink == "" {
+		return nil, nil
+	}
+	s, ok := pb.DeadLetter_Sink_value[strings.ToUpper(sink)]
+	if !ok {
+		return nil, fmt.Errorf("unknown dead letter sink: %s", sink)
+	}
+
+	return &pb.DeadLetter{Sink: pb.DeadLetter_Sink(s), Table: table, MaxErrors: maxErrors}, nil
+}
+
 func main() {
 
 	// FOR FUTURE REFERENCE
@@ -52,6 +124,15 @@ func main() {
 	// 	return nil
 	// }
 
+	// `client run <spec>` runs a job without the UI
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		os.Exit(runCommand(os.Args[2:]))
+	}
+	// `client ids export|import` moves the ID mappings of the server
+	if len(os.Args) > 1 && os.Args[1] == "ids" {
+		os.Exit(idsCommand(os.Args[2:]))
+	}
+
 	if err := runUIServer(); err != nil {
 		fmt.Printf("UI server terminated: %v\n", err)
 	}
@@ -60,7 +141,8 @@ func main() {
 
 // Functions implementing the relay functionality between the UI and the server
 
-func runFlockClient(serverIP, clientURL, clientDB, serverURL, serverDB string, dollar bool, schema, plugin []byte, params map[string]interface{}, ch chan progress) error {
+func runFlockClient(serverIP, clientURL, clientDB, serverURL, serverDB string, opts runOptions, schema, plugin []byte, params map[string]interface{}, ch chan progress) error {
+	limits := opts.limits.orDefaults()
 
 	// Connect to flock server
 	conn, err := grpc.Dial(serverIP, grpc.WithInsecure())
@@ -78,22 +160,80 @@ func runFlockClient(serverIP, clientURL, clientDB, serverURL, serverDB string, d
 
 	cli := pb.NewFlockClient(conn)
 
+	// Stops the source readers when returning early
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Get User Specified Query
+	fl, err := flock.ParseSchema(bytes.NewReader(schema))
+	if err != nil {
+		return err
+	}
+
+	// Parents are loaded before their children, as the after clauses of the entries and the foreign keys of the
+	// destination tell
+	var references map[string][]string
+	if opts.inferOrder {
+		if references, err = foreignKeys(ctx, cli, serverURL, serverDB, opts.dialect); err != nil {
+			return fmt.Errorf("failed to read the foreign keys of the destination: %v", err)
+		}
+	}
+	if fl.Entries, err = flock.Plan(fl, references); err != nil {
+		return err
+	}
+
+	// Get total number of tables to calculate percentage
+	numTables := len(fl.Entries)
+
+	// Bind the params of every query before sending anything so that an invalid param doesn't fail the run halfway
+	queries := make([]string, numTables)
+	queryArgs := make([][]interface{}, numTables)
+	for t, v := range fl.Entries {
+		if queries[t], queryArgs[t], err = parseQuery(v.Query, params, clientDB); err != nil {
+			return fmt.Errorf("query of %s: %v", v.Name, err)
+		}
+	}
+
+	// A column mapped by the schema but not selected by its query would only insert NULLs
+	issues, err := checkSourceColumns(ctx, db, fl, queries, queryArgs)
+	if err != nil {
+		return err
+	}
+	for _, i := range issues {
+		if i.warning {
+			fmt.Printf("warning: %s\n", i)
+		}
+	}
+	if err := columnsError(issues); err != nil {
+		return err
+	}
+
 	// Receive the client-side stream of the Flock RPC
-	fcli, err := cli.Flock(context.Background())
+	fcli, err := cli.Flock(ctx)
 	if err != nil {
 		return err
 	}
 
 	start := time.Now()
 
+	runID := opts.runID
+	if runID == "" {
+		runID = uuid.New().String()
+	}
+
 	if err := fcli.Send(&pb.FlockRequest{
 		Value: &pb.FlockRequest_Start{
 			Start: &pb.Start{
-				Url:      serverURL,
-				Database: serverDB,
-				Dollar:   dollar,
-				Schema:   schema,
-				Plugin:   plugin,
+				Url:         serverURL,
+				Database:    serverDB,
+				Dialect:     opts.dialect,
+				RunId:       runID,
+				Schema:      schema,
+				Plugin:      plugin,
+				Scope:       opts.scope,
+				CommitEvery: opts.commitEvery,
+				DeadLetter:  opts.deadLetter,
+				Params:      opts.params,
 			}}}); err != nil {
 		return err
 	}
@@ -102,47 +242,77 @@ func runFlockClient(serverIP, clientURL, clientDB, serverURL, serverDB string, d
 		return err
 	}
 
-	// Get User Specified Query
-	fl, err := flock.ParseSchema(bytes.NewReader(schema))
+	// Find out the batches committed by previous attempts of the run
+	if err := fcli.Send(&pb.FlockRequest{Value: &pb.FlockRequest_Resume{Resume: &pb.Resume{}}}); err != nil {
+		return err
+	}
+	res, err := fcli.Recv()
 	if err != nil {
 		return err
 	}
+	checkpoint := res.GetCheckpoint()
+	if checkpoint == nil {
+		return fmt.Errorf("expected a checkpoint, got: %T", res.Value)
+	}
 
-	// Get total number of tables to calculate percentage
-	numTables := len(fl.Entries)
+	// Up to limits.batchesInFlight batches are sent before their responses arrive, the responses are received
+	// in their own goroutine in the order the batches were sent
+	sent := make(chan position, limits.batchesInFlight)
+	received := make(chan struct{})
+	var recvErr error
+	go func() {
+		defer close(received)
+		recvErr = receive(fcli, sent, func(pos position, res interface{}) {
+			ch <- progress{pos.chunks, pos.tables, (float64(pos.tables) / float64(numTables)), time.Since(start), res, runID}
+		})
+		if recvErr != nil {
+			cancel()
+		}
+	}()
+	// Stops waiting for responses when returning early
+	defer func() {
+		cancel()
+		<-received
+	}()
 
 	// Iterating over all the tables
 	for t, v := range fl.Entries {
 
-		query, args := parseQuery(v.Query, params)
-
-		data, err := flockSQL.GetData(context.Background(), db, query, args)
-		if err != nil {
-			return err
-		}
-		records[v.Name] = len(data)
+		batches, errc := readBatches(ctx, db, queries[t], queryArgs[t], limits)
+		records[v.Name] = 0
 		i := 0
+		sequence := int64(0)
 		// Iterating over all row chunks
-		for len(data) > 0 {
-			var buf bytes.Buffer
-			var tempData []map[string]interface{}
-			if rowLimit <= len(data) {
-				tempData = data[0:rowLimit]
-				data = data[rowLimit:]
-			} else {
-				tempData = data
-				data = data[len(data):]
+		for tempData := range batches {
+			// Skip the batches committed before, this relies on the query returning rows in the same order
+			sequence++
+			if sequence <= checkpoint.Tables[v.Name] {
+				continue
 			}
+			// The server verifies the rows sent in this run
+			records[v.Name] += len(tempData.Rows)
 
-			if err := gob.NewEncoder(&buf).Encode(tempData); err != nil {
+			complete, err := proto.Marshal(tempData)
+			if err != nil {
 				return err
 			}
-			complete := buf.Bytes()
-			chunks := int64(math.Ceil(float64(len(complete)) / float64(gobLimit)))
+			chunks := int64(math.Ceil(float64(len(complete)) / float64(limits.chunkSize)))
 
 			// Generate UUID for the row chunk
 			batchID := uuid.New()
 
+			// Waits for room in the window of batches in flight
+			i++
+			select {
+			case sent <- position{i + 1, t + 1}:
+			case <-ctx.Done():
+				<-received
+				if recvErr != nil {
+					return recvErr
+				}
+				return ctx.Err()
+			}
+
 			// Sending the head of a data stream
 			if err := fcli.Send(&pb.FlockRequest{
 				Value: &pb.FlockRequest_Batch{
@@ -152,6 +322,8 @@ func runFlockClient(serverIP, clientURL, clientDB, serverURL, serverDB string, d
 								BatchId:   batchID.String(),
 								TableName: v.Name,
 								Chunks:    chunks,
+								Sequence:  sequence,
+								Encoding:  pb.Encoding_PROTO,
 							},
 						},
 					},
@@ -163,9 +335,9 @@ func runFlockClient(serverIP, clientURL, clientDB, serverURL, serverDB string, d
 			// Sending chunks of data stream
 			for len(complete) > 0 {
 				var tempComplete []byte
-				if gobLimit <= len(complete) {
-					tempComplete = complete[0:gobLimit]
-					complete = complete[gobLimit:]
+				if limits.chunkSize <= len(complete) {
+					tempComplete = complete[0:limits.chunkSize]
+					complete = complete[limits.chunkSize:]
 				} else {
 					tempComplete = complete
 					complete = complete[len(complete):]
@@ -202,28 +374,91 @@ func runFlockClient(serverIP, clientURL, clientDB, serverURL, serverDB string, d
 				return err
 			}
 
-			res, err := fcli.Recv()
-			if err != nil {
-				return err
-			}
-
-			//Update the UI server of the progress
-			i++
-			ch <- progress{i + 1, t + 1, (float64(t+1) / float64(numTables)), time.Since(start), res}
+		}
+		if err := <-errc; err != nil {
+			return err
 		}
 	}
 	var buf bytes.Buffer
 	if err := gob.NewEncoder(&buf).Encode(records); err != nil {
 		return err
 	}
+	// No more batches follow, the next response is the one of the end of the stream
+	close(sent)
 	if err = fcli.Send(&pb.FlockRequest{Value: &pb.FlockRequest_End{End: &pb.EndStream{Records: buf.Bytes()}}}); err != nil {
 		return err
 	}
-	_, err = fcli.Recv()
-	if err != nil {
-		return err
+	<-received
+	return recvErr
+}
+
+// position is the place of a batch in the run
+type position struct {
+	chunks int
+	tables int
+}
+
+// receive reports the responses of the batches until the server ends the stream. A batch response belongs
+// to the oldest batch sent, commits are reported with the position of the last batch answered
+func receive(fcli pb.Flock_FlockClient, sent <-chan position, report func(position, interface{})) error {
+	var pos position
+	for {
+		res, err := fcli.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if c := res.GetCommit(); c != nil {
+			report(pos, c)
+			continue
+		}
+
+		// Once every batch is answered, the response is the one of the end of the stream
+		if p, ok := <-sent; ok {
+			pos = p
+			report(pos, res.GetBatch())
+		}
 	}
-	return nil
+}
+
+// readBatches streams the results of the query in batches of the batch size of the limits while the cursor is open.
+// The error channel receives the outcome once the batch channel is closed
+func readBatches(ctx context.Context, db *sql.DB, query string, args []interface{}, limits limits) (<-chan *pb.Rows, <-chan error) {
+	batches := make(chan *pb.Rows, limits.batchesInFlight)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(batches)
+
+		cur, err := flockSQL.Query(ctx, db, query, args, limits.batchSize)
+		if err != nil {
+			errc <- err
+			return
+		}
+		defer cur.Close()
+
+		cols := flock.ColumnsOf(cur.Columns())
+		for cur.Next() {
+			rows, err := flock.EncodeRows(cols, cur.Batch())
+			if err != nil {
+				errc <- err
+				return
+			}
+
+			select {
+			case batches <- rows:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+		errc <- cur.Err()
+	}()
+
+	return batches, errc
 }
 
 func pingServer(ctx context.Context, serverIP string) error {
@@ -257,3 +492,18 @@ func pingServerDatabase(ctx context.Context, serverIP, url, database string) ([]
 	}
 	return res.Schema, nil
 }
+
+// foreignKeys returns the tables referenced by the foreign keys of every table of the destination
+func foreignKeys(ctx context.Context, cli pb.FlockClient, url, database, dialect string) (map[string][]string, error) {
+	res, err := cli.ForeignKeys(ctx, &pb.ForeignKeysRequest{Url: url, Database: database, Dialect: dialect})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string][]string, len(res.Keys))
+	for _, k := range res.Keys {
+		keys[k.Table] = k.References
+	}
+
+	return keys, nil
+}
@@ -1,19 +1,252 @@
 package main
 
 import (
+	"bytes"
 	"database/sql"
-	"regexp"
-	// "strings"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	flock "github.com/srikrsna/flock/pkg"
 )
 
-// Generates parameterized query and arguments according to the values and query provided
-func parseQuery(query string, params map[string]interface{}) (string, []interface{}) {
-	r := regexp.MustCompile(`\@([a-zA-Z]+)`)
-	namedParams := r.FindAllStringSubmatch(query, -1)
-	// query = r.ReplaceAllString(query, `?`)
-	args := make([]interface{}, 0)
-	for _, name := range namedParams {
-		args = append(args, sql.Named(name[1], params[name[1]]))
+// bindStyle is the way a source driver expects the parameters of a query
+type bindStyle int
+
+const (
+	// ? for every argument, in order
+	bindQuestion bindStyle = iota
+	// $1, $2 ... numbered arguments
+	bindDollar
+	// @p1, @p2 ... numbered arguments
+	bindAtP
+	// :name named arguments
+	bindColon
+)
+
+var bindStyles = map[string]bindStyle{
+	"postgres":         bindDollar,
+	"cloudsqlpostgres": bindDollar,
+	"pgx":              bindDollar,
+	"mysql":            bindQuestion,
+	"sqlite3":          bindQuestion,
+	"sqlite":           bindQuestion,
+	"sqlserver":        bindAtP,
+	"mssql":            bindAtP,
+	"godror":           bindColon,
+	"goracle":          bindColon,
+	"oci8":             bindColon,
+}
+
+// checkParams checks the params of a run against the declarations of its schema, see flock.CheckParams
+func checkParams(schema []byte, params map[string]interface{}) (map[string]interface{}, error) {
+	fl, err := flock.ParseSchema(bytes.NewReader(schema))
+	if err != nil {
+		return nil, err
+	}
+	declared, err := flock.DeclaredParams(fl)
+	if err != nil {
+		return nil, err
+	}
+
+	return flock.CheckParams(declared, params)
+}
+
+// parseQuery rewrites the @name parameters of the query into the placeholders of the driver and returns
+// the arguments to run it with. A parameter can be used more than once, and a list parameter is expanded
+// into one placeholder per element so that it can be used in IN (...). The @names that aren't params of the run,
+// such as the session variables of MySQL, are left to the database
+func parseQuery(query string, params map[string]interface{}, driver string) (string, []interface{}, error) {
+	style, ok := bindStyles[strings.ToLower(driver)]
+	if !ok {
+		return "", nil, fmt.Errorf("unsupported source driver: %q", driver)
+	}
+
+	var (
+		args []interface{}
+		err  error
+		// placeholders of the parameters already bound, for the styles that can refer to an argument twice
+		bound = make(map[string]string)
+	)
+	// Backslashes escape quotes in the string literals of MySQL
+	backslash := strings.EqualFold(driver, "mysql")
+	query = scanParams(query, backslash, func(name string) string {
+		if p, ok := bound[name]; ok && style != bindQuestion {
+			return p
+		}
+
+		v, ok := params[name]
+		if !ok {
+			return "@" + name
+		}
+
+		values := []interface{}{v}
+		if list, ok := listParam(v); ok {
+			if len(list) == 0 && err == nil {
+				err = fmt.Errorf("list parameter @%s is empty", name)
+			}
+			values = list
+		}
+
+		placeholders := make([]string, len(values))
+		for i, v := range values {
+			switch style {
+			case bindQuestion:
+				placeholders[i] = "?"
+				args = append(args, v)
+			case bindDollar:
+				args = append(args, v)
+				placeholders[i] = "$" + strconv.Itoa(len(args))
+			case bindAtP:
+				args = append(args, v)
+				placeholders[i] = "@p" + strconv.Itoa(len(args))
+			case bindColon:
+				argName := name
+				if len(values) > 1 {
+					argName = fmt.Sprintf("%s_%d", name, i+1)
+				}
+				args = append(args, sql.Named(argName, v))
+				placeholders[i] = ":" + argName
+			}
+		}
+
+		bound[name] = strings.Join(placeholders, ", ")
+		return bound[name]
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return query, args, nil
+}
+
+// listParam returns the elements of slice parameters, byte slices are single values
+func listParam(v interface{}) ([]interface{}, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	if rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
 	}
-	return query, args
+
+	return list, true
+}
+
+// queryParams returns the names of the parameters of the query in the order they are first used
+func queryParams(query string) []string {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	scanParams(query, false, func(name string) string {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		return ""
+	})
+
+	return names
+}
+
+// scanParams replaces every @name parameter of the query with the result of replace. String literals, quoted
+// identifiers, comments and @@ variables are left as they are. With backslash, a backslash escapes the next
+// character of a string literal
+func scanParams(query string, backslash bool, replace func(name string) string) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			j := quoted(query, i+1, c, backslash && c != '`')
+			buf.WriteString(query[i:j])
+			i = j
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			j := strings.IndexByte(query[i:], '\n')
+			if j == -1 {
+				j = len(query) - i
+			}
+			buf.WriteString(query[i : i+j])
+			i += j
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			j := strings.Index(query[i+2:], "*/")
+			if j == -1 {
+				j = len(query) - i
+			} else {
+				j += 4
+			}
+			buf.WriteString(query[i : i+j])
+			i += j
+		case c == '$':
+			// Dollar quoted strings of postgres, $tag$ ... $tag$
+			j := i + 1
+			for j < len(query) && isIdent(query[j], j > i+1) {
+				j++
+			}
+			if j < len(query) && query[j] == '$' {
+				tag := query[i : j+1]
+				k := strings.Index(query[j+1:], tag)
+				if k == -1 {
+					k = len(query)
+				} else {
+					k += j + 1 + len(tag)
+				}
+				buf.WriteString(query[i:k])
+				i = k
+				continue
+			}
+			buf.WriteByte(c)
+			i++
+		case c == '@' && strings.HasPrefix(query[i:], "@@"):
+			// System variables such as @@IDENTITY
+			j := i + 2
+			for j < len(query) && isIdent(query[j], true) {
+				j++
+			}
+			buf.WriteString(query[i:j])
+			i = j
+		case c == '@' && i+1 < len(query) && isIdent(query[i+1], false):
+			j := i + 1
+			for j < len(query) && isIdent(query[j], true) {
+				j++
+			}
+			buf.WriteString(replace(query[i+1 : j]))
+			i = j
+		default:
+			buf.WriteByte(c)
+			i++
+		}
+	}
+
+	return buf.String()
+}
+
+// quoted returns the index right after the closing quote of a literal starting at i, a doubled quote is part of it
+// as is the character after a backslash with backslash
+func quoted(query string, i int, end byte, backslash bool) int {
+	for i < len(query) {
+		if backslash && query[i] == '\\' {
+			i += 2
+			continue
+		}
+		if query[i] == end {
+			if i+1 < len(query) && query[i+1] == end {
+				i += 2
+				continue
+			}
+			return i + 1
+		}
+		i++
+	}
+
+	return len(query)
+}
+
+func isIdent(c byte, digits bool) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || digits && '0' <= c && c <= '9'
 }
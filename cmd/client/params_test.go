@@ -1,7 +1,9 @@
 package main
 
 import (
+	"database/sql"
 	"os"
+	"reflect"
 	"testing"
 
 	flock "github.com/srikrsna/flock/pkg"
@@ -19,7 +21,60 @@ func TestParseQuery(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unable to parse schema file: %v", err)
 	}
-	query, args := parseQuery(fl.Entries[0].Query, params)
+	query, args, err := parseQuery(fl.Entries[0].Query, params, "postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
 	t.Logf(query)
 	t.Log(args)
 }
+
+func TestParseQueryDrivers(t *testing.T) {
+	params := map[string]interface{}{"id": 1, "name": "a", "ids": []interface{}{1, 2, 3}, "blob": []byte("x")}
+
+	tests := []struct {
+		name      string
+		driver    string
+		query     string
+		wantQuery string
+		wantArgs  []interface{}
+		wantErr   bool
+	}{
+		{"Postgres", "postgres", "SELECT * FROM t WHERE id = @id AND name = @name", "SELECT * FROM t WHERE id = $1 AND name = $2", []interface{}{1, "a"}, false},
+		{"PostgresRepeated", "postgres", "SELECT @id, @name, @id", "SELECT $1, $2, $1", []interface{}{1, "a"}, false},
+		{"PostgresList", "postgres", "SELECT * FROM t WHERE id IN (@ids) OR id = @id", "SELECT * FROM t WHERE id IN ($1, $2, $3) OR id = $4", []interface{}{1, 2, 3, 1}, false},
+		{"MySQLRepeated", "mysql", "SELECT @id, @name, @id", "SELECT ?, ?, ?", []interface{}{1, "a", 1}, false},
+		{"SQLiteList", "sqlite3", "SELECT * FROM t WHERE id IN (@ids)", "SELECT * FROM t WHERE id IN (?, ?, ?)", []interface{}{1, 2, 3}, false},
+		{"SQLServer", "sqlserver", "SELECT @id, @name, @id, @@IDENTITY", "SELECT @p1, @p2, @p1, @@IDENTITY", []interface{}{1, "a"}, false},
+		{"Oracle", "godror", "SELECT * FROM t WHERE id IN (@ids) AND id <> @id", "SELECT * FROM t WHERE id IN (:ids_1, :ids_2, :ids_3) AND id <> :id", []interface{}{sql.Named("ids_1", 1), sql.Named("ids_2", 2), sql.Named("ids_3", 3), sql.Named("id", 1)}, false},
+		{"Bytes", "mysql", "SELECT @blob", "SELECT ?", []interface{}{[]byte("x")}, false},
+		{"Literals", "postgres", `SELECT '@id', 'it''s @id', "@id", $$ @id $$, $q$ @id $q$ FROM t WHERE id = @id`, `SELECT '@id', 'it''s @id', "@id", $$ @id $$, $q$ @id $q$ FROM t WHERE id = $1`, []interface{}{1}, false},
+		{"Comments", "postgres", "SELECT @id -- @name\n/* @name */ FROM t", "SELECT $1 -- @name\n/* @name */ FROM t", []interface{}{1}, false},
+		{"Casts", "postgres", "SELECT @id::int, a[@id]", "SELECT $1::int, a[$1]", []interface{}{1}, false},
+		{"Unknown", "mysql", "SET @rownum = 0; SELECT @rownum := @rownum + 1, @id", "SET @rownum = 0; SELECT @rownum := @rownum + 1, ?", []interface{}{1}, false},
+		{"UnknownPostgres", "postgres", "SELECT @missing, @id", "SELECT @missing, $1", []interface{}{1}, false},
+		{"MySQLBackslash", "mysql", `SELECT 'it\'s @id', "say \"@id\"", '\\', @id`, `SELECT 'it\'s @id', "say \"@id\"", '\\', ?`, []interface{}{1}, false},
+		{"PostgresBackslash", "postgres", `SELECT 'C:\', @id`, `SELECT 'C:\', $1`, []interface{}{1}, false},
+		{"EmptyList", "postgres", "SELECT @empty", "", nil, true},
+		{"UnknownDriver", "unknown", "SELECT 1", "", nil, true},
+	}
+
+	params["empty"] = []string{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args, err := parseQuery(tt.query, params, tt.driver)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if query != tt.wantQuery {
+				t.Errorf("parseQuery() query = %q, want %q", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("parseQuery() args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -25,16 +25,30 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 type ReportRequest struct {
-	Server               *Server   `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
-	ServerDB             *ServerDB `protobuf:"bytes,2,opt,name=serverDB,proto3" json:"serverDB,omitempty"`
-	ClientDB             *ClientDB `protobuf:"bytes,3,opt,name=clientDB,proto3" json:"clientDB,omitempty"`
-	Dollar               bool      `protobuf:"varint,4,opt,name=dollar,proto3" json:"dollar,omitempty"`
-	Flock                []byte    `protobuf:"bytes,5,opt,name=flock,proto3" json:"flock,omitempty"`
-	Params               []byte    `protobuf:"bytes,6,opt,name=params,proto3" json:"params,omitempty"`
-	Plugin               []byte    `protobuf:"bytes,7,opt,name=plugin,proto3" json:"plugin,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
-	XXX_unrecognized     []byte    `json:"-"`
-	XXX_sizecache        int32     `json:"-"`
+	Server   *Server   `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	ServerDB *ServerDB `protobuf:"bytes,2,opt,name=serverDB,proto3" json:"serverDB,omitempty"`
+	ClientDB *ClientDB `protobuf:"bytes,3,opt,name=clientDB,proto3" json:"clientDB,omitempty"`
+	Flock    []byte    `protobuf:"bytes,5,opt,name=flock,proto3" json:"flock,omitempty"`
+	Params   []byte    `protobuf:"bytes,6,opt,name=params,proto3" json:"params,omitempty"`
+	Plugin   []byte    `protobuf:"bytes,7,opt,name=plugin,proto3" json:"plugin,omitempty"`
+	Dialect  string    `protobuf:"bytes,8,opt,name=dialect,proto3" json:"dialect,omitempty"`
+	// Run to resume, a new run is started when empty
+	RunId string `protobuf:"bytes,9,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	// Transaction scope: run, table or batch
+	Scope string `protobuf:"bytes,10,opt,name=scope,proto3" json:"scope,omitempty"`
+	// Number of batches in a transaction for the batch scope
+	CommitEvery int64 `protobuf:"varint,11,opt,name=commit_every,json=commitEvery,proto3" json:"commit_every,omitempty"`
+	// Sink of the rows rejected by the run: table or file, rejected rows fail the run when empty
+	DeadLetterSink string `protobuf:"bytes,12,opt,name=dead_letter_sink,json=deadLetterSink,proto3" json:"dead_letter_sink,omitempty"`
+	// Destination table of the table sink
+	DeadLetterTable string `protobuf:"bytes,13,opt,name=dead_letter_table,json=deadLetterTable,proto3" json:"dead_letter_table,omitempty"`
+	// Number of rows the run may reject, 0 for no limit
+	MaxErrors int64 `protobuf:"varint,14,opt,name=max_errors,json=maxErrors,proto3" json:"max_errors,omitempty"`
+	// Also load the tables referenced by the foreign keys of the destination before the ones referencing them
+	InferOrder           bool     `protobuf:"varint,15,opt,name=infer_order,json=inferOrder,proto3" json:"infer_order,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *ReportRequest) Reset()         { *m = ReportRequest{} }
@@ -83,13 +97,6 @@ func (m *ReportRequest) GetClientDB() *ClientDB {
 	return nil
 }
 
-func (m *ReportRequest) GetDollar() bool {
-	if m != nil {
-		return m.Dollar
-	}
-	return false
-}
-
 func (m *ReportRequest) GetFlock() []byte {
 	if m != nil {
 		return m.Flock
@@ -111,13 +118,76 @@ func (m *ReportRequest) GetPlugin() []byte {
 	return nil
 }
 
+func (m *ReportRequest) GetDialect() string {
+	if m != nil {
+		return m.Dialect
+	}
+	return ""
+}
+
+func (m *ReportRequest) GetRunId() string {
+	if m != nil {
+		return m.RunId
+	}
+	return ""
+}
+
+func (m *ReportRequest) GetScope() string {
+	if m != nil {
+		return m.Scope
+	}
+	return ""
+}
+
+func (m *ReportRequest) GetCommitEvery() int64 {
+	if m != nil {
+		return m.CommitEvery
+	}
+	return 0
+}
+
+func (m *ReportRequest) GetDeadLetterSink() string {
+	if m != nil {
+		return m.DeadLetterSink
+	}
+	return ""
+}
+
+func (m *ReportRequest) GetDeadLetterTable() string {
+	if m != nil {
+		return m.DeadLetterTable
+	}
+	return ""
+}
+
+func (m *ReportRequest) GetMaxErrors() int64 {
+	if m != nil {
+		return m.MaxErrors
+	}
+	return 0
+}
+
+func (m *ReportRequest) GetInferOrder() bool {
+	if m != nil {
+		return m.InferOrder
+	}
+	return false
+}
+
 type ReportResponse struct {
-	Chunks               int64    `protobuf:"varint,1,opt,name=chunks,proto3" json:"chunks,omitempty"`
-	Tables               int64    `protobuf:"varint,2,opt,name=tables,proto3" json:"tables,omitempty"`
-	Percentage           int64    `protobuf:"varint,3,opt,name=percentage,proto3" json:"percentage,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Chunks     int64  `protobuf:"varint,1,opt,name=chunks,proto3" json:"chunks,omitempty"`
+	Tables     int64  `protobuf:"varint,2,opt,name=tables,proto3" json:"tables,omitempty"`
+	Percentage int64  `protobuf:"varint,3,opt,name=percentage,proto3" json:"percentage,omitempty"`
+	RunId      string `protobuf:"bytes,4,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	// Last batch committed for every table
+	CommittedBatches map[string]int64 `protobuf:"bytes,5,rep,name=committed_batches,json=committedBatches,proto3" json:"committed_batches,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// Rows committed for every table
+	CommittedRows map[string]int64 `protobuf:"bytes,6,rep,name=committed_rows,json=committedRows,proto3" json:"committed_rows,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// Result of the last batch answered by the server
+	Batch                *BatchResult `protobuf:"bytes,7,opt,name=batch,proto3" json:"batch,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
 }
 
 func (m *ReportResponse) Reset()         { *m = ReportResponse{} }
@@ -166,6 +236,164 @@ func (m *ReportResponse) GetPercentage() int64 {
 	return 0
 }
 
+func (m *ReportResponse) GetRunId() string {
+	if m != nil {
+		return m.RunId
+	}
+	return ""
+}
+
+func (m *ReportResponse) GetCommittedBatches() map[string]int64 {
+	if m != nil {
+		return m.CommittedBatches
+	}
+	return nil
+}
+
+func (m *ReportResponse) GetCommittedRows() map[string]int64 {
+	if m != nil {
+		return m.CommittedRows
+	}
+	return nil
+}
+
+func (m *ReportResponse) GetBatch() *BatchResult {
+	if m != nil {
+		return m.Batch
+	}
+	return nil
+}
+
+type BatchResult struct {
+	BatchId      string `protobuf:"bytes,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	Table        string `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`
+	RowsReceived int64  `protobuf:"varint,3,opt,name=rows_received,json=rowsReceived,proto3" json:"rows_received,omitempty"`
+	RowsInserted int64  `protobuf:"varint,4,opt,name=rows_inserted,json=rowsInserted,proto3" json:"rows_inserted,omitempty"`
+	RowsSkipped  int64  `protobuf:"varint,5,opt,name=rows_skipped,json=rowsSkipped,proto3" json:"rows_skipped,omitempty"`
+	Success      bool   `protobuf:"varint,6,opt,name=success,proto3" json:"success,omitempty"`
+	// Set when the batch failed
+	ErrorCode    string `protobuf:"bytes,7,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	ErrorMessage string `protobuf:"bytes,8,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	// Index of the failing row in the batch, -1 when the error isn't tied to a row
+	ErrorRow     int64  `protobuf:"varint,9,opt,name=error_row,json=errorRow,proto3" json:"error_row,omitempty"`
+	ErrorColumn  string `protobuf:"bytes,10,opt,name=error_column,json=errorColumn,proto3" json:"error_column,omitempty"`
+	RowsRejected int64  `protobuf:"varint,11,opt,name=rows_rejected,json=rowsRejected,proto3" json:"rows_rejected,omitempty"`
+	// Rows left out by the where clause of the entry
+	RowsFiltered         int64    `protobuf:"varint,12,opt,name=rows_filtered,json=rowsFiltered,proto3" json:"rows_filtered,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BatchResult) Reset()         { *m = BatchResult{} }
+func (m *BatchResult) String() string { return proto.CompactTextString(m) }
+func (*BatchResult) ProtoMessage()    {}
+func (*BatchResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_63867a62624c1283, []int{2}
+}
+
+func (m *BatchResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchResult.Unmarshal(m, b)
+}
+func (m *BatchResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchResult.Marshal(b, m, deterministic)
+}
+func (m *BatchResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchResult.Merge(m, src)
+}
+func (m *BatchResult) XXX_Size() int {
+	return xxx_messageInfo_BatchResult.Size(m)
+}
+func (m *BatchResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchResult proto.InternalMessageInfo
+
+func (m *BatchResult) GetBatchId() string {
+	if m != nil {
+		return m.BatchId
+	}
+	return ""
+}
+
+func (m *BatchResult) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *BatchResult) GetRowsReceived() int64 {
+	if m != nil {
+		return m.RowsReceived
+	}
+	return 0
+}
+
+func (m *BatchResult) GetRowsInserted() int64 {
+	if m != nil {
+		return m.RowsInserted
+	}
+	return 0
+}
+
+func (m *BatchResult) GetRowsSkipped() int64 {
+	if m != nil {
+		return m.RowsSkipped
+	}
+	return 0
+}
+
+func (m *BatchResult) GetSuccess() bool {
+	if m != nil {
+		return m.Success
+	}
+	return false
+}
+
+func (m *BatchResult) GetErrorCode() string {
+	if m != nil {
+		return m.ErrorCode
+	}
+	return ""
+}
+
+func (m *BatchResult) GetErrorMessage() string {
+	if m != nil {
+		return m.ErrorMessage
+	}
+	return ""
+}
+
+func (m *BatchResult) GetErrorRow() int64 {
+	if m != nil {
+		return m.ErrorRow
+	}
+	return 0
+}
+
+func (m *BatchResult) GetErrorColumn() string {
+	if m != nil {
+		return m.ErrorColumn
+	}
+	return ""
+}
+
+func (m *BatchResult) GetRowsRejected() int64 {
+	if m != nil {
+		return m.RowsRejected
+	}
+	return 0
+}
+
+func (m *BatchResult) GetRowsFiltered() int64 {
+	if m != nil {
+		return m.RowsFiltered
+	}
+	return 0
+}
+
 type PingRequest struct {
 	// Types that are valid to be assigned to Value:
 	//	*PingRequest_Server
@@ -181,7 +409,7 @@ func (m *PingRequest) Reset()         { *m = PingRequest{} }
 func (m *PingRequest) String() string { return proto.CompactTextString(m) }
 func (*PingRequest) ProtoMessage()    {}
 func (*PingRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_63867a62624c1283, []int{2}
+	return fileDescriptor_63867a62624c1283, []int{3}
 }
 
 func (m *PingRequest) XXX_Unmarshal(b []byte) error {
@@ -272,7 +500,7 @@ func (m *PingResponse) Reset()         { *m = PingResponse{} }
 func (m *PingResponse) String() string { return proto.CompactTextString(m) }
 func (*PingResponse) ProtoMessage()    {}
 func (*PingResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_63867a62624c1283, []int{3}
+	return fileDescriptor_63867a62624c1283, []int{4}
 }
 
 func (m *PingResponse) XXX_Unmarshal(b []byte) error {
@@ -301,7 +529,9 @@ func (m *PingResponse) GetSchema() []byte {
 }
 
 type SchemaFile struct {
-	File                 []byte   `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	File []byte `protobuf:"bytes,1,opt,name=file,proto3" json:"file,omitempty"`
+	// The function calls of the schema are checked against the built-in functions and the ones of the plugin when it is given
+	Plugin               []byte   `protobuf:"bytes,2,opt,name=plugin,proto3" json:"plugin,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -311,7 +541,7 @@ func (m *SchemaFile) Reset()         { *m = SchemaFile{} }
 func (m *SchemaFile) String() string { return proto.CompactTextString(m) }
 func (*SchemaFile) ProtoMessage()    {}
 func (*SchemaFile) Descriptor() ([]byte, []int) {
-	return fileDescriptor_63867a62624c1283, []int{4}
+	return fileDescriptor_63867a62624c1283, []int{5}
 }
 
 func (m *SchemaFile) XXX_Unmarshal(b []byte) error {
@@ -339,8 +569,17 @@ func (m *SchemaFile) GetFile() []byte {
 	return nil
 }
 
+func (m *SchemaFile) GetPlugin() []byte {
+	if m != nil {
+		return m.Plugin
+	}
+	return nil
+}
+
 type SchemaResponse struct {
-	Params               []string `protobuf:"bytes,2,rep,name=params,proto3" json:"params,omitempty"`
+	Params []string `protobuf:"bytes,2,rep,name=params,proto3" json:"params,omitempty"`
+	// Params declared by the params directive of the schema
+	Declarations         []*Param `protobuf:"bytes,3,rep,name=declarations,proto3" json:"declarations,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -350,7 +589,7 @@ func (m *SchemaResponse) Reset()         { *m = SchemaResponse{} }
 func (m *SchemaResponse) String() string { return proto.CompactTextString(m) }
 func (*SchemaResponse) ProtoMessage()    {}
 func (*SchemaResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_63867a62624c1283, []int{5}
+	return fileDescriptor_63867a62624c1283, []int{6}
 }
 
 func (m *SchemaResponse) XXX_Unmarshal(b []byte) error {
@@ -378,6 +617,103 @@ func (m *SchemaResponse) GetParams() []string {
 	return nil
 }
 
+func (m *SchemaResponse) GetDeclarations() []*Param {
+	if m != nil {
+		return m.Declarations
+	}
+	return nil
+}
+
+type Param struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// string, int, float, bool, date or time
+	Type string `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	// A list holds several values of the type
+	List       bool   `protobuf:"varint,3,opt,name=list,proto3" json:"list,omitempty"`
+	Required   bool   `protobuf:"varint,4,opt,name=required,proto3" json:"required,omitempty"`
+	HasDefault bool   `protobuf:"varint,5,opt,name=has_default,json=hasDefault,proto3" json:"has_default,omitempty"`
+	Default    string `protobuf:"bytes,6,opt,name=default,proto3" json:"default,omitempty"`
+	// Regular expression the values must match, empty when any value is allowed
+	Pattern              string   `protobuf:"bytes,7,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Param) Reset()         { *m = Param{} }
+func (m *Param) String() string { return proto.CompactTextString(m) }
+func (*Param) ProtoMessage()    {}
+func (*Param) Descriptor() ([]byte, []int) {
+	return fileDescriptor_63867a62624c1283, []int{7}
+}
+
+func (m *Param) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Param.Unmarshal(m, b)
+}
+func (m *Param) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Param.Marshal(b, m, deterministic)
+}
+func (m *Param) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Param.Merge(m, src)
+}
+func (m *Param) XXX_Size() int {
+	return xxx_messageInfo_Param.Size(m)
+}
+func (m *Param) XXX_DiscardUnknown() {
+	xxx_messageInfo_Param.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Param proto.InternalMessageInfo
+
+func (m *Param) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Param) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *Param) GetList() bool {
+	if m != nil {
+		return m.List
+	}
+	return false
+}
+
+func (m *Param) GetRequired() bool {
+	if m != nil {
+		return m.Required
+	}
+	return false
+}
+
+func (m *Param) GetHasDefault() bool {
+	if m != nil {
+		return m.HasDefault
+	}
+	return false
+}
+
+func (m *Param) GetDefault() string {
+	if m != nil {
+		return m.Default
+	}
+	return ""
+}
+
+func (m *Param) GetPattern() string {
+	if m != nil {
+		return m.Pattern
+	}
+	return ""
+}
+
 type PluginRequest struct {
 	Server               *Server  `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
 	Plugin               []byte   `protobuf:"bytes,2,opt,name=plugin,proto3" json:"plugin,omitempty"`
@@ -390,7 +726,7 @@ func (m *PluginRequest) Reset()         { *m = PluginRequest{} }
 func (m *PluginRequest) String() string { return proto.CompactTextString(m) }
 func (*PluginRequest) ProtoMessage()    {}
 func (*PluginRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_63867a62624c1283, []int{6}
+	return fileDescriptor_63867a62624c1283, []int{8}
 }
 
 func (m *PluginRequest) XXX_Unmarshal(b []byte) error {
@@ -435,7 +771,7 @@ func (m *PluginResponse) Reset()         { *m = PluginResponse{} }
 func (m *PluginResponse) String() string { return proto.CompactTextString(m) }
 func (*PluginResponse) ProtoMessage()    {}
 func (*PluginResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_63867a62624c1283, []int{7}
+	return fileDescriptor_63867a62624c1283, []int{9}
 }
 
 func (m *PluginResponse) XXX_Unmarshal(b []byte) error {
@@ -467,7 +803,7 @@ func (m *Server) Reset()         { *m = Server{} }
 func (m *Server) String() string { return proto.CompactTextString(m) }
 func (*Server) ProtoMessage()    {}
 func (*Server) Descriptor() ([]byte, []int) {
-	return fileDescriptor_63867a62624c1283, []int{8}
+	return fileDescriptor_63867a62624c1283, []int{10}
 }
 
 func (m *Server) XXX_Unmarshal(b []byte) error {
@@ -507,7 +843,7 @@ func (m *ClientDB) Reset()         { *m = ClientDB{} }
 func (m *ClientDB) String() string { return proto.CompactTextString(m) }
 func (*ClientDB) ProtoMessage()    {}
 func (*ClientDB) Descriptor() ([]byte, []int) {
-	return fileDescriptor_63867a62624c1283, []int{9}
+	return fileDescriptor_63867a62624c1283, []int{11}
 }
 
 func (m *ClientDB) XXX_Unmarshal(b []byte) error {
@@ -555,7 +891,7 @@ func (m *ServerDB) Reset()         { *m = ServerDB{} }
 func (m *ServerDB) String() string { return proto.CompactTextString(m) }
 func (*ServerDB) ProtoMessage()    {}
 func (*ServerDB) Descriptor() ([]byte, []int) {
-	return fileDescriptor_63867a62624c1283, []int{10}
+	return fileDescriptor_63867a62624c1283, []int{12}
 }
 
 func (m *ServerDB) XXX_Unmarshal(b []byte) error {
@@ -600,10 +936,14 @@ func (m *ServerDB) GetDatabase() string {
 func init() {
 	proto.RegisterType((*ReportRequest)(nil), "UIproto.ReportRequest")
 	proto.RegisterType((*ReportResponse)(nil), "UIproto.ReportResponse")
+	proto.RegisterMapType((map[string]int64)(nil), "UIproto.ReportResponse.CommittedBatchesEntry")
+	proto.RegisterMapType((map[string]int64)(nil), "UIproto.ReportResponse.CommittedRowsEntry")
+	proto.RegisterType((*BatchResult)(nil), "UIproto.BatchResult")
 	proto.RegisterType((*PingRequest)(nil), "UIproto.PingRequest")
 	proto.RegisterType((*PingResponse)(nil), "UIproto.PingResponse")
 	proto.RegisterType((*SchemaFile)(nil), "UIproto.SchemaFile")
 	proto.RegisterType((*SchemaResponse)(nil), "UIproto.SchemaResponse")
+	proto.RegisterType((*Param)(nil), "UIproto.Param")
 	proto.RegisterType((*PluginRequest)(nil), "UIproto.PluginRequest")
 	proto.RegisterType((*PluginResponse)(nil), "UIproto.PluginResponse")
 	proto.RegisterType((*Server)(nil), "UIproto.Server")
@@ -614,39 +954,73 @@ func init() {
 func init() { proto.RegisterFile("ui.proto", fileDescriptor_63867a62624c1283) }
 
 var fileDescriptor_63867a62624c1283 = []byte{
-	// 500 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0x4d, 0x73, 0xd3, 0x30,
-	0x10, 0xad, 0xed, 0xc4, 0x71, 0xb6, 0x69, 0x28, 0xa2, 0x14, 0x4f, 0x0e, 0x8c, 0x47, 0x07, 0x30,
-	0x07, 0x52, 0xa6, 0xbd, 0xf0, 0x71, 0x0b, 0x19, 0xa6, 0xbd, 0x65, 0x54, 0x7a, 0x47, 0x71, 0xd5,
-	0xd4, 0x53, 0xd5, 0x36, 0x96, 0xdd, 0x3f, 0xc4, 0xef, 0xe3, 0xc6, 0x0f, 0x60, 0xbc, 0x96, 0x1c,
-	0x39, 0x43, 0x07, 0xb8, 0xed, 0x3e, 0xbd, 0xdd, 0xd5, 0xbe, 0x67, 0x19, 0x82, 0x3a, 0x9d, 0x17,
-	0x65, 0x5e, 0xe5, 0x64, 0x74, 0x75, 0x81, 0x01, 0xfd, 0xe5, 0xc0, 0x01, 0x13, 0x45, 0x5e, 0x56,
-	0x4c, 0x7c, 0xaf, 0x85, 0xaa, 0xc8, 0x6b, 0xf0, 0x95, 0x28, 0x1f, 0x44, 0x19, 0x3a, 0x91, 0x13,
-	0xef, 0x9f, 0x3e, 0x99, 0x6b, 0xee, 0xfc, 0x12, 0x61, 0xa6, 0x8f, 0xc9, 0x5b, 0x08, 0xda, 0x68,
-	0xb9, 0x08, 0x5d, 0xa4, 0x3e, 0xdd, 0xa1, 0x2e, 0x17, 0xac, 0xa3, 0x34, 0xf4, 0x44, 0xa6, 0x22,
-	0xab, 0x96, 0x8b, 0xd0, 0xdb, 0xa1, 0x7f, 0xd6, 0x07, 0xac, 0xa3, 0x90, 0x63, 0xf0, 0xaf, 0x73,
-	0x29, 0x79, 0x19, 0x0e, 0x22, 0x27, 0x0e, 0x98, 0xce, 0xc8, 0x11, 0x0c, 0x6f, 0x64, 0x9e, 0xdc,
-	0x85, 0xc3, 0xc8, 0x89, 0x27, 0xac, 0x4d, 0x1a, 0x76, 0xc1, 0x4b, 0x7e, 0xaf, 0x42, 0x1f, 0x61,
-	0x9d, 0x21, 0x2e, 0xeb, 0x4d, 0x9a, 0x85, 0x23, 0x8d, 0x63, 0x46, 0xbf, 0xc1, 0xd4, 0x6c, 0xad,
-	0x8a, 0x3c, 0x53, 0xa2, 0x61, 0x26, 0xb7, 0x75, 0x76, 0xa7, 0x70, 0x6d, 0x8f, 0xe9, 0xac, 0xc1,
-	0x2b, 0xbe, 0x96, 0x42, 0xe1, 0x8e, 0x1e, 0xd3, 0x19, 0x79, 0x09, 0x50, 0x88, 0x32, 0x11, 0x59,
-	0xc5, 0x37, 0x02, 0x17, 0xf2, 0x98, 0x85, 0xd0, 0x1f, 0x0e, 0xec, 0xaf, 0xd2, 0x6c, 0x63, 0x64,
-	0x7d, 0xf3, 0x17, 0x59, 0xcf, 0xf7, 0x3a, 0x61, 0x4f, 0x2c, 0xa5, 0xdc, 0x47, 0x94, 0x3a, 0xdf,
-	0xb3, 0xb4, 0x3a, 0xb1, 0x9c, 0xf0, 0x1e, 0x71, 0xa2, 0x29, 0x30, 0xa4, 0xc5, 0x08, 0x86, 0x0f,
-	0x5c, 0xd6, 0x82, 0xbe, 0x82, 0x49, 0x7b, 0xc9, 0xad, 0x0a, 0x2a, 0xb9, 0x15, 0xf7, 0x1c, 0x6f,
-	0x39, 0x61, 0x3a, 0xa3, 0x11, 0xc0, 0x25, 0x46, 0x5f, 0x52, 0x29, 0x08, 0x81, 0xc1, 0x4d, 0x2a,
-	0x85, 0xe6, 0x60, 0x4c, 0x63, 0x98, 0xb6, 0x0c, 0xbb, 0x97, 0xf6, 0xc4, 0x8d, 0xbc, 0x78, 0x6c,
-	0x3c, 0xa1, 0x2b, 0x38, 0x58, 0xa1, 0x0b, 0xff, 0xfd, 0xc5, 0x6d, 0xdd, 0x74, 0x7b, 0x6e, 0x1e,
-	0xc2, 0xd4, 0x74, 0x6c, 0x67, 0xd3, 0x10, 0xfc, 0xb6, 0x96, 0x4c, 0xc1, 0x4d, 0x0b, 0x6c, 0x3c,
-	0x66, 0x6e, 0x5a, 0xd0, 0xf7, 0x10, 0x18, 0x0d, 0xc9, 0x21, 0x78, 0x75, 0x29, 0xf5, 0x61, 0x13,
-	0x92, 0x19, 0x04, 0xd7, 0xbc, 0xe2, 0x6b, 0xae, 0x04, 0xce, 0x18, 0xb3, 0x2e, 0xa7, 0x1c, 0x02,
-	0x23, 0xe6, 0xbf, 0x5f, 0x59, 0x8f, 0x70, 0xff, 0x3c, 0xc2, 0xeb, 0x8f, 0x38, 0xfd, 0xe9, 0x80,
-	0x7b, 0x75, 0x41, 0xce, 0x60, 0xd0, 0xb8, 0x42, 0x8e, 0xba, 0xae, 0xd6, 0x97, 0x34, 0x7b, 0xbe,
-	0x83, 0x6a, 0xb9, 0x3f, 0x1a, 0x8b, 0xbe, 0x36, 0x9a, 0x3e, 0xdb, 0x5e, 0xa8, 0xf3, 0x6d, 0xf6,
-	0x62, 0x07, 0xec, 0x6a, 0x3f, 0x80, 0xdf, 0x0a, 0x48, 0x8e, 0xb7, 0xcd, 0x6d, 0x8f, 0xac, 0xd2,
-	0xbe, 0xd2, 0xe4, 0x13, 0xf8, 0xed, 0x4b, 0xb2, 0x4a, 0x7b, 0x3f, 0x14, 0xab, 0xb4, 0xff, 0xe4,
-	0xde, 0x39, 0x6b, 0x1f, 0xf1, 0xb3, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff, 0xa9, 0x03, 0xac, 0x0d,
-	0x99, 0x04, 0x00, 0x00,
+	// 1050 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x56, 0xdd, 0x72, 0xdb, 0x44,
+	0x14, 0xae, 0xad, 0xd8, 0x91, 0x8f, 0x1d, 0x27, 0x59, 0x9a, 0x46, 0x98, 0x01, 0x8c, 0x98, 0x01,
+	0x93, 0x99, 0xa6, 0x4c, 0x7a, 0x13, 0xca, 0x0d, 0x93, 0x9f, 0x4e, 0xc3, 0xc0, 0x10, 0x36, 0xed,
+	0x0d, 0xc3, 0x8c, 0x67, 0x23, 0x9d, 0x24, 0xc2, 0xb2, 0xa4, 0xee, 0xae, 0x92, 0xe6, 0x39, 0x78,
+	0x04, 0x5e, 0x81, 0x97, 0xe1, 0x96, 0x7b, 0xde, 0x81, 0xd9, 0xb3, 0x2b, 0x59, 0x49, 0x9a, 0x69,
+	0x7b, 0xb7, 0xe7, 0xdb, 0x6f, 0xcf, 0x1e, 0x7f, 0xe7, 0xdb, 0x23, 0x83, 0x5f, 0x26, 0xdb, 0x85,
+	0xcc, 0x75, 0xce, 0x96, 0x5f, 0x1d, 0xd1, 0x22, 0xfc, 0xd7, 0x83, 0x15, 0x8e, 0x45, 0x2e, 0x35,
+	0xc7, 0xd7, 0x25, 0x2a, 0xcd, 0xbe, 0x86, 0xae, 0x42, 0x79, 0x89, 0x32, 0x68, 0x8d, 0x5b, 0x93,
+	0xfe, 0xce, 0xea, 0xb6, 0xe3, 0x6e, 0x9f, 0x10, 0xcc, 0xdd, 0x36, 0x7b, 0x0c, 0xbe, 0x5d, 0x1d,
+	0xec, 0x05, 0x6d, 0xa2, 0xae, 0xdf, 0xa2, 0x1e, 0xec, 0xf1, 0x9a, 0x62, 0xe8, 0x51, 0x9a, 0x60,
+	0xa6, 0x0f, 0xf6, 0x02, 0xef, 0x16, 0x7d, 0xdf, 0x6d, 0xf0, 0x9a, 0xc2, 0x1e, 0x42, 0xe7, 0x2c,
+	0xcd, 0xa3, 0x59, 0xd0, 0x19, 0xb7, 0x26, 0x03, 0x6e, 0x03, 0xf6, 0x08, 0xba, 0x85, 0x90, 0x62,
+	0xae, 0x82, 0x2e, 0xc1, 0x2e, 0x22, 0x3c, 0x2d, 0xcf, 0x93, 0x2c, 0x58, 0x76, 0x38, 0x45, 0x2c,
+	0x80, 0xe5, 0x38, 0x11, 0x29, 0x46, 0x3a, 0xf0, 0xc7, 0xad, 0x49, 0x8f, 0x57, 0x21, 0xdb, 0x80,
+	0xae, 0x2c, 0xb3, 0x69, 0x12, 0x07, 0x3d, 0xda, 0xe8, 0xc8, 0x32, 0x3b, 0x8a, 0xcd, 0xb5, 0x2a,
+	0xca, 0x0b, 0x0c, 0xc0, 0xa2, 0x14, 0xb0, 0x2f, 0x60, 0x10, 0xe5, 0xf3, 0x79, 0xa2, 0xa7, 0x78,
+	0x89, 0xf2, 0x3a, 0xe8, 0x8f, 0x5b, 0x13, 0x8f, 0xf7, 0x2d, 0x76, 0x68, 0x20, 0x36, 0x81, 0xb5,
+	0x18, 0x45, 0x3c, 0x4d, 0x51, 0x6b, 0x94, 0x53, 0x95, 0x64, 0xb3, 0x60, 0x40, 0x39, 0x86, 0x06,
+	0xff, 0x89, 0xe0, 0x93, 0x24, 0x9b, 0xb1, 0x2d, 0x58, 0x6f, 0x32, 0xb5, 0x38, 0x4d, 0x31, 0x58,
+	0x21, 0xea, 0xea, 0x82, 0xfa, 0xd2, 0xc0, 0xec, 0x53, 0x80, 0xb9, 0x78, 0x33, 0x45, 0x29, 0x73,
+	0xa9, 0x82, 0x21, 0x5d, 0xdb, 0x9b, 0x8b, 0x37, 0x87, 0x04, 0xb0, 0xcf, 0xa1, 0x9f, 0x64, 0x67,
+	0x28, 0xa7, 0xb9, 0x8c, 0x51, 0x06, 0xab, 0xe3, 0xd6, 0xc4, 0xe7, 0x40, 0xd0, 0x2f, 0x06, 0xf9,
+	0x71, 0xc9, 0x5f, 0x5a, 0xeb, 0x84, 0xff, 0x78, 0x30, 0xac, 0x9a, 0xac, 0x8a, 0x3c, 0x53, 0x68,
+	0x04, 0x8b, 0x2e, 0xca, 0x6c, 0xa6, 0xa8, 0xcb, 0x1e, 0x77, 0x91, 0xc1, 0xa9, 0x20, 0x45, 0x2d,
+	0xf5, 0xb8, 0x8b, 0xd8, 0x67, 0x00, 0x05, 0xca, 0x08, 0x33, 0x2d, 0xce, 0x91, 0xfa, 0xe7, 0xf1,
+	0x06, 0xd2, 0x90, 0x73, 0xa9, 0x29, 0xe7, 0x6f, 0xb0, 0x6e, 0x45, 0xd2, 0x18, 0x4f, 0x4f, 0x85,
+	0x8e, 0x2e, 0x50, 0x05, 0x9d, 0xb1, 0x37, 0xe9, 0xef, 0x3c, 0xae, 0xbb, 0x7f, 0xb3, 0xb4, 0xed,
+	0xfd, 0xea, 0xc0, 0x9e, 0xe5, 0x1f, 0x66, 0x5a, 0x5e, 0xf3, 0xb5, 0xe8, 0x16, 0xcc, 0x7e, 0x85,
+	0xe1, 0x22, 0xb7, 0xcc, 0xaf, 0x8c, 0x27, 0x4c, 0xe2, 0xad, 0x77, 0x26, 0xe6, 0xf9, 0x95, 0xcb,
+	0xba, 0x12, 0x35, 0x31, 0xb6, 0x05, 0x1d, 0x2a, 0x92, 0x5c, 0xd4, 0xdf, 0x79, 0x58, 0x67, 0xa2,
+	0x3b, 0x39, 0xaa, 0x32, 0xd5, 0xdc, 0x52, 0x46, 0xfb, 0xb0, 0xf1, 0xd6, 0x4a, 0xd9, 0x1a, 0x78,
+	0x33, 0xbc, 0x26, 0x5d, 0x7b, 0xdc, 0x2c, 0x8d, 0xa9, 0x2e, 0x45, 0x5a, 0xa2, 0xd3, 0xd4, 0x06,
+	0xcf, 0xda, 0xbb, 0xad, 0xd1, 0x0f, 0xc0, 0xee, 0x56, 0xf5, 0x21, 0x19, 0xc2, 0x3f, 0x3d, 0xe8,
+	0x37, 0xaa, 0x63, 0x1f, 0x83, 0x4f, 0xf5, 0x99, 0x56, 0xd8, 0x04, 0xcb, 0x14, 0x5b, 0x6f, 0x5b,
+	0xb3, 0xb5, 0x6d, 0x8b, 0x28, 0x60, 0x5f, 0xc2, 0x8a, 0x11, 0x6f, 0x2a, 0x31, 0xc2, 0xe4, 0x12,
+	0x63, 0xd7, 0xdc, 0x81, 0x01, 0xb9, 0xc3, 0x6a, 0x52, 0x92, 0x29, 0x94, 0x1a, 0x6d, 0x97, 0x1d,
+	0xe9, 0xc8, 0x61, 0xe6, 0x95, 0x10, 0x49, 0xcd, 0x92, 0xa2, 0xc0, 0x98, 0x5e, 0xae, 0xc7, 0xfb,
+	0x06, 0x3b, 0xb1, 0x90, 0x79, 0x8f, 0xaa, 0x8c, 0x22, 0x54, 0xf6, 0x01, 0xfb, 0xbc, 0x0a, 0x8d,
+	0xd3, 0xc9, 0xe5, 0xd3, 0x28, 0x8f, 0x91, 0xf4, 0xef, 0xf1, 0x1e, 0x21, 0xfb, 0x79, 0x4c, 0x55,
+	0xda, 0xed, 0x39, 0x2a, 0x65, 0x2c, 0x68, 0x9f, 0xf3, 0x80, 0xc0, 0x9f, 0x2d, 0xc6, 0x3e, 0x01,
+	0x7b, 0xc2, 0xb8, 0x81, 0x9e, 0xb5, 0xc7, 0x7d, 0x02, 0x78, 0x7e, 0x65, 0xaa, 0xab, 0x2e, 0x48,
+	0xcb, 0x79, 0xe6, 0x1e, 0x78, 0xdf, 0x5d, 0x61, 0xa0, 0x86, 0x14, 0x7f, 0x60, 0x64, 0x7e, 0x65,
+	0xbf, 0x29, 0x85, 0xc5, 0x6a, 0xd2, 0x59, 0x92, 0x6a, 0x94, 0x18, 0x07, 0x83, 0x05, 0xe9, 0xb9,
+	0xc3, 0xc2, 0xbf, 0x5a, 0xd0, 0x3f, 0x4e, 0xb2, 0xf3, 0x6a, 0xa8, 0x7e, 0xf3, 0x8e, 0xa1, 0xfa,
+	0xe2, 0x41, 0x3d, 0x56, 0x9f, 0x34, 0xe6, 0x64, 0xfb, 0x9e, 0x39, 0xf9, 0xe2, 0x41, 0x63, 0x52,
+	0x3e, 0x69, 0xcc, 0x61, 0xef, 0x9e, 0x39, 0x6c, 0x0e, 0x54, 0xa4, 0xbd, 0x65, 0x67, 0xa6, 0xf0,
+	0x2b, 0x18, 0xd8, 0x22, 0x17, 0x43, 0x41, 0x45, 0x17, 0x38, 0x17, 0x54, 0xe5, 0x80, 0xbb, 0x28,
+	0xdc, 0x05, 0x38, 0xa1, 0xd5, 0xf3, 0x24, 0x45, 0xc6, 0x60, 0xe9, 0x2c, 0x49, 0xd1, 0x71, 0x68,
+	0xdd, 0x98, 0xbf, 0xed, 0xe6, 0xfc, 0x0d, 0x7f, 0x87, 0xa1, 0x3d, 0xd9, 0xbc, 0xc3, 0x4d, 0xf0,
+	0xf6, 0xd8, 0x9b, 0xf4, 0xea, 0x09, 0xbe, 0x03, 0x83, 0x18, 0xa3, 0x54, 0x48, 0xa1, 0x93, 0x3c,
+	0x53, 0x81, 0x47, 0x6f, 0x79, 0x58, 0xff, 0x92, 0x63, 0x43, 0xe3, 0x37, 0x38, 0xe1, 0xdf, 0x2d,
+	0xe8, 0x10, 0x6e, 0x6a, 0xca, 0xc4, 0x1c, 0x9d, 0xe3, 0x69, 0x6d, 0x30, 0x7d, 0x5d, 0x54, 0x6e,
+	0xa7, 0xb5, 0xc1, 0xd2, 0x44, 0x69, 0xd2, 0xc9, 0xe7, 0xb4, 0x66, 0x23, 0xf0, 0x25, 0xbe, 0x2e,
+	0x13, 0xe9, 0x6c, 0xed, 0xf3, 0x3a, 0x36, 0x03, 0xf6, 0x42, 0xa8, 0x69, 0x8c, 0x67, 0xa2, 0x4c,
+	0x35, 0x39, 0xda, 0xe7, 0x70, 0x21, 0xd4, 0x81, 0x45, 0xe8, 0x03, 0xe3, 0x36, 0xbb, 0xee, 0x03,
+	0xb3, 0xd8, 0x29, 0x84, 0xd6, 0x28, 0x33, 0xe7, 0xe6, 0x2a, 0x0c, 0x8f, 0x61, 0xe5, 0x98, 0xe4,
+	0xf9, 0xe0, 0x4f, 0xee, 0x7d, 0x32, 0xaf, 0xc1, 0xb0, 0xca, 0x68, 0x65, 0x0e, 0x03, 0xe8, 0xda,
+	0xb3, 0x6c, 0x08, 0xed, 0xa4, 0x70, 0xc2, 0xb4, 0x93, 0x22, 0xdc, 0x05, 0xbf, 0xb2, 0x91, 0x19,
+	0x34, 0xa5, 0x4c, 0xdd, 0xa6, 0x59, 0x1a, 0x31, 0x62, 0xa1, 0xc5, 0xa9, 0x50, 0x95, 0x70, 0x75,
+	0x1c, 0x0a, 0xf0, 0x2b, 0x3f, 0xbd, 0x7f, 0xc9, 0xee, 0x8a, 0xf6, 0xdb, 0xaf, 0xf0, 0x6e, 0x5e,
+	0xb1, 0xf3, 0x5f, 0x0b, 0xda, 0xaf, 0x8e, 0xd8, 0x53, 0x58, 0x32, 0xc6, 0x64, 0x8b, 0x01, 0xdc,
+	0x78, 0x4c, 0xa3, 0x8d, 0x5b, 0xa8, 0x73, 0xd6, 0xb3, 0xca, 0xa5, 0x2f, 0x8d, 0xa6, 0x1f, 0x2d,
+	0x0a, 0xaa, 0xad, 0x3b, 0xda, 0xbc, 0x05, 0xd6, 0x67, 0xbf, 0x83, 0xae, 0x15, 0x90, 0x3d, 0x5a,
+	0x24, 0x6f, 0xf6, 0x68, 0xb4, 0x79, 0x07, 0x77, 0x47, 0xbf, 0x87, 0xae, 0xfd, 0xce, 0x34, 0x8e,
+	0xde, 0xf8, 0x47, 0x35, 0xda, 0xbc, 0x83, 0xdb, 0xa3, 0xdf, 0xb6, 0x4e, 0xbb, 0x84, 0x3f, 0xfd,
+	0x7f, 0x00, 0x62, 0xb5, 0x94, 0x39, 0x9a, 0x09, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -9,6 +9,8 @@ import (
 	"github.com/improbable-eng/grpc-web/go/grpcweb"
 	"github.com/rs/cors"
 	pb "github.com/srikrsna/flock/cmd/client/protos"
+	flock "github.com/srikrsna/flock/pkg"
+	flockpb "github.com/srikrsna/flock/protos"
 	flockSQL "github.com/srikrsna/flock/sql"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
@@ -62,12 +64,34 @@ func (s *Server) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PingRespons
 // SchemaTest ...
 func (s *Server) SchemaTest(ctx context.Context, req *pb.SchemaFile) (*pb.SchemaResponse, error) {
 	// defer s.Logger.Sync()
-	params, err := testSchema(req.File)
+	params, declared, err := testSchema(req.File, req.Plugin)
 	if err != nil {
 		s.Logger.Error("failed to parse schema", zap.String("error", err.Error()))
+		if _, ok := err.(*flock.ValidationError); ok {
+			return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
 		return nil, err
 	}
-	return &pb.SchemaResponse{Params: params}, nil
+	res := &pb.SchemaResponse{Params: params, Declarations: make([]*pb.Param, 0, len(declared))}
+	for _, p := range declared {
+		res.Declarations = append(res.Declarations, paramDeclaration(p))
+	}
+	return res, nil
+}
+
+// paramDeclaration converts the declaration of a param for the UI
+func paramDeclaration(p *flock.Param) *pb.Param {
+	res := &pb.Param{Name: p.Name, Type: p.Type, List: p.List, Required: p.Required}
+	if p.Default != nil {
+		v, _ := p.Default.Value()
+		res.HasDefault = true
+		res.Default = fmt.Sprint(v)
+	}
+	if p.Pattern != nil {
+		res.Pattern = *p.Pattern
+	}
+
+	return res
 }
 
 // Plugin ...
@@ -91,9 +115,35 @@ func (s *Server) Report(req *pb.ReportRequest, srv pb.UI_ReportServer) error {
 		s.Logger.Error("failed to parse params", zap.String("error", err.Error()))
 		return err
 	}
+	// Bad params are rejected before connecting to anything
+	params, err := checkParams(req.Flock, params)
+	if err != nil {
+		s.Logger.Error("invalid params", zap.String("error", err.Error()))
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	scope, err := parseScope(req.Scope)
+	if err != nil {
+		s.Logger.Error("failed to parse transaction scope", zap.String("error", err.Error()))
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	deadLetter, err := parseDeadLetter(req.DeadLetterSink, req.DeadLetterTable, req.MaxErrors)
+	if err != nil {
+		s.Logger.Error("failed to parse dead letter sink", zap.String("error", err.Error()))
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	opts := runOptions{dialect: req.Dialect, runID: req.RunId, scope: scope, commitEvery: req.CommitEvery, deadLetter: deadLetter, params: req.Params, inferOrder: req.InferOrder}
+
 	go func() {
 		for v := range progChan {
-			if err := srv.Send(&pb.ReportResponse{Chunks: int64(v.chunks), Tables: int64(v.tables), Percentage: int64(v.percentage * 100)}); err != nil {
+			res := &pb.ReportResponse{Chunks: int64(v.chunks), Tables: int64(v.tables), Percentage: int64(v.percentage * 100), RunId: v.runID}
+			switch r := v.res.(type) {
+			case *flockpb.Commit:
+				res.CommittedBatches = r.Batches
+				res.CommittedRows = r.Rows
+			case *flockpb.BatchInsertResponse:
+				res.Batch = batchResult(r)
+			}
+			if err := srv.Send(res); err != nil {
 				s.Logger.Error("unable to send progress report to UI", zap.String("error", err.Error()))
 				return
 			}
@@ -101,7 +151,7 @@ func (s *Server) Report(req *pb.ReportRequest, srv pb.UI_ReportServer) error {
 		}
 	}()
 
-	if err := runFlockClient(req.Server.Ip, req.ClientDB.Url, req.ClientDB.Database, req.ServerDB.Url, req.ServerDB.Database, req.Dollar, req.Flock, req.Plugin, params, progChan); err != nil {
+	if err := runFlockClient(req.Server.Ip, req.ClientDB.Url, req.ClientDB.Database, req.ServerDB.Url, req.ServerDB.Database, opts, req.Flock, req.Plugin, params, progChan); err != nil {
 		s.Logger.Error("failed to transfer data", zap.String("error", err.Error()))
 		return err
 	}
@@ -109,6 +159,29 @@ func (s *Server) Report(req *pb.ReportRequest, srv pb.UI_ReportServer) error {
 	return nil
 }
 
+// batchResult converts the response of a batch for the UI
+func batchResult(r *flockpb.BatchInsertResponse) *pb.BatchResult {
+	res := &pb.BatchResult{
+		BatchId:      r.BatchId,
+		Table:        r.TableName,
+		RowsReceived: r.RowsReceived,
+		RowsInserted: r.RowsInserted,
+		RowsSkipped:  r.RowsSkipped,
+		RowsRejected: r.RowsRejected,
+		RowsFiltered: r.RowsFiltered,
+		Success:      r.Success,
+		ErrorRow:     -1,
+	}
+	if r.Error != nil {
+		res.ErrorCode = r.Error.Code.String()
+		res.ErrorMessage = r.Error.Message
+		res.ErrorRow = r.Error.Row
+		res.ErrorColumn = r.Error.Column
+	}
+
+	return res
+}
+
 func runUIServer() error {
 	log, err := zap.NewDevelopment()
 	if err != nil {
@@ -3,13 +3,13 @@ package main
 import (
 	"bytes"
 	"fmt"
-	"regexp"
 
 	flock "github.com/srikrsna/flock/pkg"
 )
 
-// Reads the flock file and extracts the named parameters
-func testSchema(f []byte) ([]string, error) {
+// Reads the flock file and extracts the named parameters along with their declarations.
+// The function calls are checked when the plugin is given, as the functions aren't known without it
+func testSchema(f, plugin []byte) ([]string, []*flock.Param, error) {
 
 	buf := bytes.NewBuffer(f)
 
@@ -17,17 +17,51 @@ func testSchema(f []byte) ([]string, error) {
 	fl, err := flock.ParseSchema(buf)
 	if err != nil {
 		fmt.Println("Failed to parse schema. Please check your .fl file.")
-		return params, err
+		return params, nil, err
 	}
 
-	r := regexp.MustCompile(`\@([a-zA-Z]+)`)
+	declared, err := flock.DeclaredParams(fl)
+	if err != nil {
+		return params, nil, err
+	}
+	known := make(map[string]bool, len(declared))
+	for _, p := range declared {
+		known[p.Name] = true
+	}
+
+	// A param used by several queries is only asked for once
+	seen := make(map[string]bool)
 	for _, v := range fl.Entries {
-		param := r.FindAllStringSubmatch(v.Query, -1)
-		for _, v := range param {
-			params = append(params, v[1])
+		for _, p := range queryParams(v.Query) {
+			// The @names that aren't declared, such as session variables, are left to the database
+			if len(declared) > 0 && !known[p] {
+				continue
+			}
+			if !seen[p] {
+				seen[p] = true
+				params = append(params, p)
+			}
+		}
+	}
+	// The after clauses must name entries and leave no cycle
+	if _, err := flock.Plan(fl, nil); err != nil {
+		return params, nil, err
+	}
+	if len(plugin) > 0 {
+		funcs := flock.DefaultFuncs()
+		fm, err := flock.PluginHandler(plugin)
+		if err != nil {
+			return params, nil, err
+		}
+		if err := funcs.Register(fm); err != nil {
+			return params, nil, err
+		}
+		if err := flock.Validate(fl, funcs.Map()); err != nil {
+			return params, nil, err
 		}
 	}
-	return params, nil
+
+	return params, declared, nil
 }
 
 func testPlugin(f []byte) error {
@@ -8,11 +8,12 @@ import (
 
 func TestTestSchema(t *testing.T) {
 	tests := []struct {
-		name   string
-		input  string
-		output []string
+		name     string
+		input    string
+		output   []string
+		declared []string
 	}{
-		{"Test-1", "./schema_test.fl", []string{"first", "theird"}},
+		{"Test-1", "./schema_test.fl", []string{"first", "theird"}, []string{"first", "theird"}},
 	}
 	for _, v := range tests {
 		t.Run(v.name, func(t *testing.T) {
@@ -20,11 +21,32 @@ func TestTestSchema(t *testing.T) {
 			if err != nil {
 				t.Error(err.Error())
 			}
-			params, err := testSchema(f)
+			params, declared, err := testSchema(f, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
 
 			if !reflect.DeepEqual(params, v.output) {
 				t.Errorf("output did not match, expected: %v, got: %v", v.output, params)
 			}
+			names := make([]string, 0, len(declared))
+			for _, p := range declared {
+				names = append(names, p.Name)
+			}
+			if !reflect.DeepEqual(names, v.declared) {
+				t.Errorf("declarations did not match, expected: %v, got: %v", v.declared, names)
+			}
 		})
 	}
 }
+
+func TestTestSchemaUndeclared(t *testing.T) {
+	schema := "params ( $first string )\nRandom {\n `SELECT @rownum := @rownum + 1, * FROM Random WHERE First = @first`\n {\n - First = one\n }\n}"
+	params, _, err := testSchema([]byte(schema), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(params, []string{"first"}) {
+		t.Errorf("expected the session variables to be left out of the params, got: %v", params)
+	}
+}
@@ -1,16 +1,14 @@
 package main
 
 import (
-	"database/sql"
 	"flag"
 	"fmt"
 	"log"
 	"net"
-	"strconv"
+	"runtime"
 
 	_ "github.com/GoogleCloudPlatform/cloudsql-proxy/proxy/dialers/postgres"
 	"github.com/dgraph-io/badger"
-	"github.com/elgris/sqrl"
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 	flock "github.com/srikrsna/flock/pkg"
@@ -20,30 +18,58 @@ import (
 	"google.golang.org/grpc"
 )
 
-var db *badger.DB
+var checkpoints = flag.String("checkpoints", "", "directory of the badger database keeping the checkpoints of runs, runs can only be resumed within the process when empty")
+
+var workers = flag.Int("workers", runtime.NumCPU(), "number of batches of a run prepared concurrently")
+var pending = flag.Int("pending", 2*runtime.NumCPU(), "number of batches of a run held in memory before the server stops reading the stream")
+
+var deadLetters = flag.String("dead-letters", "", "directory of the NDJSON files of the rows rejected by runs using the file sink")
+
+var ids = flag.String("ids", "memory", "mapper of the ToGuid function: memory, badger, sql (a table of the destination database) or uuidv5")
+var idsDir = flag.String("ids-dir", "", "directory of the badger database of the badger ID mapper, it can be the one of the checkpoints")
+var idsSchema = flag.String("ids-schema", "guid", "destination schema of the tables of the sql ID mapper, a table per mapped table")
+var idsNamespace = flag.String("ids-namespace", flock.DefaultIDNamespace.String(), "namespace of the UUIDs of the uuidv5 ID mapper")
 
 func main() {
 	log.SetFlags(0)
 	flag.Parse()
 
-	// opts := badger.DefaultOptions
-	// opts.Dir = "/tmp/badger"
-	// opts.ValueDir = "/tmp/badger"
-	// var err error
-	// db, err = badger.Open(opts)
-	// if err != nil {
-	// 	log.Fatal(err)
-	// }
-	// defer db.Close()
+	// Badger databases are opened once even when the checkpoints and the IDs share a directory
+	dbs := make(map[string]*badger.DB)
+	openBadger := func(dir string) *badger.DB {
+		if db, ok := dbs[dir]; ok {
+			return db
+		}
+		opts := badger.DefaultOptions
+		opts.Dir = dir
+		opts.ValueDir = dir
+		db, err := badger.Open(opts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		dbs[dir] = db
+		return db
+	}
+	defer func() {
+		for _, db := range dbs {
+			db.Close()
+		}
+	}()
+
+	store := server.NewMemoryCheckpoints()
+	if *checkpoints != "" {
+		store = server.NewBadgerCheckpoints(openBadger(*checkpoints))
+	}
 
-	srv, err := makeServer()
+	idMappers, err := makeIDs(openBadger)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	flock.RegisterFunc(flock.FuncMap{
-		"ToGuid": inMemory(),
-	})
+	srv, err := makeServer(store, idMappers)
+	if err != nil {
+		log.Fatalln(err)
+	}
 
 	s := grpc.NewServer()
 	pb.RegisterFlockServer(s, srv)
@@ -60,7 +86,7 @@ func main() {
 	}
 }
 
-func makeServer() (*server.Server, error) {
+func makeServer(store server.CheckpointStore, idMappers func(server.DB, flock.Dialect) (flock.IDMapper, error)) (*server.Server, error) {
 
 	// FOR FUTURE REFERENCE
 	// u := &url.URL{
@@ -71,10 +97,6 @@ func makeServer() (*server.Server, error) {
 	// 	RawQuery: fmt.Sprintf("sslmode=%s&connect_timeout=%d", "disable", 3),
 	// }
 
-	// flock.RegisterFunc(flock.FuncMap{
-	// 	"Nil": Nil,
-	// })
-
 	log, err := zap.NewDevelopment()
 	if err != nil {
 		return nil, err
@@ -83,96 +105,44 @@ func makeServer() (*server.Server, error) {
 	// TODO : Add syncs and tweak the logger
 
 	return &server.Server{
-		Logger: log,
+		Logger:            log,
+		Checkpoints:       store,
+		Workers:           *workers,
+		MaxPendingBatches: *pending,
+		DeadLetterDir:     *deadLetters,
+		IDs:               idMappers,
 	}, nil
 }
 
-func inMemory() func(table string, oldID int64) (string, error) {
-	f := func(table string, oldID int64) (string, error) {
-		key := table + strconv.FormatInt(oldID, 10)
-
-		id, ok := keys[key]
-		if ok {
-			return id, nil
+// makeIDs returns the ID mapper of the runs chosen by the flags
+func makeIDs(openBadger func(dir string) *badger.DB) (func(server.DB, flock.Dialect) (flock.IDMapper, error), error) {
+	var m flock.IDMapper
+	switch *ids {
+	case "memory":
+		m = flock.NewMemoryIDs()
+	case "badger":
+		if *idsDir == "" {
+			return nil, fmt.Errorf("the badger ID mapper needs -ids-dir")
 		}
-
-		id = uuid.Must(uuid.NewUUID()).String()
-
-		keys[key] = id
-
-		return id, nil
-	}
-
-	return f
-}
-
-func bGuid(db *badger.DB) func(table string, oldID int64) (string, error) {
-	f := func(table string, oldID int64) (string, error) {
-		key := []byte(table + strconv.FormatInt(oldID, 10))
-		tx := db.NewTransaction(true)
-		defer tx.Commit(nil)
-
-		i, err := tx.Get(key)
+		m = flock.NewBadgerIDs(openBadger(*idsDir))
+	case "uuidv5":
+		ns, err := uuid.Parse(*idsNamespace)
 		if err != nil {
-			if err == badger.ErrKeyNotFound {
-				ui := uuid.Must(uuid.NewUUID())
-				if err := tx.Set(key, ui[:]); err != nil {
-					return "", err
-				}
-
-				return ui.String(), nil
-			}
-		}
-
-		ui, err := i.Value()
-		if err != nil {
-			return "", err
-		}
-
-		return string(ui), nil
-	}
-
-	return f
-}
-
-var keys = map[string]string{}
-
-func toGuid(db *sql.DB) func(table string, oldID int64) (string, error) {
-	f := func(table string, oldID int64) (string, error) {
-		key := table + strconv.FormatInt(oldID, 10)
-		id, ok := keys[key]
-		if ok {
-			return id, nil
-		}
-
-		err := sqrl.Select("new").From("guid." + table).Where(sqrl.Eq{"old": oldID}).PlaceholderFormat(sqrl.Dollar).RunWith(db).QueryRow().Scan(&id)
-		if err == nil {
-			keys[key] = id
-			return id, nil
-		}
-
-		if err != sql.ErrNoRows {
-			return "", err
+			return nil, fmt.Errorf("invalid ID namespace: %v", err)
 		}
-
-		id = uuid.Must(uuid.NewUUID()).String()
-		if _, err := sqrl.Insert("guid."+table).Columns("old", "new").Values(oldID, id).PlaceholderFormat(sqrl.Dollar).RunWith(db).Exec(); err != nil {
-			return "", err
-		}
-
-		keys[key] = id
-
-		return id, nil
-	}
-
-	return f
-}
-
-// Nil ...
-func Nil(a interface{}, b interface{}) interface{} {
-	if b == nil {
-		return a
+		m = flock.NewUUIDv5IDs(ns)
+	case "sql":
+		// Every run keeps the IDs in its own destination
+		return func(db server.DB, dialect flock.Dialect) (flock.IDMapper, error) {
+			if db == nil {
+				return nil, fmt.Errorf("the sql ID mapper needs the destination database")
+			}
+			return flock.NewSQLIDs(db, *idsSchema, dialect), nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown ID mapper: %s", *ids)
 	}
 
-	return b
+	// The other mappers are shared by every run
+	return func(server.DB, flock.Dialect) (flock.IDMapper, error) { return m, nil }, nil
 }
@@ -0,0 +1,542 @@
+package flock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Builtins are the functions every schema can call without a plugin. As with any function, the value of the
+// column is the last argument, after the ones written in the schema. Most of them return NULL for NULL
+//
+//   - name = Name | TrimSpace | Title
+//   - created = Created | ParseDate "02/01/2006" | InTimezone "Asia/Kolkata"
+var Builtins = FuncMap{
+	// NULLs
+	"Nil":      Nil,
+	"Default":  Default,
+	"Coalesce": Coalesce,
+	"NullIf":   NullIf,
+	"Const":    Const,
+
+	// Strings
+	"TrimSpace": TrimSpace,
+	"Trim":      Trim,
+	"Upper":     Upper,
+	"Lower":     Lower,
+	"Title":     Title,
+	"Substring": Substring,
+	"Replace":   Replace,
+	"Split":     Split,
+
+	// Regular expressions
+	"RegexExtract": RegexExtract,
+	"RegexReplace": RegexReplace,
+
+	// Numbers
+	"ParseInt":     ParseInt,
+	"ParseFloat":   ParseFloat,
+	"FormatNumber": FormatNumber,
+
+	// Dates
+	"Now":            Now,
+	"ParseDate":      ParseDate,
+	"FormatDate":     FormatDate,
+	"InTimezone":     InTimezone,
+	"AssumeTimezone": AssumeTimezone,
+
+	// Booleans
+	"ParseBool": ParseBool,
+	"MapBool":   MapBool,
+
+	// Hashes
+	"SHA256": SHA256,
+	"Bcrypt": Bcrypt,
+
+	// JSON
+	"JSONEncode": JSONEncode,
+	"JSONDecode": JSONDecode,
+	"JSONGet":    JSONGet,
+
+	// IDs, the server replaces it with the mapper it is configured with
+	"ToGuid":  ToGuid(defaultIDs),
+	"NewUUID": NewUUID,
+}
+
+func init() {
+	RegisterFunc(Builtins)
+}
+
+// Nil returns def when v is NULL
+func Nil(def interface{}, v interface{}) interface{} {
+	if v == nil {
+		return def
+	}
+
+	return v
+}
+
+// Default returns def when v is NULL or an empty string
+func Default(def interface{}, v interface{}) interface{} {
+	if s, ok := text(v); v == nil || ok && s == "" {
+		return def
+	}
+
+	return v
+}
+
+// Coalesce returns the first of its arguments that isn't NULL
+func Coalesce(values ...interface{}) interface{} {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// NullIf returns NULL when v equals null
+func NullIf(null interface{}, v interface{}) interface{} {
+	if v == nil || reflect.DeepEqual(null, v) || fmt.Sprint(null) == fmt.Sprint(v) {
+		return nil
+	}
+
+	return v
+}
+
+// Const returns c whatever the value of the column
+func Const(c interface{}, v interface{}) interface{} {
+	return c
+}
+
+// text returns v as a string if it holds text
+func text(v interface{}) (string, bool) {
+	switch s := v.(type) {
+	case string:
+		return s, true
+	case []byte:
+		return string(s), true
+	}
+
+	return "", false
+}
+
+// toString returns the text of v or its default formatting
+func toString(v interface{}) string {
+	if s, ok := text(v); ok {
+		return s
+	}
+
+	return fmt.Sprint(v)
+}
+
+// mapString applies f to v as a string, NULL stays NULL
+func mapString(v interface{}, f func(string) string) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	return f(toString(v))
+}
+
+// TrimSpace removes the leading and trailing white space of v
+func TrimSpace(v interface{}) interface{} {
+	return mapString(v, strings.TrimSpace)
+}
+
+// Trim removes the leading and trailing characters of v found in cutset
+func Trim(cutset string, v interface{}) interface{} {
+	return mapString(v, func(s string) string { return strings.Trim(s, cutset) })
+}
+
+// Upper returns v in upper case
+func Upper(v interface{}) interface{} {
+	return mapString(v, strings.ToUpper)
+}
+
+// Lower returns v in lower case
+func Lower(v interface{}) interface{} {
+	return mapString(v, strings.ToLower)
+}
+
+// Title returns v with the first letter of every word in upper case and the others in lower case
+func Title(v interface{}) interface{} {
+	return mapString(v, func(s string) string {
+		words := strings.Fields(strings.ToLower(s))
+		for i, w := range words {
+			r := []rune(w)
+			words[i] = strings.ToUpper(string(r[0])) + string(r[1:])
+		}
+		return strings.Join(words, " ")
+	})
+}
+
+// Substring returns length characters of v starting from the character at start, counting from 0.
+// A negative length takes every character up to the end
+func Substring(start, length int64, v interface{}) (interface{}, error) {
+	if start < 0 {
+		return nil, fmt.Errorf("negative start: %d", start)
+	}
+
+	return mapString(v, func(s string) string {
+		r := []rune(s)
+		if start >= int64(len(r)) {
+			return ""
+		}
+		r = r[start:]
+		if length >= 0 && length < int64(len(r)) {
+			r = r[:length]
+		}
+		return string(r)
+	}), nil
+}
+
+// Replace replaces every old in v with new
+func Replace(old, new string, v interface{}) interface{} {
+	return mapString(v, func(s string) string { return strings.Replace(s, old, new, -1) })
+}
+
+// Split splits v at every sep, NULL and the empty string have no parts. It is meant to explode a row with each
+func Split(sep string, v interface{}) []string {
+	if v == nil {
+		return nil
+	}
+	s := toString(v)
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, sep)
+}
+
+// regexps caches the expressions compiled by the regular expression functions, they are called for every row
+var regexps sync.Map
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexps.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexps.Store(pattern, re)
+
+	return re, nil
+}
+
+// RegexExtract returns the first match of the pattern in v, or its first group when it has one. It returns NULL when nothing matches
+func RegexExtract(pattern string, v interface{}) (interface{}, error) {
+	re, err := compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+
+	m := re.FindStringSubmatch(toString(v))
+	switch {
+	case m == nil:
+		return nil, nil
+	case len(m) > 1:
+		return m[1], nil
+	default:
+		return m[0], nil
+	}
+}
+
+// RegexReplace replaces the matches of the pattern in v with repl, which can refer to the groups as $1
+func RegexReplace(pattern, repl string, v interface{}) (interface{}, error) {
+	re, err := compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapString(v, func(s string) string { return re.ReplaceAllString(s, repl) }), nil
+}
+
+// ParseInt converts v to an integer, surrounding white space is ignored
+func ParseInt(v interface{}) (interface{}, error) {
+	switch n := v.(type) {
+	case nil:
+		return nil, nil
+	case int64:
+		return n, nil
+	case float64:
+		if n != float64(int64(n)) {
+			return nil, fmt.Errorf("%v is not an integer", n)
+		}
+		return int64(n), nil
+	}
+
+	return strconv.ParseInt(strings.TrimSpace(toString(v)), 10, 64)
+}
+
+// ParseFloat converts v to a floating point number, surrounding white space is ignored
+func ParseFloat(v interface{}) (interface{}, error) {
+	switch n := v.(type) {
+	case nil:
+		return nil, nil
+	case int64:
+		return float64(n), nil
+	case float64:
+		return n, nil
+	}
+
+	return strconv.ParseFloat(strings.TrimSpace(toString(v)), 64)
+}
+
+// FormatNumber formats v with a fmt verb such as %.2f or %05d. Text is parsed as a number first
+func FormatNumber(format string, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	if s, ok := text(v); ok {
+		var err error
+		if strings.ContainsAny(s, ".eE") {
+			v, err = ParseFloat(s)
+		} else {
+			v, err = ParseInt(s)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Integers are allowed in float formats and the other way around
+	switch n := v.(type) {
+	case int64:
+		if strings.ContainsAny(format, "eEfFgG") {
+			v = float64(n)
+		}
+	case float64:
+		if strings.ContainsAny(format, "dxXob") && n == float64(int64(n)) {
+			v = int64(n)
+		}
+	}
+
+	return fmt.Sprintf(format, v), nil
+}
+
+// dateLayouts are tried in order when a date is given as text without a layout
+var dateLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05", "2006-01-02"}
+
+// toTime returns v as a time, text is parsed with the layout or the common layouts when it is empty
+func toTime(layout string, v interface{}) (time.Time, error) {
+	if t, ok := v.(time.Time); ok {
+		return t, nil
+	}
+
+	s, ok := text(v)
+	if !ok {
+		return time.Time{}, fmt.Errorf("expected a date, got %T", v)
+	}
+	s = strings.TrimSpace(s)
+	if layout != "" {
+		return time.Parse(layout, s)
+	}
+	for _, l := range dateLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unknown date format: %q", s)
+}
+
+// Now returns the current time in UTC, it is meant to give the value of a field
+//
+//   - migrated = Now()
+func Now() time.Time {
+	return time.Now().UTC()
+}
+
+// ParseDate parses v with a Go time layout such as 02/01/2006
+func ParseDate(layout string, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	return toTime(layout, v)
+}
+
+// FormatDate formats v with a Go time layout, text is parsed in one of the common layouts first
+func FormatDate(layout string, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	t, err := toTime("", v)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Format(layout), nil
+}
+
+// InTimezone converts v to the IANA time zone, the instant stays the same
+func InTimezone(zone string, v interface{}) (interface{}, error) {
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+
+	t, err := toTime("", v)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.In(loc), nil
+}
+
+// AssumeTimezone keeps the date and the clock of v but places it in the IANA time zone.
+// It is meant for sources storing local times without a zone
+func AssumeTimezone(zone string, v interface{}) (interface{}, error) {
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+
+	t, err := toTime("", v)
+	if err != nil {
+		return nil, err
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc), nil
+}
+
+// ParseBool converts v to a boolean. Besides true and false it understands yes/no, y/n, on/off and 1/0 in any case
+func ParseBool(v interface{}) (interface{}, error) {
+	switch b := v.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		return b, nil
+	case int64:
+		return b != 0, nil
+	case float64:
+		return b != 0, nil
+	}
+
+	switch s := strings.ToLower(strings.TrimSpace(toString(v))); s {
+	case "true", "t", "yes", "y", "on", "1":
+		return true, nil
+	case "false", "f", "no", "n", "off", "0":
+		return false, nil
+	default:
+		return nil, fmt.Errorf("%q is not a boolean", s)
+	}
+}
+
+// MapBool returns t or f for the truth of v, see ParseBool
+func MapBool(t, f interface{}, v interface{}) (interface{}, error) {
+	b, err := ParseBool(v)
+	if b == nil || err != nil {
+		return nil, err
+	}
+	if b.(bool) {
+		return t, nil
+	}
+
+	return f, nil
+}
+
+// SHA256 returns the hex encoded SHA-256 of v
+func SHA256(v interface{}) interface{} {
+	return mapString(v, func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	})
+}
+
+// Bcrypt hashes v with bcrypt at the cost, which is meant for passwords. It is slow by design
+func Bcrypt(cost int64, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	h, err := bcrypt.GenerateFromPassword([]byte(toString(v)), int(cost))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(h), nil
+}
+
+// JSONEncode returns v encoded as JSON
+func JSONEncode(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// JSONDecode decodes the JSON text of v
+func JSONDecode(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	var res interface{}
+	if err := json.Unmarshal([]byte(toString(v)), &res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// JSONGet returns the value found at the dot separated path in the JSON text of v, such as address.lines.0.
+// Objects and arrays are returned as JSON, and a path leading nowhere returns NULL
+func JSONGet(path string, v interface{}) (interface{}, error) {
+	doc, err := JSONDecode(v)
+	if doc == nil || err != nil {
+		return nil, err
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch d := doc.(type) {
+		case map[string]interface{}:
+			doc = d[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(d) {
+				return nil, nil
+			}
+			doc = d[i]
+		default:
+			return nil, nil
+		}
+	}
+
+	switch doc.(type) {
+	case map[string]interface{}, []interface{}:
+		return JSONEncode(doc)
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,142 @@
+package flock_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	flock "github.com/srikrsna/flock/pkg"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBuiltins(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skip("no time zone database: ", err)
+	}
+	noon := time.Date(2019, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	// must drops the error of functions that can't fail for the given arguments
+	must := func(v interface{}, err error) interface{} {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	fails := func(_ interface{}, err error) interface{} {
+		return err != nil
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"Nil", flock.Nil("none", nil), "none"},
+		{"NilValue", flock.Nil("none", "some"), "some"},
+		{"Default", flock.Default("none", ""), "none"},
+		{"DefaultBytes", flock.Default("none", []byte("a")), []byte("a")},
+		{"Coalesce", flock.Coalesce(nil, int64(2), int64(3)), int64(2)},
+		{"CoalesceNull", flock.Coalesce(nil, nil), nil},
+		{"NullIf", flock.NullIf("N/A", "N/A"), nil},
+		{"NullIfNumber", flock.NullIf(int64(0), float64(0)), nil},
+		{"NullIfOther", flock.NullIf("N/A", "a"), "a"},
+		{"Const", flock.Const("active", "anything"), "active"},
+
+		{"TrimSpace", flock.TrimSpace("  a b \n"), "a b"},
+		{"TrimSpaceNull", flock.TrimSpace(nil), nil},
+		{"Trim", flock.Trim("-", []byte("--a-b--")), "a-b"},
+		{"Upper", flock.Upper("abc"), "ABC"},
+		{"Lower", flock.Lower("ABC"), "abc"},
+		{"Title", flock.Title("hELLO  wORLD"), "Hello World"},
+		{"Substring", must(flock.Substring(1, 3, "héllo")), "éll"},
+		{"SubstringToEnd", must(flock.Substring(2, -1, "hello")), "llo"},
+		{"SubstringOut", must(flock.Substring(9, 2, "hello")), ""},
+		{"SubstringNegative", fails(flock.Substring(-1, 2, "hello")), true},
+		{"Replace", flock.Replace("-", "", "555-0100"), "5550100"},
+		{"Split", flock.Split(",", "a,b,,c"), []string{"a", "b", "", "c"}},
+		{"SplitEmpty", flock.Split(",", ""), []string(nil)},
+
+		{"RegexExtract", must(flock.RegexExtract(`\d+`, "order 42 of 50")), "42"},
+		{"RegexExtractGroup", must(flock.RegexExtract(`^(\w+)@`, "jane@example.com")), "jane"},
+		{"RegexExtractNoMatch", must(flock.RegexExtract(`\d+`, "none")), nil},
+		{"RegexExtractInvalid", fails(flock.RegexExtract(`(`, "none")), true},
+		{"RegexReplace", must(flock.RegexReplace(`(\w+)@(\w+)`, "$2:$1", "jane@example")), "example:jane"},
+
+		{"ParseInt", must(flock.ParseInt(" 42 ")), int64(42)},
+		{"ParseIntFloat", must(flock.ParseInt(float64(42))), int64(42)},
+		{"ParseIntInvalid", fails(flock.ParseInt("4.2")), true},
+		{"ParseFloat", must(flock.ParseFloat([]byte("4.25"))), 4.25},
+		{"ParseFloatNull", must(flock.ParseFloat(nil)), nil},
+		{"FormatNumber", must(flock.FormatNumber("%.2f", int64(3))), "3.00"},
+		{"FormatNumberText", must(flock.FormatNumber("%05d", "42")), "00042"},
+		{"FormatNumberDecimal", must(flock.FormatNumber("%.1f", "12.345")), "12.3"},
+
+		{"ParseDate", must(flock.ParseDate("02/01/2006", "10/06/2019")), time.Date(2019, 6, 10, 0, 0, 0, 0, time.UTC)},
+		{"ParseDateInvalid", fails(flock.ParseDate("02/01/2006", "2019-06-10")), true},
+		{"FormatDate", must(flock.FormatDate("2006-01-02", noon)), "2019-06-10"},
+		{"FormatDateText", must(flock.FormatDate("15:04", "2019-06-10 12:30:00")), "12:30"},
+		{"InTimezone", must(flock.InTimezone("Asia/Kolkata", noon)), noon.In(kolkata)},
+		{"AssumeTimezone", must(flock.AssumeTimezone("Asia/Kolkata", noon)), time.Date(2019, 6, 10, 12, 0, 0, 0, kolkata)},
+		{"UnknownTimezone", fails(flock.InTimezone("Mars/Olympus", noon)), true},
+
+		{"ParseBool", must(flock.ParseBool("Y")), true},
+		{"ParseBoolInt", must(flock.ParseBool(int64(0))), false},
+		{"ParseBoolInvalid", fails(flock.ParseBool("maybe")), true},
+		{"MapBool", must(flock.MapBool("active", "inactive", "off")), "inactive"},
+		{"MapBoolNull", must(flock.MapBool("active", "inactive", nil)), nil},
+
+		{"SHA256", flock.SHA256("abc"), "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+
+		{"JSONEncode", must(flock.JSONEncode(map[string]interface{}{"a": int64(1)})), `{"a":1}`},
+		{"JSONDecode", must(flock.JSONDecode(`[1, "a"]`)), []interface{}{float64(1), "a"}},
+		{"JSONDecodeInvalid", fails(flock.JSONDecode(`{`)), true},
+		{"JSONGet", must(flock.JSONGet("address.lines.1", `{"address": {"lines": ["a", "b"]}}`)), "b"},
+		{"JSONGetObject", must(flock.JSONGet("address", `{"address": {"city": "x"}}`)), `{"city":"x"}`},
+		{"JSONGetMissing", must(flock.JSONGet("address.zip", `{"address": {}}`)), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("got %#v, want %#v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBcrypt(t *testing.T) {
+	h, err := flock.Bcrypt(int64(bcrypt.MinCost), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(h.(string)), []byte("secret")); err != nil {
+		t.Error(err)
+	}
+	if _, err := flock.Bcrypt(100, "secret"); err == nil {
+		t.Error("expected an error for an invalid cost")
+	}
+}
+
+func TestBuiltinsInSchema(t *testing.T) {
+	fl, err := flock.ParseSchema(strings.NewReader("Users {\n `SELECT * FROM Users`\n {\n" +
+		` - name = Name | TrimSpace | Title
+		  - email = Email | Lower | Nil "unknown"
+		  - active = Active | MapBool "A" "I"
+		  - nick = Nick | Coalesce Name
+		 }
+		}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, vars := flock.BuildTables(fl)
+
+	got, err := flock.CalculateValuesOfRow(map[string]interface{}{"Name": " jane doe ", "Email": nil, "Active": int64(1), "Nick": nil}, tables["Users"], flock.DefaultFuncs(), vars["Users"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]interface{}{{"Jane Doe", "unknown", "A", " jane doe "}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CalculateValuesOfRow() = %#v, want %#v", got, want)
+	}
+}
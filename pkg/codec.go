@@ -0,0 +1,230 @@
+package flock
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/gob"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/uuid"
+	pb "github.com/srikrsna/flock/protos"
+)
+
+// ColumnsOf returns the metadata of the columns of a result set
+func ColumnsOf(cols []*sql.ColumnType) []*pb.Column {
+	res := make([]*pb.Column, 0, len(cols))
+	for _, col := range cols {
+		nullable, _ := col.Nullable()
+		res = append(res, &pb.Column{Name: col.Name(), DatabaseType: col.DatabaseTypeName(), Nullable: nullable})
+	}
+
+	return res
+}
+
+// EncodeRows converts rows read from the source database into a Rows message
+func EncodeRows(cols []*pb.Column, rows []map[string]interface{}) (*pb.Rows, error) {
+	res := &pb.Rows{Columns: cols, Rows: make([]*pb.Row, 0, len(rows))}
+	for _, row := range rows {
+		values := make([]*pb.Value, 0, len(cols))
+		for _, col := range cols {
+			v, err := EncodeValue(col, row[col.Name])
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		res.Rows = append(res.Rows, &pb.Row{Values: values})
+	}
+
+	return res, nil
+}
+
+// DecodeRows decodes the data of a batch sent in the given encoding
+func DecodeRows(encoding pb.Encoding, data []byte) ([]map[string]interface{}, error) {
+	switch encoding {
+	case pb.Encoding_GOB:
+		var rows []map[string]interface{}
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&rows); err != nil {
+			return nil, err
+		}
+
+		return rows, nil
+	case pb.Encoding_PROTO:
+		var rows pb.Rows
+		if err := proto.Unmarshal(data, &rows); err != nil {
+			return nil, err
+		}
+
+		return RowsOf(&rows)
+	}
+
+	return nil, fmt.Errorf("unknown encoding: %v", encoding)
+}
+
+// RowsOf converts a Rows message into maps of column names to values
+func RowsOf(rows *pb.Rows) ([]map[string]interface{}, error) {
+	res := make([]map[string]interface{}, 0, len(rows.Rows))
+	for i, row := range rows.Rows {
+		if len(row.Values) != len(rows.Columns) {
+			return nil, fmt.Errorf("row %d has %d values for %d columns", i, len(row.Values), len(rows.Columns))
+		}
+
+		m := make(map[string]interface{}, len(rows.Columns))
+		for j, col := range rows.Columns {
+			v, err := DecodeValue(row.Values[j])
+			if err != nil {
+				return nil, fmt.Errorf("row %d, column %s: %v", i, col.Name, err)
+			}
+			m[col.Name] = v
+		}
+		res = append(res, m)
+	}
+
+	return res, nil
+}
+
+// EncodeValue converts a value scanned from a column into a Value.
+// The type of the column tells decimals and UUIDs apart from plain strings and bytes
+func EncodeValue(col *pb.Column, v interface{}) (*pb.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return &pb.Value{Kind: &pb.Value_NullValue{NullValue: true}}, nil
+	case bool:
+		return &pb.Value{Kind: &pb.Value_BoolValue{BoolValue: v}}, nil
+	case int:
+		return &pb.Value{Kind: &pb.Value_IntValue{IntValue: int64(v)}}, nil
+	case int8:
+		return &pb.Value{Kind: &pb.Value_IntValue{IntValue: int64(v)}}, nil
+	case int16:
+		return &pb.Value{Kind: &pb.Value_IntValue{IntValue: int64(v)}}, nil
+	case int32:
+		return &pb.Value{Kind: &pb.Value_IntValue{IntValue: int64(v)}}, nil
+	case int64:
+		return &pb.Value{Kind: &pb.Value_IntValue{IntValue: v}}, nil
+	case uint:
+		return encodeUint(uint64(v)), nil
+	case uint8:
+		return encodeUint(uint64(v)), nil
+	case uint16:
+		return encodeUint(uint64(v)), nil
+	case uint32:
+		return encodeUint(uint64(v)), nil
+	case uint64:
+		return encodeUint(v), nil
+	case float32:
+		return &pb.Value{Kind: &pb.Value_FloatValue{FloatValue: float64(v)}}, nil
+	case float64:
+		return &pb.Value{Kind: &pb.Value_FloatValue{FloatValue: v}}, nil
+	case time.Time:
+		ts, err := ptypes.TimestampProto(v)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %v", col.Name, err)
+		}
+
+		_, offset := v.Zone()
+		return &pb.Value{Kind: &pb.Value_TimestampValue{TimestampValue: ts}, TimestampOffset: int32(offset)}, nil
+	case uuid.UUID:
+		return &pb.Value{Kind: &pb.Value_UuidValue{UuidValue: v[:]}}, nil
+	case string:
+		return encodeText(col, []byte(v), v)
+	case []byte:
+		return encodeText(col, v, nil)
+	case driver.Valuer:
+		dv, err := v.Value()
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %v", col.Name, err)
+		}
+
+		return EncodeValue(col, dv)
+	}
+
+	return nil, fmt.Errorf("column %s: unsupported type %T", col.Name, v)
+}
+
+func encodeUint(v uint64) *pb.Value {
+	if v > math.MaxInt64 {
+		return &pb.Value{Kind: &pb.Value_DecimalValue{DecimalValue: fmt.Sprint(v)}}
+	}
+
+	return &pb.Value{Kind: &pb.Value_IntValue{IntValue: int64(v)}}
+}
+
+// encodeText encodes the raw value of a column, s is set when the driver returned a string
+func encodeText(col *pb.Column, b []byte, s interface{}) (*pb.Value, error) {
+	typ := strings.ToUpper(col.DatabaseType)
+	switch {
+	case isDecimal(typ):
+		return &pb.Value{Kind: &pb.Value_DecimalValue{DecimalValue: string(b)}}, nil
+	case typ == "UNIQUEIDENTIFIER" && len(b) == 16:
+		// SQL Server stores the first three groups in little endian order
+		id := make([]byte, 16)
+		copy(id, b)
+		id[0], id[1], id[2], id[3] = id[3], id[2], id[1], id[0]
+		id[4], id[5] = id[5], id[4]
+		id[6], id[7] = id[7], id[6]
+
+		return &pb.Value{Kind: &pb.Value_UuidValue{UuidValue: id}}, nil
+	case typ == "UUID" || typ == "UNIQUEIDENTIFIER":
+		id, err := uuid.ParseBytes(b)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %v", col.Name, err)
+		}
+
+		return &pb.Value{Kind: &pb.Value_UuidValue{UuidValue: id[:]}}, nil
+	case s != nil:
+		return &pb.Value{Kind: &pb.Value_StringValue{StringValue: string(b)}}, nil
+	}
+
+	return &pb.Value{Kind: &pb.Value_BytesValue{BytesValue: b}}, nil
+}
+
+func isDecimal(typ string) bool {
+	switch typ {
+	case "DECIMAL", "NUMERIC", "MONEY", "SMALLMONEY":
+		return true
+	}
+
+	return false
+}
+
+// DecodeValue converts a Value into the Go value handed to the functions and the destination driver. Timestamps keep
+// the offset of their zone but not its name, UUIDs are strings in their canonical form so that they compare equal to
+// string literals
+func DecodeValue(v *pb.Value) (interface{}, error) {
+	switch k := v.GetKind().(type) {
+	case nil, *pb.Value_NullValue:
+		return nil, nil
+	case *pb.Value_IntValue:
+		return k.IntValue, nil
+	case *pb.Value_FloatValue:
+		return k.FloatValue, nil
+	case *pb.Value_StringValue:
+		return k.StringValue, nil
+	case *pb.Value_BytesValue:
+		return k.BytesValue, nil
+	case *pb.Value_BoolValue:
+		return k.BoolValue, nil
+	case *pb.Value_TimestampValue:
+		t, err := ptypes.Timestamp(k.TimestampValue)
+		if err != nil || v.TimestampOffset == 0 {
+			return t, err
+		}
+		return t.In(time.FixedZone("", int(v.TimestampOffset))), nil
+	case *pb.Value_DecimalValue:
+		return k.DecimalValue, nil
+	case *pb.Value_UuidValue:
+		id, err := uuid.FromBytes(k.UuidValue)
+		if err != nil {
+			return nil, err
+		}
+		return id.String(), nil
+	}
+
+	return nil, fmt.Errorf("unknown value kind: %T", v.GetKind())
+}
@@ -0,0 +1,108 @@
+package flock_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/uuid"
+	flock "github.com/srikrsna/flock/pkg"
+	pb "github.com/srikrsna/flock/protos"
+)
+
+func TestEncodeValue(t *testing.T) {
+	id := uuid.MustParse("6f9619ff-8b86-d011-b42d-00c04fc964ff")
+	now := time.Date(2019, 7, 1, 10, 30, 0, 500, time.UTC)
+
+	tests := []struct {
+		name    string
+		typ     string
+		in      interface{}
+		exp     interface{}
+		wantErr bool
+	}{
+		{"Null", "TEXT", nil, nil, false},
+		{"Int", "INT4", int32(7), int64(7), false},
+		{"Uint", "INT", uint8(7), int64(7), false},
+		{"BigUint", "BIGINT UNSIGNED", uint64(1 << 63), "9223372036854775808", false},
+		{"Float", "FLOAT8", float32(1.5), float64(1.5), false},
+		{"String", "VARCHAR", "flock", "flock", false},
+		{"Bytes", "BYTEA", []byte{0, 1, 2}, []byte{0, 1, 2}, false},
+		{"Bool", "BOOL", true, true, false},
+		{"Timestamp", "TIMESTAMP", now, now, false},
+		{"TimestampOffset", "TIMESTAMPTZ", now.In(time.FixedZone("", 5*3600+1800)), now.In(time.FixedZone("", 5*3600+1800)), false},
+		{"Decimal", "NUMERIC", []byte("12345678901234567890.123"), "12345678901234567890.123", false},
+		{"PostgresUUID", "UUID", []byte(id.String()), id.String(), false},
+		{"MSSQLUUID", "UNIQUEIDENTIFIER", []byte{0xff, 0x19, 0x96, 0x6f, 0x86, 0x8b, 0x11, 0xd0, 0xb4, 0x2d, 0x00, 0xc0, 0x4f, 0xc9, 0x64, 0xff}, id.String(), false},
+		{"InvalidUUID", "UUID", "flock", nil, true},
+		{"Unsupported", "JSON", map[string]string{}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := flock.EncodeValue(&pb.Column{Name: "col", DatabaseType: tt.typ}, tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EncodeValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			got, err := flock.DecodeValue(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.exp) {
+				t.Errorf("expected: %#v, got: %#v", tt.exp, got)
+			}
+		})
+	}
+}
+
+func TestDecodeRows(t *testing.T) {
+	input := []map[string]interface{}{
+		{"id": int64(1), "name": "one"},
+		{"id": int64(2), "name": nil},
+	}
+
+	cols := []*pb.Column{{Name: "id", DatabaseType: "INT8"}, {Name: "name", DatabaseType: "TEXT", Nullable: true}}
+	rows, err := flock.EncodeRows(cols, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typed, err := proto.Marshal(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(input); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		encoding pb.Encoding
+		data     []byte
+		wantErr  bool
+	}{
+		{"Proto", pb.Encoding_PROTO, typed, false},
+		{"Gob", pb.Encoding_GOB, buf.Bytes(), false},
+		{"Mismatch", pb.Encoding_GOB, typed, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := flock.DecodeRows(tt.encoding, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeRows() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, input) {
+				t.Errorf("expected: %v, got: %v", input, got)
+			}
+		})
+	}
+}
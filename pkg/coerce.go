@@ -0,0 +1,208 @@
+package flock
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// callFunc calls fn with the arguments converted to the types of its parameters. Drivers and the codec hand over
+// values of whatever width they have and literals of the .fl file are int64, float64 or string, so the arguments
+// are converted between numeric types, strings and bytes, text and times, values and their pointers, and NULL
+// becomes the zero value
+//
+// The functions of plugins are arbitrary code, a panic in one of them is returned as an error so that it fails the
+// batch instead of the server
+func callFunc(fn reflect.Value, in []reflect.Value) (res []reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			res, err = nil, fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	typ := fn.Type()
+	n := typ.NumIn()
+	if typ.IsVariadic() && len(in) < n-1 || !typ.IsVariadic() && len(in) != n {
+		return nil, fmt.Errorf("expected %d arguments, got %d", n, len(in))
+	}
+
+	args := make([]reflect.Value, len(in))
+	for i, v := range in {
+		t := paramType(typ, i)
+		arg, err := coerce(v, t)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %v", i+1, err)
+		}
+		args[i] = arg
+	}
+
+	return fn.Call(args), nil
+}
+
+// paramType returns the type of the ith argument of a call to a function of type typ
+func paramType(typ reflect.Type, i int) reflect.Type {
+	if typ.IsVariadic() && i >= typ.NumIn()-1 {
+		return typ.In(typ.NumIn() - 1).Elem()
+	}
+
+	return typ.In(i)
+}
+
+// coerce converts v to t. Conversions that lose information, like an overflowing or fractional number going
+// into an integer, are errors
+func coerce(v reflect.Value, t reflect.Type) (reflect.Value, error) {
+	// Values coming out of interfaces, such as the results of other functions, are taken for what they hold
+	for v.IsValid() && v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() || isNil(v) {
+		return reflect.Zero(t), nil
+	}
+
+	if v.Type().AssignableTo(t) {
+		return v, nil
+	}
+
+	// Pointers are followed and taken, so that both time.Time and *time.Time fit either
+	if v.Kind() == reflect.Ptr {
+		return coerce(v.Elem(), t)
+	}
+	if t.Kind() == reflect.Ptr {
+		e, err := coerce(v, t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		p := reflect.New(t.Elem())
+		p.Elem().Set(e)
+		return p, nil
+	}
+
+	switch {
+	case isNumber(v.Kind()) && isNumber(t.Kind()):
+		return convertNumber(v, t)
+	case v.Kind() == reflect.String && isBytes(t), isBytes(v.Type()) && t.Kind() == reflect.String:
+		return v.Convert(t), nil
+	case t == timeType && (v.Kind() == reflect.String || isBytes(v.Type())):
+		tm, err := toTime("", v.Interface())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(tm), nil
+	case v.Kind() == t.Kind() && v.Type().ConvertibleTo(t):
+		// Named types of the same kind, like time.Duration and int64
+		return v.Convert(t), nil
+	}
+
+	return reflect.Value{}, fmt.Errorf("can't use %s %v as %s", v.Type(), v.Interface(), t)
+}
+
+// canCoerce tells whether coerce can convert values of type from to t. Values of an interface type can hold anything,
+// so they are only checked when they are converted
+func canCoerce(from, t reflect.Type) bool {
+	switch {
+	case from.Kind() == reflect.Interface || from.AssignableTo(t):
+		return true
+	case from.Kind() == reflect.Ptr:
+		return canCoerce(from.Elem(), t)
+	case t.Kind() == reflect.Ptr:
+		return canCoerce(from, t.Elem())
+	case isNumber(from.Kind()) && isNumber(t.Kind()):
+		return true
+	case from.Kind() == reflect.String && isBytes(t), isBytes(from) && t.Kind() == reflect.String:
+		return true
+	case t == timeType && (from.Kind() == reflect.String || isBytes(from)):
+		return true
+	}
+
+	return from.Kind() == t.Kind() && from.ConvertibleTo(t)
+}
+
+func isNil(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+func isBytes(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+func isNumber(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
+
+func isInt(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUint(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+func convertNumber(v reflect.Value, t reflect.Type) (reflect.Value, error) {
+	fail := func() (reflect.Value, error) {
+		return reflect.Value{}, fmt.Errorf("%s %v doesn't fit in %s", v.Type(), v.Interface(), t)
+	}
+
+	res := reflect.New(t).Elem()
+	switch k := v.Kind(); {
+	case isInt(k):
+		n := v.Int()
+		switch {
+		case isInt(t.Kind()):
+			if res.OverflowInt(n) {
+				return fail()
+			}
+			res.SetInt(n)
+		case isUint(t.Kind()):
+			if n < 0 || res.OverflowUint(uint64(n)) {
+				return fail()
+			}
+			res.SetUint(uint64(n))
+		default:
+			res.SetFloat(float64(n))
+		}
+	case isUint(k):
+		n := v.Uint()
+		switch {
+		case isInt(t.Kind()):
+			if n > 1<<63-1 || res.OverflowInt(int64(n)) {
+				return fail()
+			}
+			res.SetInt(int64(n))
+		case isUint(t.Kind()):
+			if res.OverflowUint(n) {
+				return fail()
+			}
+			res.SetUint(n)
+		default:
+			res.SetFloat(float64(n))
+		}
+	default:
+		f := v.Float()
+		switch {
+		case isInt(t.Kind()):
+			if f != float64(int64(f)) || res.OverflowInt(int64(f)) {
+				return fail()
+			}
+			res.SetInt(int64(f))
+		case isUint(t.Kind()):
+			if f < 0 || f != float64(uint64(f)) || res.OverflowUint(uint64(f)) {
+				return fail()
+			}
+			res.SetUint(uint64(f))
+		default:
+			if res.OverflowFloat(f) {
+				return fail()
+			}
+			res.SetFloat(f)
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,93 @@
+package flock_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	flock "github.com/srikrsna/flock/pkg"
+)
+
+func TestCoercion(t *testing.T) {
+	day := time.Date(2019, 6, 10, 0, 0, 0, 0, time.UTC)
+	funcs := flock.NewFuncs()
+	if err := funcs.Register(flock.FuncMap{
+		"Add":   func(n int, v int) int { return n + v },
+		"Half":  func(v float32) float32 { return v / 2 },
+		"Small": func(v int8) int8 { return v },
+		"Upper": func(v string) string { return strings.ToUpper(v) },
+		"Len":   func(v []byte) int { return len(v) },
+		"Year":  func(v time.Time) int { return v.Year() },
+		"Ptr":   func(v *int64) bool { return v != nil },
+		"Sum": func(vs ...int32) int32 {
+			s := int32(0)
+			for _, v := range vs {
+				s += v
+			}
+			return s
+		},
+		"Seconds": func(v time.Duration) float64 { return v.Seconds() },
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		fn      string
+		params  []interface{}
+		value   interface{}
+		want    interface{}
+		wantErr string
+	}{
+		{"IntLiteralToInt", "Add", []interface{}{int64(2)}, int32(3), 5, ""},
+		{"IntToFloat", "Half", nil, int16(3), float32(1.5), ""},
+		{"WholeFloatToInt", "Add", []interface{}{int64(1)}, float64(2), 3, ""},
+		{"BytesToString", "Upper", nil, []byte("abc"), "ABC", ""},
+		{"StringToBytes", "Len", nil, "abcd", 4, ""},
+		{"TimePointer", "Year", nil, &day, 2019, ""},
+		{"TextToTime", "Year", nil, "2019-06-10", 2019, ""},
+		{"ValueToPointer", "Ptr", nil, int32(1), true, ""},
+		{"NullToPointer", "Ptr", nil, nil, false, ""},
+		{"NullToZero", "Upper", nil, nil, "", ""},
+		{"Variadic", "Sum", []interface{}{int64(1), int64(2)}, int64(3), int32(6), ""},
+		{"NamedType", "Seconds", nil, int64(time.Second), float64(1), ""},
+		{"Overflow", "Small", nil, int64(300), nil, "argument 1: int64 300 doesn't fit in int8"},
+		{"Fraction", "Add", []interface{}{int64(1)}, 2.5, nil, "argument 2: float64 2.5 doesn't fit in int"},
+		{"Mismatch", "Upper", nil, true, nil, "argument 1: can't use bool true as string"},
+		{"BadDate", "Year", nil, "someday", nil, "argument 1: unknown date format"},
+		{"Arity", "Add", nil, int64(1), nil, "expected 2 arguments, got 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := make([]reflect.Value, len(tt.params))
+			for i, p := range tt.params {
+				params[i] = reflect.ValueOf(p)
+			}
+			table := flock.Table{
+				Name:    "Users",
+				Keys:    map[string]flock.Column{"value": {Value: "Value", Functions: []flock.Func{{Name: tt.fn, Parameters: params}}}},
+				Ordered: []string{"value"},
+			}
+
+			got, err := flock.CalculateValuesOfRow(map[string]interface{}{"Value": tt.value}, table, funcs, nil)
+			if tt.wantErr != "" {
+				rerr, ok := err.(*flock.RowError)
+				if !ok {
+					t.Fatalf("expected a row error, got %v", err)
+				}
+				if rerr.Entry != "Users" || rerr.Column != "value" || rerr.Func != tt.fn || !strings.Contains(rerr.Err.Error(), tt.wantErr) {
+					t.Errorf("unexpected row error: %v", rerr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got[0][0], tt.want) {
+				t.Errorf("got %#v, want %#v", got[0][0], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,471 @@
+package flock
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/elgris/sqrl"
+)
+
+// Dialect holds everything that differs between the SQL flavours of the supported destination databases
+type Dialect interface {
+	// Name returns the name the dialect is registered with
+	Name() string
+	// Placeholder returns the bind parameter format understood by the driver
+	Placeholder() sqrl.PlaceholderFormat
+	// Quote quotes a possibly schema qualified identifier where quoting doesn't change the case the database sees
+	Quote(ident string) string
+	// Insert returns the statements that insert the rows into the table resolving conflicts as configured
+	Insert(table string, columns []string, rows [][]interface{}, conflict Conflict) ([]sqrl.Sqlizer, error)
+	// MaxParams returns the maximum number of bind parameters allowed in a single statement
+	MaxParams() int
+	// CountQuery returns the query used to count the rows of a table during verification
+	CountQuery(table string) string
+	// ForeignKeysQuery returns the query listing the foreign keys of the database, as rows of the referencing table
+	// and the referenced one, qualified by their schema for the databases having schemas
+	ForeignKeysQuery() string
+	// Savepoint returns the statements that create a savepoint, roll back to it and release it.
+	// Release is empty when the database releases savepoints on its own
+	Savepoint(name string) (save, rollback, release string)
+}
+
+// KeyFinder is implemented by the dialects that need the key columns of a table to skip the rows already there,
+// the primary key of the tables declaring no keys is then looked up in the database
+type KeyFinder interface {
+	// PrimaryKeyQuery returns the query listing the primary key columns of a table in order, the possibly schema
+	// qualified name of the table is its only parameter
+	PrimaryKeyQuery() string
+}
+
+var dialects = map[string]Dialect{}
+
+// Supported dialects
+var (
+	Postgres Dialect = postgres{}
+	MySQL    Dialect = mysql{}
+	SQLite   Dialect = sqlite{}
+	MSSQL    Dialect = mssql{}
+)
+
+func init() {
+	RegisterDialect(Postgres, "postgres", "cloudsqlpostgres", "pgx")
+	RegisterDialect(MySQL, "mysql")
+	RegisterDialect(SQLite, "sqlite3", "sqlite")
+	RegisterDialect(MSSQL, "sqlserver", "mssql")
+}
+
+// RegisterDialect makes a dialect available under its name and the given aliases, usually the driver names
+func RegisterDialect(d Dialect, aliases ...string) {
+	dialects[d.Name()] = d
+	for _, a := range aliases {
+		dialects[a] = d
+	}
+}
+
+// GetDialect returns the dialect registered for the name or driver
+func GetDialect(name string) (Dialect, error) {
+	d, ok := dialects[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported dialect: %q", name)
+	}
+
+	return d, nil
+}
+
+type postgres struct{}
+
+func (postgres) Name() string                        { return "postgres" }
+func (postgres) Placeholder() sqrl.PlaceholderFormat { return sqrl.Dollar }
+func (postgres) Quote(ident string) string           { return quoteFolded(ident, `"`, `"`) }
+func (postgres) MaxParams() int                      { return 65535 }
+
+func (d postgres) Insert(table string, columns []string, rows [][]interface{}, c Conflict) ([]sqrl.Sqlizer, error) {
+	return onConflictInsert(d, table, columns, rows, c, "EXCLUDED")
+}
+
+func (postgres) Savepoint(name string) (string, string, string) { return savepoint(name) }
+
+func (d postgres) CountQuery(table string) string {
+	return "SELECT COUNT(*) FROM " + d.Quote(table)
+}
+
+func (postgres) ForeignKeysQuery() string {
+	return `SELECT tc.table_schema || '.' || tc.table_name, ccu.table_schema || '.' || ccu.table_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.constraint_column_usage ccu
+		ON ccu.constraint_schema = tc.constraint_schema AND ccu.constraint_name = tc.constraint_name
+		WHERE tc.constraint_type = 'FOREIGN KEY'`
+}
+
+type mysql struct{}
+
+func (mysql) Name() string                        { return "mysql" }
+func (mysql) Placeholder() sqrl.PlaceholderFormat { return sqrl.Question }
+func (mysql) Quote(ident string) string           { return quote(ident, "`", "`") }
+func (mysql) MaxParams() int                      { return 65535 }
+
+func (d mysql) Insert(table string, columns []string, rows [][]interface{}, c Conflict) ([]sqrl.Sqlizer, error) {
+	b := insert(d, table, columns, rows)
+	switch c.Action {
+	case ConflictIgnore:
+		return []sqrl.Sqlizer{b.Options("IGNORE")}, nil
+	case ConflictUpdate:
+		// MySQL resolves the conflict on any unique key so the keys only narrow down the updated columns
+		set := make([]string, 0, len(columns))
+		for _, col := range updateColumns(columns, c) {
+			set = append(set, fmt.Sprintf("%s = VALUES(%[1]s)", d.Quote(col)))
+		}
+		if len(set) == 0 {
+			return []sqrl.Sqlizer{b.Options("IGNORE")}, nil
+		}
+		return []sqrl.Sqlizer{b.Suffix("ON DUPLICATE KEY UPDATE " + strings.Join(set, ", "))}, nil
+	case ConflictReplace:
+		return replace(d, table, columns, rows, c)
+	default:
+		return []sqrl.Sqlizer{b}, nil
+	}
+}
+
+func (mysql) Savepoint(name string) (string, string, string) { return savepoint(name) }
+
+func (d mysql) CountQuery(table string) string {
+	return "SELECT COUNT(*) FROM " + d.Quote(table)
+}
+
+func (mysql) ForeignKeysQuery() string {
+	return `SELECT table_name, referenced_table_name FROM information_schema.key_column_usage
+		WHERE referenced_table_name IS NOT NULL AND table_schema = DATABASE()`
+}
+
+type sqlite struct{}
+
+func (sqlite) Name() string                        { return "sqlite3" }
+func (sqlite) Placeholder() sqrl.PlaceholderFormat { return sqrl.Question }
+func (sqlite) Quote(ident string) string           { return quoteFolded(ident, `"`, `"`) }
+
+// MaxParams is the default SQLITE_MAX_VARIABLE_NUMBER of builds before 3.32
+func (sqlite) MaxParams() int { return 999 }
+
+func (d sqlite) Insert(table string, columns []string, rows [][]interface{}, c Conflict) ([]sqrl.Sqlizer, error) {
+	switch c.Action {
+	case ConflictIgnore:
+		return []sqrl.Sqlizer{insert(d, table, columns, rows).Options("OR IGNORE")}, nil
+	case ConflictReplace:
+		return []sqrl.Sqlizer{insert(d, table, columns, rows).Options("OR REPLACE")}, nil
+	default:
+		// Upserts share the syntax of Postgres since SQLite 3.24
+		return onConflictInsert(d, table, columns, rows, c, "excluded")
+	}
+}
+
+func (sqlite) Savepoint(name string) (string, string, string) { return savepoint(name) }
+
+func (d sqlite) CountQuery(table string) string {
+	return "SELECT COUNT(*) FROM " + d.Quote(table)
+}
+
+func (sqlite) ForeignKeysQuery() string {
+	return `SELECT m.name, fk."table" FROM sqlite_master m JOIN pragma_foreign_key_list(m.name) fk WHERE m.type = 'table'`
+}
+
+type mssql struct{}
+
+func (mssql) Name() string                        { return "sqlserver" }
+func (mssql) Placeholder() sqrl.PlaceholderFormat { return atP{} }
+func (mssql) Quote(ident string) string           { return quote(ident, "[", "]") }
+
+// MaxParams leaves room below the 2100 parameter limit of SQL Server for the ones the driver adds itself
+func (mssql) MaxParams() int { return 2000 }
+
+// Insert uses MERGE to skip or update existing rows as SQL Server has no conflict clause.
+// Without keys there is nothing to merge on, the keys of the tables declaring none are looked up with PrimaryKeyQuery
+func (d mssql) Insert(table string, columns []string, rows [][]interface{}, c Conflict) ([]sqrl.Sqlizer, error) {
+	switch c.Action {
+	case ConflictIgnore, ConflictUpdate:
+		if len(c.Keys) == 0 {
+			return nil, errNoKeys(table, c)
+		}
+		m, err := d.merge(table, columns, rows, c)
+		if err != nil {
+			return nil, err
+		}
+		return []sqrl.Sqlizer{m}, nil
+	case ConflictReplace:
+		return replace(d, table, columns, rows, c)
+	default:
+		return []sqrl.Sqlizer{insert(d, table, columns, rows)}, nil
+	}
+}
+
+func (d mssql) merge(table string, columns []string, rows [][]interface{}, c Conflict) (sqrl.Sqlizer, error) {
+	if _, err := indexes(columns, c.Keys); err != nil {
+		return nil, err
+	}
+
+	cols := make([]string, len(columns))
+	src := make([]string, len(columns))
+	for i, col := range columns {
+		cols[i] = d.Quote(col)
+		src[i] = "[source]." + cols[i]
+	}
+
+	on := make([]string, len(c.Keys))
+	for i, k := range c.Keys {
+		on[i] = fmt.Sprintf("[target].%s = [source].%[1]s", d.Quote(k))
+	}
+
+	var buf bytes.Buffer
+	args := make([]interface{}, 0, len(rows)*len(columns))
+	fmt.Fprintf(&buf, "MERGE INTO %s AS [target] USING (VALUES ", d.Quote(table))
+	for i, row := range rows {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("(" + sqrl.Placeholders(len(row)) + ")")
+		args = append(args, row...)
+	}
+	fmt.Fprintf(&buf, ") AS [source] (%s) ON %s", strings.Join(cols, ","), strings.Join(on, " AND "))
+
+	if c.Action == ConflictUpdate {
+		set := make([]string, 0, len(columns))
+		for _, col := range updateColumns(columns, c) {
+			set = append(set, fmt.Sprintf("[target].%s = [source].%[1]s", d.Quote(col)))
+		}
+		if len(set) > 0 {
+			buf.WriteString(" WHEN MATCHED THEN UPDATE SET " + strings.Join(set, ", "))
+		}
+	}
+	fmt.Fprintf(&buf, " WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s);", strings.Join(cols, ","), strings.Join(src, ","))
+
+	return statement{d.Placeholder(), buf.String(), args}, nil
+}
+
+func (mssql) Savepoint(name string) (string, string, string) {
+	return "SAVE TRANSACTION " + name, "ROLLBACK TRANSACTION " + name, ""
+}
+
+func (d mssql) CountQuery(table string) string {
+	return "SELECT COUNT_BIG(*) FROM " + d.Quote(table)
+}
+
+func (mssql) ForeignKeysQuery() string {
+	return "SELECT OBJECT_SCHEMA_NAME(parent_object_id) + '.' + OBJECT_NAME(parent_object_id), " +
+		"OBJECT_SCHEMA_NAME(referenced_object_id) + '.' + OBJECT_NAME(referenced_object_id) FROM sys.foreign_keys"
+}
+
+func (mssql) PrimaryKeyQuery() string {
+	return "SELECT c.name FROM sys.indexes i " +
+		"JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id " +
+		"JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id " +
+		"WHERE i.is_primary_key = 1 AND i.object_id = OBJECT_ID(@p1) ORDER BY ic.key_ordinal"
+}
+
+// onConflictInsert builds the insert for dialects supporting the ON CONFLICT clause,
+// excluded is the name of the pseudo table holding the rejected row
+func onConflictInsert(d Dialect, table string, columns []string, rows [][]interface{}, c Conflict, excluded string) ([]sqrl.Sqlizer, error) {
+	b := insert(d, table, columns, rows)
+	switch c.Action {
+	case ConflictIgnore:
+		return []sqrl.Sqlizer{b.Suffix("ON CONFLICT " + target(d, c) + "DO NOTHING")}, nil
+	case ConflictUpdate:
+		if len(c.Keys) == 0 {
+			return nil, errNoKeys(table, c)
+		}
+		set := make([]string, 0, len(columns))
+		for _, col := range updateColumns(columns, c) {
+			set = append(set, fmt.Sprintf("%s = %s.%[1]s", d.Quote(col), excluded))
+		}
+		if len(set) == 0 {
+			return []sqrl.Sqlizer{b.Suffix("ON CONFLICT " + target(d, c) + "DO NOTHING")}, nil
+		}
+		return []sqrl.Sqlizer{b.Suffix("ON CONFLICT " + target(d, c) + "DO UPDATE SET " + strings.Join(set, ", "))}, nil
+	case ConflictReplace:
+		return replace(d, table, columns, rows, c)
+	default:
+		return []sqrl.Sqlizer{b}, nil
+	}
+}
+
+// target returns the conflict target of the keys
+func target(d Dialect, c Conflict) string {
+	if len(c.Keys) == 0 {
+		return ""
+	}
+
+	keys := make([]string, len(c.Keys))
+	for i, k := range c.Keys {
+		keys[i] = d.Quote(k)
+	}
+
+	return "(" + strings.Join(keys, ",") + ") "
+}
+
+// insert builds a plain multi row insert
+func insert(d Dialect, table string, columns []string, rows [][]interface{}) *sqrl.InsertBuilder {
+	cols := make([]string, len(columns))
+	for i, col := range columns {
+		cols[i] = d.Quote(col)
+	}
+
+	b := sqrl.Insert(d.Quote(table)).PlaceholderFormat(d.Placeholder()).Columns(cols...)
+	for _, row := range rows {
+		b = b.Values(row...)
+	}
+
+	return b
+}
+
+// replace deletes the rows having the same keys as the inserted ones before inserting them
+func replace(d Dialect, table string, columns []string, rows [][]interface{}, c Conflict) ([]sqrl.Sqlizer, error) {
+	if len(c.Keys) == 0 {
+		return nil, errNoKeys(table, c)
+	}
+	idx, err := indexes(columns, c.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var where sqrl.Sqlizer
+	if len(c.Keys) == 1 {
+		in := make([]interface{}, len(rows))
+		for i, row := range rows {
+			in[i] = row[idx[0]]
+		}
+		where = sqrl.Eq{d.Quote(c.Keys[0]): in}
+	} else {
+		or := make(sqrl.Or, len(rows))
+		for i, row := range rows {
+			and := make(sqrl.And, len(c.Keys))
+			for j, k := range c.Keys {
+				and[j] = sqrl.Eq{d.Quote(k): row[idx[j]]}
+			}
+			or[i] = and
+		}
+		where = or
+	}
+
+	del := sqrl.Delete(d.Quote(table)).PlaceholderFormat(d.Placeholder()).Where(where)
+
+	return []sqrl.Sqlizer{del, insert(d, table, columns, rows)}, nil
+}
+
+// updateColumns returns the columns overwritten by an update, every non key column unless listed
+func updateColumns(columns []string, c Conflict) []string {
+	if len(c.Columns) > 0 {
+		return c.Columns
+	}
+
+	res := make([]string, 0, len(columns))
+	for _, col := range columns {
+		if !contains(c.Keys, col) {
+			res = append(res, col)
+		}
+	}
+
+	return res
+}
+
+// indexes returns the position of every key in the columns
+func indexes(columns, keys []string) ([]int, error) {
+	res := make([]int, len(keys))
+	for i, k := range keys {
+		res[i] = -1
+		for j, col := range columns {
+			if col == k {
+				res[i] = j
+			}
+		}
+		if res[i] == -1 {
+			return nil, fmt.Errorf("conflict key %q is not one of the inserted columns", k)
+		}
+	}
+
+	return res, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func errNoKeys(table string, c Conflict) error {
+	return fmt.Errorf("conflict strategy %q of table %s needs the key columns to be declared", c.Action, table)
+}
+
+// statement is a raw query written with question mark placeholders
+type statement struct {
+	format sqrl.PlaceholderFormat
+	query  string
+	args   []interface{}
+}
+
+func (s statement) ToSql() (string, []interface{}, error) {
+	query, err := s.format.ReplacePlaceholders(s.query)
+	return query, s.args, err
+}
+
+// atP replaces the question mark placeholders with the ordinal @p1, @p2 ... placeholders of SQL Server
+type atP struct{}
+
+func (atP) ReplacePlaceholders(query string) (string, error) {
+	var buf bytes.Buffer
+	i := 0
+	for {
+		p := strings.Index(query, "?")
+		if p == -1 {
+			break
+		}
+
+		// ?? is an escaped question mark
+		if strings.HasPrefix(query[p:], "??") {
+			buf.WriteString(query[:p+1])
+			query = query[p+2:]
+			continue
+		}
+
+		i++
+		buf.WriteString(query[:p])
+		fmt.Fprintf(&buf, "@p%d", i)
+		query = query[p+1:]
+	}
+	buf.WriteString(query)
+
+	return buf.String(), nil
+}
+
+// quote wraps every dot separated part of the identifier in the open and close quotes
+// doubling any close quote found inside of it
+func quote(ident, open, close string) string {
+	parts := strings.Split(ident, ".")
+	for i, p := range parts {
+		parts[i] = open + strings.Replace(p, close, close+close, -1) + close
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// quoteFolded quotes the dot separated parts of the identifier like quote, except the plain names with upper case
+// letters. The database folds the case of the names left bare, quoting them would make them case sensitive
+func quoteFolded(ident, open, close string) string {
+	parts := strings.Split(ident, ".")
+	for i, p := range parts {
+		if !plainName.MatchString(p) || p == strings.ToLower(p) {
+			parts[i] = quote(p, open, close)
+		}
+	}
+
+	return strings.Join(parts, ".")
+}
+
+var plainName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func savepoint(name string) (string, string, string) {
+	return "SAVEPOINT " + name, "ROLLBACK TO SAVEPOINT " + name, "RELEASE SAVEPOINT " + name
+}
@@ -0,0 +1,183 @@
+package flock_test
+
+import (
+	"testing"
+
+	flock "github.com/srikrsna/flock/pkg"
+)
+
+func TestGetDialect(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect flock.Dialect
+		wantErr bool
+	}{
+		{"postgres", flock.Postgres, false},
+		{"cloudsqlpostgres", flock.Postgres, false},
+		{"mysql", flock.MySQL, false},
+		{"sqlite3", flock.SQLite, false},
+		{"sqlserver", flock.MSSQL, false},
+		{"MSSQL", flock.MSSQL, false},
+		{"oracle", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := flock.GetDialect(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetDialect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if d != tt.dialect {
+				t.Errorf("expected: %v, got: %v", tt.dialect, d)
+			}
+		})
+	}
+}
+
+func TestBuildSingleInsertQuery(t *testing.T) {
+	columns := map[string]flock.Column{"id": {Value: "one", Functions: []flock.Func{}}, "order": {Value: "two", Functions: []flock.Func{}}}
+	table := flock.Table{Name: "Random", Keys: columns, Ordered: []string{"id", "order"}, Conflict: flock.Conflict{Action: flock.ConflictIgnore}}
+
+	tests := []struct {
+		dialect flock.Dialect
+		query   string
+		wantErr bool
+	}{
+		{flock.Postgres, `INSERT INTO "public".Random ("id","order") VALUES ($1,$2) ON CONFLICT DO NOTHING`, false},
+		{flock.MySQL, "INSERT IGNORE INTO `public`.`Random` (`id`,`order`) VALUES (?,?)", false},
+		{flock.SQLite, `INSERT OR IGNORE INTO "public".Random ("id","order") VALUES (?,?)`, false},
+		// SQL Server has nothing to merge on without keys
+		{flock.MSSQL, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name(), func(t *testing.T) {
+			query, err := flock.BuildSingleInsertQuery(table, "public.Random", tt.dialect)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildSingleInsertQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if query != tt.query {
+				t.Errorf("expected: %s, got: %s", tt.query, query)
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	if q := flock.MySQL.Quote("we`ird"); q != "`we``ird`" {
+		t.Errorf("unexpected quoted name: %s", q)
+	}
+	if q := flock.MSSQL.Quote("dbo.Users"); q != "[dbo].[Users]" {
+		t.Errorf("unexpected quoted name: %s", q)
+	}
+	// The names with upper case letters are left to the case folding of the database
+	if q := flock.Postgres.Quote("public.Random.order.First Name"); q != `"public".Random."order"."First Name"` {
+		t.Errorf("unexpected quoted name: %s", q)
+	}
+	if q := flock.SQLite.Quote("Users"); q != "Users" {
+		t.Errorf("unexpected quoted name: %s", q)
+	}
+}
+
+func TestCountQuery(t *testing.T) {
+	if q := flock.Postgres.CountQuery("public.Users"); q != `SELECT COUNT(*) FROM "public".Users` {
+		t.Errorf("unexpected count query: %s", q)
+	}
+	if q := flock.MSSQL.CountQuery("dbo.Users"); q != "SELECT COUNT_BIG(*) FROM [dbo].[Users]" {
+		t.Errorf("unexpected count query: %s", q)
+	}
+}
+
+func TestConflictStrategies(t *testing.T) {
+	columns := []string{"id", "name", "phone"}
+	rows := [][]interface{}{{1, "a", "1"}, {2, "b", "2"}}
+
+	tests := []struct {
+		name     string
+		dialect  flock.Dialect
+		conflict flock.Conflict
+		queries  []string
+		wantErr  bool
+	}{
+		{
+			"postgres-ignore", flock.Postgres, flock.Conflict{Action: flock.ConflictIgnore},
+			[]string{`INSERT INTO "users" ("id","name","phone") VALUES ($1,$2,$3),($4,$5,$6) ON CONFLICT DO NOTHING`}, false,
+		},
+		{
+			"postgres-update", flock.Postgres, flock.Conflict{Keys: []string{"id"}, Action: flock.ConflictUpdate},
+			[]string{`INSERT INTO "users" ("id","name","phone") VALUES ($1,$2,$3),($4,$5,$6) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name", "phone" = EXCLUDED."phone"`}, false,
+		},
+		{
+			"postgres-update-columns", flock.Postgres, flock.Conflict{Keys: []string{"id"}, Action: flock.ConflictUpdate, Columns: []string{"phone"}},
+			[]string{`INSERT INTO "users" ("id","name","phone") VALUES ($1,$2,$3),($4,$5,$6) ON CONFLICT ("id") DO UPDATE SET "phone" = EXCLUDED."phone"`}, false,
+		},
+		{
+			"postgres-update-no-keys", flock.Postgres, flock.Conflict{Action: flock.ConflictUpdate}, nil, true,
+		},
+		{
+			"postgres-fail", flock.Postgres, flock.Conflict{Action: flock.ConflictFail},
+			[]string{`INSERT INTO "users" ("id","name","phone") VALUES ($1,$2,$3),($4,$5,$6)`}, false,
+		},
+		{
+			"postgres-replace", flock.Postgres, flock.Conflict{Keys: []string{"id"}, Action: flock.ConflictReplace},
+			[]string{
+				`DELETE FROM "users" WHERE "id" IN ($1,$2)`,
+				`INSERT INTO "users" ("id","name","phone") VALUES ($1,$2,$3),($4,$5,$6)`,
+			}, false,
+		},
+		{
+			"postgres-replace-unknown-key", flock.Postgres, flock.Conflict{Keys: []string{"email"}, Action: flock.ConflictReplace}, nil, true,
+		},
+		{
+			"mysql-update", flock.MySQL, flock.Conflict{Keys: []string{"id"}, Action: flock.ConflictUpdate},
+			[]string{"INSERT INTO `users` (`id`,`name`,`phone`) VALUES (?,?,?),(?,?,?) ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `phone` = VALUES(`phone`)"}, false,
+		},
+		{
+			"mysql-replace", flock.MySQL, flock.Conflict{Keys: []string{"id", "name"}, Action: flock.ConflictReplace},
+			[]string{
+				"DELETE FROM `users` WHERE ((`id` = ? AND `name` = ?) OR (`id` = ? AND `name` = ?))",
+				"INSERT INTO `users` (`id`,`name`,`phone`) VALUES (?,?,?),(?,?,?)",
+			}, false,
+		},
+		{
+			"sqlite-update", flock.SQLite, flock.Conflict{Keys: []string{"id"}, Action: flock.ConflictUpdate, Columns: []string{"name"}},
+			[]string{`INSERT INTO "users" ("id","name","phone") VALUES (?,?,?),(?,?,?) ON CONFLICT ("id") DO UPDATE SET "name" = excluded."name"`}, false,
+		},
+		{
+			"sqlite-replace", flock.SQLite, flock.Conflict{Action: flock.ConflictReplace},
+			[]string{`INSERT OR REPLACE INTO "users" ("id","name","phone") VALUES (?,?,?),(?,?,?)`}, false,
+		},
+		{
+			"mssql-ignore", flock.MSSQL, flock.Conflict{Keys: []string{"id"}, Action: flock.ConflictIgnore},
+			[]string{"MERGE INTO [users] AS [target] USING (VALUES (@p1,@p2,@p3),(@p4,@p5,@p6)) AS [source] ([id],[name],[phone]) ON [target].[id] = [source].[id] WHEN NOT MATCHED THEN INSERT ([id],[name],[phone]) VALUES ([source].[id],[source].[name],[source].[phone]);"}, false,
+		},
+		{
+			"mssql-ignore-no-keys", flock.MSSQL, flock.Conflict{Action: flock.ConflictIgnore}, nil, true,
+		},
+		{
+			"mssql-update", flock.MSSQL, flock.Conflict{Keys: []string{"id"}, Action: flock.ConflictUpdate, Columns: []string{"name"}},
+			[]string{"MERGE INTO [users] AS [target] USING (VALUES (@p1,@p2,@p3),(@p4,@p5,@p6)) AS [source] ([id],[name],[phone]) ON [target].[id] = [source].[id] WHEN MATCHED THEN UPDATE SET [target].[name] = [source].[name] WHEN NOT MATCHED THEN INSERT ([id],[name],[phone]) VALUES ([source].[id],[source].[name],[source].[phone]);"}, false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmts, err := tt.dialect.Insert("users", columns, rows, tt.conflict)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Insert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(stmts) != len(tt.queries) {
+				t.Fatalf("expected %d statements, got %d", len(tt.queries), len(stmts))
+			}
+			for i, stmt := range stmts {
+				query, _, err := stmt.ToSql()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if query != tt.queries[i] {
+					t.Errorf("expected: %s, got: %s", tt.queries[i], query)
+				}
+			}
+		})
+	}
+}
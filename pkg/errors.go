@@ -0,0 +1,57 @@
+package flock
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RowError is the failure of a function while calculating the value of a column of a row
+type RowError struct {
+	// Row is the index of the row in its batch
+	Row int
+	// Entry is the entry of the .fl file the row belongs to
+	Entry string
+	// Column is the destination column whose value failed
+	Column string
+	// Func is the name of the function that failed
+	Func string
+	// Inputs are the arguments of the failed call with their values redacted, as the rows may hold personal data
+	Inputs []string
+	Err    error
+}
+
+func (e *RowError) Error() string {
+	at := fmt.Sprintf("entry %s, row %d", e.Entry, e.Row)
+	if e.Column != "" {
+		at += ", column " + e.Column
+	}
+	switch {
+	case e.Func != "" && len(e.Inputs) > 0:
+		at += fmt.Sprintf(", function %s(%s)", e.Func, strings.Join(e.Inputs, ", "))
+	case e.Func != "":
+		at += ", function " + e.Func
+	}
+
+	return fmt.Sprintf("%s: %v", at, e.Err)
+}
+
+// redact describes the values without giving them away, text only by its length
+func redact(in []reflect.Value) []string {
+	res := make([]string, len(in))
+	for i, v := range in {
+		for v.IsValid() && v.Kind() == reflect.Interface && !v.IsNil() {
+			v = v.Elem()
+		}
+		switch {
+		case !v.IsValid() || isNil(v):
+			res[i] = "NULL"
+		case v.Kind() == reflect.String || isBytes(v.Type()):
+			res[i] = fmt.Sprintf("%s(len %d)", v.Type(), v.Len())
+		default:
+			res[i] = v.Type().String()
+		}
+	}
+
+	return res
+}
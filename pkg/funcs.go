@@ -3,30 +3,102 @@ package flock
 import (
 	"fmt"
 	"reflect"
+	"sync"
 )
 
-var funcMap = map[string]reflect.Value{}
+// defaultFuncs holds the functions registered with RegisterFunc, every run starts with a copy of them
+var defaultFuncs = NewFuncs()
 
 // FuncMap ...
 type FuncMap map[string]interface{}
 
-var (
-	errorType = reflect.TypeOf((*error)(nil)).Elem()
-)
+// Funcs is a registry of the functions a schema can call. Each run keeps its own registry
+// so that the functions of its plugin don't leak into other runs
+type Funcs struct {
+	lock  sync.RWMutex
+	funcs map[string]reflect.Value
+}
 
-// RegisterFunc ...
-func RegisterFunc(fm FuncMap) {
+// NewFuncs returns an empty function registry
+func NewFuncs() *Funcs {
+	return &Funcs{funcs: make(map[string]reflect.Value)}
+}
+
+// DefaultFuncs returns a copy of the functions registered with RegisterFunc
+func DefaultFuncs() *Funcs {
+	return defaultFuncs.Clone()
+}
+
+// Register adds the functions to the registry, replacing the ones with the same name.
+// Nothing is added when one of them is not a valid function
+func (f *Funcs) Register(fm FuncMap) error {
+	values := make(map[string]reflect.Value, len(fm))
 	for name, v := range fm {
 		rv := reflect.ValueOf(v)
 		if rv.Kind() != reflect.Func {
-			panic("value for " + name + " not a function")
+			return fmt.Errorf("value for %s not a function", name)
 		}
 
 		if !goodFunc(rv.Type()) {
-			panic(fmt.Errorf("can't install method/function %q with %d results", name, rv.Type().NumOut()))
+			return fmt.Errorf("can't install method/function %q with %d results", name, rv.Type().NumOut())
 		}
 
-		funcMap[name] = rv
+		values[name] = rv
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for name, rv := range values {
+		f.funcs[name] = rv
+	}
+
+	return nil
+}
+
+// Lookup returns the function registered with the name
+func (f *Funcs) Lookup(name string) (reflect.Value, bool) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	rv, ok := f.funcs[name]
+	return rv, ok
+}
+
+// Clone returns a copy of the registry
+func (f *Funcs) Clone() *Funcs {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	c := &Funcs{funcs: make(map[string]reflect.Value, len(f.funcs))}
+	for name, rv := range f.funcs {
+		c.funcs[name] = rv
+	}
+
+	return c
+}
+
+// Map returns the functions of the registry
+func (f *Funcs) Map() FuncMap {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	fm := make(FuncMap, len(f.funcs))
+	for name, rv := range f.funcs {
+		fm[name] = rv.Interface()
+	}
+
+	return fm
+}
+
+var (
+	errorType = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// RegisterFunc registers functions available to every run, it panics when one of them is not a valid function
+func RegisterFunc(fm FuncMap) {
+	if err := defaultFuncs.Register(fm); err != nil {
+		panic(err)
 	}
 }
 
@@ -16,6 +16,32 @@ func TestRegisterFunc(t *testing.T) {
 	RegisterFunc(testFuncs)
 }
 
+func TestFuncs(t *testing.T) {
+	RegisterFunc(FuncMap{"one": func() int { return 1 }})
+
+	first, second := DefaultFuncs(), DefaultFuncs()
+	if err := first.Register(FuncMap{"one": func() int { return 11 }, "plugin": func() bool { return true }}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := second.Lookup("plugin"); ok {
+		t.Errorf("function registered in one registry is visible in another")
+	}
+	if fn, _ := second.Lookup("one"); fn.Call(nil)[0].Int() != 1 {
+		t.Errorf("function registered in one registry replaced the one of another")
+	}
+	if fn, _ := first.Lookup("one"); fn.Call(nil)[0].Int() != 11 {
+		t.Errorf("expected the function of the registry to replace the default one")
+	}
+
+	if err := second.Register(FuncMap{"bad": func() {}, "good": func() int { return 0 }}); err == nil {
+		t.Errorf("expected an error registering a function without results")
+	}
+	if _, ok := second.Lookup("good"); ok {
+		t.Errorf("expected nothing to be registered when one of the functions is invalid")
+	}
+}
+
 func TestGoodFunc(t *testing.T) {
 	testFuncs := []struct {
 		function interface{}
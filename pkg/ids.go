@@ -0,0 +1,538 @@
+package flock
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/dgraph-io/badger"
+	"github.com/elgris/sqrl"
+	"github.com/google/uuid"
+)
+
+// IDMapper maps the IDs of the rows of a source table to UUIDs. The same ID of the same table always
+// maps to the same UUID, so the foreign keys of the tables referring to it stay consistent
+type IDMapper interface {
+	Map(table, id string) (string, error)
+}
+
+// IDMapping is a legacy ID of a table and the UUID it is mapped to
+type IDMapping struct {
+	Old string `json:"old_id"`
+	New string `json:"new_id"`
+}
+
+// IDStore is an IDMapper that keeps its mappings, so that they can be exported and imported
+type IDStore interface {
+	IDMapper
+	// Export calls fn with every mapping of the table
+	Export(table string, fn func(IDMapping) error) error
+	// Import stores the mappings of the table and returns the number stored. IDs already mapped keep their UUID
+	Import(table string, mappings []IDMapping) (int64, error)
+}
+
+// defaultIDs backs the built-in ToGuid until the server chooses a mapper for its runs
+var defaultIDs = NewMemoryIDs()
+
+// DefaultIDs returns the mapper of the built-in ToGuid
+func DefaultIDs() IDMapper {
+	return defaultIDs
+}
+
+// checkMappings returns the mappings with their UUIDs in the canonical form, failing on anything that isn't a UUID
+func checkMappings(mappings []IDMapping) ([]IDMapping, error) {
+	res := make([]IDMapping, len(mappings))
+	for i, m := range mappings {
+		id, err := uuid.Parse(m.New)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UUID %q for the ID %s: %v", m.New, m.Old, err)
+		}
+		res[i] = IDMapping{m.Old, id.String()}
+	}
+
+	return res, nil
+}
+
+// ToGuid returns the function mapping the IDs of a table with the mapper, NULL IDs stay NULL
+//
+//   - id = ID | ToGuid "Users"
+func ToGuid(m IDMapper) func(table string, id interface{}) (interface{}, error) {
+	return func(table string, id interface{}) (interface{}, error) {
+		if id == nil {
+			return nil, nil
+		}
+
+		return m.Map(table, toString(id))
+	}
+}
+
+// NewUUID returns a random UUID, it is meant to give the value of a field
+//
+//   - id = NewUUID() per row
+func NewUUID() string {
+	return uuid.New().String()
+}
+
+// NewMemoryIDs returns a mapper that generates random UUIDs and keeps them as long as the process lives
+func NewMemoryIDs() IDMapper {
+	return &memoryIDs{ids: make(map[idKey]string)}
+}
+
+type idKey struct {
+	table, id string
+}
+
+type memoryIDs struct {
+	lock sync.Mutex
+	ids  map[idKey]string
+}
+
+func (m *memoryIDs) Map(table, id string) (string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	k := idKey{table, id}
+	if v, ok := m.ids[k]; ok {
+		return v, nil
+	}
+	v := uuid.New().String()
+	m.ids[k] = v
+
+	return v, nil
+}
+
+func (m *memoryIDs) Export(table string, fn func(IDMapping) error) error {
+	m.lock.Lock()
+	mappings := make([]IDMapping, 0)
+	for k, v := range m.ids {
+		if k.table == table {
+			mappings = append(mappings, IDMapping{k.id, v})
+		}
+	}
+	m.lock.Unlock()
+
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].Old < mappings[j].Old })
+	for _, mapping := range mappings {
+		if err := fn(mapping); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *memoryIDs) Import(table string, mappings []IDMapping) (int64, error) {
+	mappings, err := checkMappings(mappings)
+	if err != nil {
+		return 0, err
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var n int64
+	for _, mapping := range mappings {
+		k := idKey{table, mapping.Old}
+		if _, ok := m.ids[k]; !ok {
+			m.ids[k] = mapping.New
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+// NewUUIDv5IDs returns a mapper that derives the UUIDs from the namespace, the table and the ID.
+// Nothing is stored, the same namespace gives the same UUIDs on any server
+func NewUUIDv5IDs(namespace uuid.UUID) IDMapper {
+	return uuidv5IDs{namespace}
+}
+
+// DefaultIDNamespace is the namespace of the UUIDv5 mapper when none is chosen
+var DefaultIDNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("github.com/srikrsna/flock"))
+
+type uuidv5IDs struct {
+	namespace uuid.UUID
+}
+
+func (m uuidv5IDs) Map(table, id string) (string, error) {
+	// The length of the table name keeps table "a1" and ID "2" apart from table "a" and ID "12"
+	return uuid.NewSHA1(m.namespace, []byte(fmt.Sprintf("%d:%s:%s", len(table), table, id))).String(), nil
+}
+
+// NewBadgerIDs returns a mapper that generates random UUIDs and keeps them in a badger database
+// so that they survive server restarts
+func NewBadgerIDs(db *badger.DB) IDMapper {
+	return &badgerIDs{db: db}
+}
+
+type badgerIDs struct {
+	db *badger.DB
+}
+
+func (b *badgerIDs) Map(table, id string) (string, error) {
+	key := badgerIDKey(table, id)
+
+	// Most IDs are mapped already, they are read without a write transaction
+	var res uuid.UUID
+	err := b.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		v, err := item.Value()
+		if err != nil {
+			return err
+		}
+		res, err = uuid.FromBytes(v)
+		return err
+	})
+	if err == nil {
+		return res.String(), nil
+	}
+	if err != badger.ErrKeyNotFound {
+		return "", err
+	}
+
+	for {
+		err := b.db.Update(func(txn *badger.Txn) error {
+			item, err := txn.Get(key)
+			if err == badger.ErrKeyNotFound {
+				res = uuid.New()
+				return txn.Set(key, res[:])
+			}
+			if err != nil {
+				return err
+			}
+
+			v, err := item.Value()
+			if err != nil {
+				return err
+			}
+			res, err = uuid.FromBytes(v)
+			return err
+		})
+		// Another run mapped the same ID at the same time, its UUID is read on the next attempt
+		if err == badger.ErrConflict {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		break
+	}
+
+	return res.String(), nil
+}
+
+// badgerIDPrefix is the prefix of the keys of the table, the length of the name keeps the tables apart
+func badgerIDPrefix(table string) []byte {
+	return []byte(fmt.Sprintf("ids/%d:%s:", len(table), table))
+}
+
+func badgerIDKey(table, id string) []byte {
+	return append(badgerIDPrefix(table), id...)
+}
+
+func (b *badgerIDs) Export(table string, fn func(IDMapping) error) error {
+	prefix := badgerIDPrefix(table)
+
+	return b.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			v, err := it.Item().Value()
+			if err != nil {
+				return err
+			}
+			id, err := uuid.FromBytes(v)
+			if err != nil {
+				return err
+			}
+
+			if err := fn(IDMapping{string(bytes.TrimPrefix(it.Item().Key(), prefix)), id.String()}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// badgerImportBatch is the number of mappings imported in a transaction, badger limits the size of transactions
+const badgerImportBatch = 1000
+
+func (b *badgerIDs) Import(table string, mappings []IDMapping) (int64, error) {
+	mappings, err := checkMappings(mappings)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	for len(mappings) > 0 {
+		batch := mappings
+		if len(batch) > badgerImportBatch {
+			batch = batch[:badgerImportBatch]
+		}
+
+		var stored int64
+		err := b.db.Update(func(txn *badger.Txn) error {
+			stored = 0
+			for _, m := range batch {
+				key := badgerIDKey(table, m.Old)
+				_, err := txn.Get(key)
+				if err == nil {
+					continue
+				}
+				if err != badger.ErrKeyNotFound {
+					return err
+				}
+
+				id := uuid.Must(uuid.Parse(m.New))
+				if err := txn.Set(key, id[:]); err != nil {
+					return err
+				}
+				stored++
+			}
+			return nil
+		})
+		if err == badger.ErrConflict {
+			continue
+		}
+		if err != nil {
+			return n, err
+		}
+
+		n += stored
+		mappings = mappings[len(batch):]
+	}
+
+	return n, nil
+}
+
+// SQLIDsDB is the connection to the database keeping the tables of a SQL mapper
+type SQLIDsDB interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// NewSQLIDs returns a mapper that keeps the UUIDs in the database, usually the destination of the runs. The IDs of a
+// table are kept in the table of the same name in the schema, guid for the stores made before, with the columns old
+// and new and a primary key on old. The mappings it makes are only written by Flush, so that a run writes them in
+// its own transaction
+func NewSQLIDs(db SQLIDsDB, schema string, dialect Dialect) IDMapper {
+	return &sqlIDs{db: db, schema: schema, dialect: dialect, ids: make(map[idKey]string), pending: make(map[string][]IDMapping)}
+}
+
+// IDWriter is an IDMapper whose new mappings are written with the rows using them
+type IDWriter interface {
+	IDMapper
+	// Flush writes the mappings made since the last call with db, usually the transaction inserting the rows
+	Flush(ctx context.Context, db sqrl.ExecerContext) error
+	// Release forgets the mappings written by Flush once their transaction has committed or rolled back, the
+	// committed ones are looked up in the database from then on
+	Release()
+}
+
+var idColumns = []string{"old", "new"}
+
+type sqlIDs struct {
+	db      SQLIDsDB
+	schema  string
+	dialect Dialect
+
+	lock sync.Mutex
+	// ids holds the UUIDs made by the mapper and not committed yet, by idKey. Only the mappings of the open
+	// transaction are kept in memory, the other ones are looked up
+	ids map[idKey]string
+	// pending holds the mappings made and not written yet, by table
+	pending map[string][]IDMapping
+	// flushed holds the IDs written in the open transaction
+	flushed []idKey
+}
+
+// store returns the name of the table keeping the IDs of the table
+func (s *sqlIDs) store(table string) string {
+	return s.schema + "." + table
+}
+
+func (s *sqlIDs) Map(table, id string) (string, error) {
+	k := idKey{table, id}
+	s.lock.Lock()
+	v, ok := s.ids[k]
+	s.lock.Unlock()
+	if ok {
+		return v, nil
+	}
+
+	v, err := s.lookup(table, id)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to map the ID %s with %s: %v", id, s.store(table), err)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Another call may have mapped the same ID in the meantime
+	if v, ok := s.ids[k]; ok {
+		return v, nil
+	}
+	if err == sql.ErrNoRows {
+		v = uuid.New().String()
+		s.pending[table] = append(s.pending[table], IDMapping{id, v})
+		s.ids[k] = v
+	}
+
+	return v, nil
+}
+
+func (s *sqlIDs) Flush(ctx context.Context, db sqrl.ExecerContext) error {
+	s.lock.Lock()
+	pending := s.pending
+	s.pending = make(map[string][]IDMapping)
+	// The mappings are released with the transaction, whether they are all written or not
+	for table, mappings := range pending {
+		for _, m := range mappings {
+			s.flushed = append(s.flushed, idKey{table, m.Old})
+		}
+	}
+	s.lock.Unlock()
+
+	tables := make([]string, 0, len(pending))
+	for table := range pending {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	// An ID mapped by another run in the meantime fails the insert rather than leaving rows with another UUID
+	size := s.dialect.MaxParams() / len(idColumns)
+	for _, table := range tables {
+		mappings := pending[table]
+		for len(mappings) > 0 {
+			batch := mappings
+			if len(batch) > size {
+				batch = batch[:size]
+			}
+			if _, err := s.write(ctx, db, table, batch, Conflict{}); err != nil {
+				return fmt.Errorf("failed to store the IDs in %s: %v", s.store(table), err)
+			}
+			mappings = mappings[len(batch):]
+		}
+	}
+
+	return nil
+}
+
+func (s *sqlIDs) Release() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, k := range s.flushed {
+		delete(s.ids, k)
+	}
+	s.flushed = nil
+}
+
+// write inserts the mappings with the conflict clause and returns the number of rows inserted
+func (s *sqlIDs) write(ctx context.Context, db sqrl.ExecerContext, table string, mappings []IDMapping, conflict Conflict) (int64, error) {
+	values := make([][]interface{}, len(mappings))
+	for i, m := range mappings {
+		values[i] = []interface{}{m.Old, m.New}
+	}
+
+	stmts, err := s.dialect.Insert(s.store(table), idColumns, values, conflict)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for _, stmt := range stmts {
+		query, args, err := stmt.ToSql()
+		if err != nil {
+			return n, err
+		}
+		res, err := db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return n, err
+		}
+		if affected, err := res.RowsAffected(); err == nil {
+			n += affected
+		}
+	}
+
+	return n, nil
+}
+
+func (s *sqlIDs) Export(table string, fn func(IDMapping) error) error {
+	query, args, err := sqrl.Select(s.dialect.Quote("old"), s.dialect.Quote("new")).
+		From(s.dialect.Quote(s.store(table))).
+		OrderBy(s.dialect.Quote("old")).
+		PlaceholderFormat(s.dialect.Placeholder()).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.db.QueryContext(context.Background(), query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m IDMapping
+		if err := rows.Scan(&m.Old, &m.New); err != nil {
+			return err
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *sqlIDs) Import(table string, mappings []IDMapping) (int64, error) {
+	mappings, err := checkMappings(mappings)
+	if err != nil {
+		return 0, err
+	}
+
+	// Every mapping takes a parameter per column
+	size := s.dialect.MaxParams() / len(idColumns)
+	var n int64
+	for len(mappings) > 0 {
+		batch := mappings
+		if len(batch) > size {
+			batch = batch[:size]
+		}
+		// IDs already mapped keep their UUID
+		stored, err := s.write(context.Background(), s.db, table, batch, Conflict{Keys: idColumns[:1], Action: ConflictIgnore})
+		n += stored
+		if err != nil {
+			return n, fmt.Errorf("failed to import the IDs into %s: %v", s.store(table), err)
+		}
+		mappings = mappings[len(batch):]
+	}
+
+	return n, nil
+}
+
+func (s *sqlIDs) lookup(table, id string) (string, error) {
+	query, args, err := sqrl.Select(s.dialect.Quote("new")).
+		From(s.dialect.Quote(s.store(table))).
+		Where(s.dialect.Quote("old")+" = ?", id).
+		PlaceholderFormat(s.dialect.Placeholder()).
+		ToSql()
+	if err != nil {
+		return "", err
+	}
+
+	var v string
+	err = s.db.QueryRow(query, args...).Scan(&v)
+	return v, err
+}
@@ -0,0 +1,258 @@
+package flock_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/dgraph-io/badger"
+	"github.com/google/uuid"
+	flock "github.com/srikrsna/flock/pkg"
+)
+
+func TestIDMappers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ids")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	opts := badger.DefaultOptions
+	opts.Dir = dir
+	opts.ValueDir = dir
+	db, err := badger.Open(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		ids  flock.IDMapper
+	}{
+		{"Memory", flock.NewMemoryIDs()},
+		{"Badger", flock.NewBadgerIDs(db)},
+		{"UUIDv5", flock.NewUUIDv5IDs(flock.DefaultIDNamespace)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := tt.ids.Map("Users", "12")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := uuid.Parse(a); err != nil {
+				t.Errorf("not a UUID: %s", a)
+			}
+			if again, _ := tt.ids.Map("Users", "12"); again != a {
+				t.Errorf("the same ID mapped to %s and %s", a, again)
+			}
+			if other, _ := tt.ids.Map("Users1", "2"); other == a {
+				t.Errorf("different IDs mapped to the same UUID %s", a)
+			}
+		})
+	}
+
+	// The badger mapper keeps its IDs when the database is opened again
+	before, _ := flock.NewBadgerIDs(db).Map("Users", "12")
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if db, err = badger.Open(opts); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if after, _ := flock.NewBadgerIDs(db).Map("Users", "12"); after != before {
+		t.Errorf("the ID mapped to %s after a restart, it was %s", after, before)
+	}
+
+	// The UUIDv5 mapper is deterministic
+	a, _ := flock.NewUUIDv5IDs(flock.DefaultIDNamespace).Map("Users", "12")
+	b, _ := flock.NewUUIDv5IDs(flock.DefaultIDNamespace).Map("Users", "12")
+	c, _ := flock.NewUUIDv5IDs(uuid.NameSpaceDNS).Map("Users", "12")
+	if a != b || a == c {
+		t.Errorf("unexpected UUIDv5 IDs: %s, %s and %s", a, b, c)
+	}
+}
+
+func TestSQLIDs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	const id = "0f8fad5b-d9cb-469f-a165-70867728950e"
+	// The IDs stored already are looked up every time they are mapped
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery(`SELECT "new" FROM "guid".Users WHERE "old" = \$1`).
+			WithArgs("12").
+			WillReturnRows(sqlmock.NewRows([]string{"new"}).AddRow(id))
+	}
+	mock.ExpectQuery(`SELECT "new" FROM "guid".Users WHERE "old" = \$1`).
+		WithArgs("13").
+		WillReturnRows(sqlmock.NewRows([]string{"new"}))
+	// The new mappings are only written by Flush, in the transaction it is given
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "guid".Users \("old","new"\) VALUES \(\$1,\$2\)$`).
+		WithArgs("13", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// Only the mappings of the open transaction are kept in memory, the committed ones are looked up again
+	mock.ExpectQuery(`SELECT "new" FROM "guid".Users WHERE "old" = \$1`).
+		WithArgs("13").
+		WillReturnRows(sqlmock.NewRows([]string{"new"}).AddRow(id))
+
+	ids := flock.NewSQLIDs(db, "guid", flock.Postgres)
+	toGuid := flock.ToGuid(ids)
+	for i := 0; i < 2; i++ {
+		got, err := toGuid("Users", int64(12))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != id {
+			t.Errorf("ToGuid() = %v, want %v", got, id)
+		}
+	}
+	a, err := toGuid("Users", "13")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := toGuid("Users", 13); a != b || a == id {
+		t.Errorf("unexpected UUIDs for a new ID: %v and %v", a, b)
+	}
+	if got, err := toGuid("Users", nil); got != nil || err != nil {
+		t.Errorf("ToGuid() = %v, %v for NULL", got, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := ids.(flock.IDWriter)
+	for i := 0; i < 2; i++ {
+		if err := w.Flush(context.Background(), tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if b, _ := toGuid("Users", "13"); a != b {
+		t.Errorf("the flushed ID mapped to %v, it was %v", b, a)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	w.Release()
+	if got, _ := toGuid("Users", "13"); got != id {
+		t.Errorf("ToGuid() = %v, want the stored %v", got, id)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIDStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ids")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	opts := badger.DefaultOptions
+	opts.Dir = dir
+	opts.ValueDir = dir
+	db, err := badger.Open(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	const (
+		a = "0f8fad5b-d9cb-469f-a165-70867728950e"
+		b = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	)
+	tests := []struct {
+		name string
+		ids  flock.IDMapper
+	}{
+		{"Memory", flock.NewMemoryIDs()},
+		{"Badger", flock.NewBadgerIDs(db)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := tt.ids.(flock.IDStore)
+			existing, err := ids.Map("Users", "1")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			n, err := ids.Import("Users", []flock.IDMapping{{"1", a}, {"2", strings.ToUpper(b)}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != 1 {
+				t.Errorf("Import() = %d, want 1", n)
+			}
+			if got, _ := ids.Map("Users", "2"); got != b {
+				t.Errorf("the imported ID mapped to %s, want %s", got, b)
+			}
+			if _, err := ids.Import("Users", []flock.IDMapping{{"3", "not a uuid"}}); err == nil {
+				t.Error("expected an error for an invalid UUID")
+			}
+
+			got := make([]flock.IDMapping, 0)
+			if err := ids.Export("Users", func(m flock.IDMapping) error {
+				got = append(got, m)
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if want := []flock.IDMapping{{"1", existing}, {"2", b}}; !reflect.DeepEqual(got, want) {
+				t.Errorf("Export() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSQLIDsImportExport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	const id = "0f8fad5b-d9cb-469f-a165-70867728950e"
+	mock.ExpectExec(`INSERT INTO "guid".Users \("old","new"\) VALUES \(\$1,\$2\),\(\$3,\$4\) ON CONFLICT`).
+		WithArgs("1", id, "2", id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT "old", "new" FROM "guid".Users ORDER BY "old"`).
+		WillReturnRows(sqlmock.NewRows([]string{"old", "new"}).AddRow("1", id).AddRow("2", id))
+
+	ids := flock.NewSQLIDs(db, "guid", flock.Postgres).(flock.IDStore)
+	n, err := ids.Import("Users", []flock.IDMapping{{"1", id}, {"2", id}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("Import() = %d, want 1", n)
+	}
+
+	count := 0
+	if err := ids.Export("Users", func(flock.IDMapping) error {
+		count++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("Export() returned %d mappings, want 2", count)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
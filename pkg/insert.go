@@ -2,8 +2,11 @@ package flock
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/elgris/sqrl"
 )
@@ -11,95 +14,360 @@ import (
 var sqlLimit = 1000
 
 // InsertBulk ...
-func InsertBulk(ctx context.Context, db sqrl.ExecerContext, rows []map[string]interface{}, table Table, tableName string, format sqrl.PlaceholderFormat, varFields map[string]Variable) error {
-	for sqlLimit < len(rows) {
-		if err := insertBulk(ctx, db, rows[0:sqlLimit], table, tableName, funcMap, format, varFields); err != nil {
-			return err
-		}
-		rows = rows[sqlLimit:]
+func InsertBulk(ctx context.Context, db sqrl.ExecerContext, rows []map[string]interface{}, table Table, tableName string, dialect Dialect, funcs *Funcs, varFields map[string]Variable) error {
+	values, err := PrepareRows(rows, table, funcs, varFields)
+	if err != nil {
+		return err
 	}
 
-	return insertBulk(ctx, db, rows, table, tableName, funcMap, format, varFields)
+	_, err = InsertValues(ctx, db, values, table, tableName, dialect)
+	return err
 }
 
-func insertBulk(ctx context.Context, db sqrl.ExecerContext, rows []map[string]interface{}, table Table, tableName string, funcMap map[string]reflect.Value, format sqrl.PlaceholderFormat, varFields map[string]Variable) error {
-	inst := BuildInsertStatement(table, tableName, format)
-	for _, row := range rows {
-		data, err := CalculateValuesOfRow(row, table, funcMap, varFields)
+// PrepareRows calculates the values of the columns of the table for every row, leaving out the rows
+// failing the where clause of the table. It doesn't touch the database, so batches can be prepared concurrently
+func PrepareRows(rows []map[string]interface{}, table Table, funcs *Funcs, varFields map[string]Variable) ([][]interface{}, error) {
+	values := make([][]interface{}, 0, len(rows))
+	for i, row := range rows {
+		data, _, err := PrepareRow(row, table, funcs, varFields)
 		if err != nil {
-			return err
+			if rerr, ok := err.(*RowError); ok {
+				rerr.Row = i
+			}
+			return nil, err
 		}
 
-		inst = inst.Values(data...)
+		values = append(values, data...)
 	}
 
-	query, args, err := inst.ToSql()
+	return values, nil
+}
+
+// PrepareRow calculates the values of the destination rows of the row, the ones passing the where clause of the
+// table, and returns the number of rows left out by it
+func PrepareRow(row map[string]interface{}, table Table, funcs *Funcs, varFields map[string]Variable) ([][]interface{}, int, error) {
+	values, err := CalculateValuesOfRow(row, table, funcs, varFields)
 	if err != nil {
-		return err
+		return nil, 0, err
+	}
+
+	kept := values[:0]
+	for _, v := range values {
+		keep, err := table.Keep(row, v, funcs)
+		if err != nil {
+			return nil, 0, &RowError{Entry: table.Name, Err: fmt.Errorf("where: %v", err)}
+		}
+		if keep {
+			kept = append(kept, v)
+		}
+	}
+
+	return kept, len(values) - len(kept), nil
+}
+
+// InsertValues inserts prepared values into the table, splitting them into as many statements as the dialect needs.
+// It returns the number of rows inserted, rows left out by the conflict clause of the table aren't counted
+func InsertValues(ctx context.Context, db sqrl.ExecerContext, values [][]interface{}, table Table, tableName string, dialect Dialect) (int64, error) {
+	var inserted int64
+	limit := statementLimit(table, dialect)
+	for limit < len(values) {
+		n, err := insertValues(ctx, db, values[0:limit], table, tableName, dialect)
+		if err != nil {
+			return inserted, err
+		}
+		inserted += n
+		values = values[limit:]
+	}
+
+	n, err := insertValues(ctx, db, values, table, tableName, dialect)
+	return inserted + n, err
+}
+
+// InsertValuesEach inserts prepared values like InsertValues, except that the rows rejected by the database
+// don't fail the others. The rows are inserted at once first and one by one when that fails, every attempt
+// inside a savepoint. reject is called with the index and the error of every rejected row
+func InsertValuesEach(ctx context.Context, db sqrl.ExecerContext, values [][]interface{}, table Table, tableName string, dialect Dialect, reject func(i int, err error)) (int64, error) {
+	save, rollback, release := dialect.Savepoint("flock_batch")
+	if _, err := db.ExecContext(ctx, save); err != nil {
+		return 0, err
+	}
+
+	inserted, err := InsertValues(ctx, db, values, table, tableName, dialect)
+	if err == nil {
+		return inserted, execIf(ctx, db, release)
 	}
+	if _, err := db.ExecContext(ctx, rollback); err != nil {
+		return 0, err
+	}
+
+	inserted = 0
+	rowSave, rowRollback, rowRelease := dialect.Savepoint("flock_row")
+	for i := range values {
+		if _, err := db.ExecContext(ctx, rowSave); err != nil {
+			return inserted, err
+		}
 
-	_, err = db.ExecContext(ctx, query, args...)
+		n, err := InsertValues(ctx, db, values[i:i+1], table, tableName, dialect)
+		if err != nil {
+			if _, err := db.ExecContext(ctx, rowRollback); err != nil {
+				return inserted, err
+			}
+			reject(i, err)
+			continue
+		}
+		if err := execIf(ctx, db, rowRelease); err != nil {
+			return inserted, err
+		}
+		inserted += n
+	}
+
+	return inserted, execIf(ctx, db, release)
+}
+
+// execIf executes the statement unless it is empty
+func execIf(ctx context.Context, db sqrl.ExecerContext, query string) error {
+	if query == "" {
+		return nil
+	}
+
+	_, err := db.ExecContext(ctx, query)
 	return err
 }
 
-// CalculateValuesOfRow ...
-func CalculateValuesOfRow(row map[string]interface{}, table Table, funcMap map[string]reflect.Value, varFields map[string]Variable) ([]interface{}, error) {
-	data := make([]interface{}, 0, len(row))
-	for _, key := range table.Ordered {
+// statementLimit returns the number of rows that fit in a single insert statement
+// without going over the row limit or the parameter limit of the dialect
+func statementLimit(table Table, dialect Dialect) int {
+	limit := sqlLimit
+	if n := len(table.Ordered); n > 0 && dialect.MaxParams()/n < limit {
+		limit = dialect.MaxParams() / n
+	}
+	if limit < 1 {
+		limit = 1
+	}
+
+	return limit
+}
+
+func insertValues(ctx context.Context, db sqrl.ExecerContext, values [][]interface{}, table Table, tableName string, dialect Dialect) (int64, error) {
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	stmts, err := BuildInsertStatements(table, tableName, dialect, values)
+	if err != nil {
+		return 0, err
+	}
+
+	var res sql.Result
+	for _, stmt := range stmts {
+		query, args, err := stmt.ToSql()
+		if err != nil {
+			return 0, err
+		}
+
+		if res, err = db.ExecContext(ctx, query, args...); err != nil {
+			return 0, err
+		}
+	}
+
+	// The insert is the last statement, the ones before it only make room for the rows
+	n, err := res.RowsAffected()
+	if err != nil || n > int64(len(values)) {
+		// Drivers that can't tell, or that count updated rows twice, are taken to have inserted every row
+		return int64(len(values)), nil
+	}
+
+	return n, nil
+}
+
+// CalculateValuesOfRow returns the values of the columns of the table for the destination rows of the row. It is a
+// single row unless a column explodes the row, which makes a row of every element it returns, none for an empty one.
+// A call evaluated per row is evaluated for every destination row, the other columns repeat the values of the row
+func CalculateValuesOfRow(row map[string]interface{}, table Table, funcs *Funcs, varFields map[string]Variable) ([][]interface{}, error) {
+	rows := [][]interface{}{make([]interface{}, 0, len(table.Ordered))}
+	// The calls evaluated per row are filled in once the rows are exploded
+	perRow := make([]int, 0)
+	for i, key := range table.Ordered {
 		col := table.Keys[key]
-		rv := row[col.Value]
-		v := varFields[col.Value]
-		var i reflect.Value
-		if rv != nil {
-			i = reflect.ValueOf(rv)
-		} else {
-			i = reflect.Zero(reflect.TypeOf((*error)(nil)).Elem())
+		if col.Source != nil && col.Source.PerRow && !col.explodes() {
+			perRow = append(perRow, i)
+			for j := range rows {
+				rows[j] = append(rows[j], nil)
+			}
+			continue
+		}
+
+		vs, err := columnValues(row, table, key, funcs, varFields[key])
+		if err != nil {
+			return nil, err
+		}
+		if len(vs) == 1 {
+			for j := range rows {
+				rows[j] = append(rows[j], vs[0])
+			}
+			continue
+		}
+
+		// Every destination row gets its own copy of the values before it
+		next := make([][]interface{}, 0, len(rows)*len(vs))
+		for _, r := range rows {
+			for _, v := range vs {
+				next = append(next, append(r[:len(r):len(r)], v))
+			}
+		}
+		rows = next
+	}
+
+	for _, r := range rows {
+		for _, i := range perRow {
+			key := table.Ordered[i]
+			vs, err := columnValues(row, table, key, funcs, varFields[key])
+			if err != nil {
+				return nil, err
+			}
+			r[i] = vs[0]
+		}
+	}
+
+	return rows, nil
+}
+
+// columnValues returns the values of the column for the row, more than one when it explodes the row
+func columnValues(row map[string]interface{}, table Table, key string, funcs *Funcs, v Variable) ([]interface{}, error) {
+	col := table.Keys[key]
+	rv := row[col.Value]
+	if col.Source != nil {
+		var err error
+		if rv, err = col.Source.Value(row, funcs); err != nil {
+			rerr := &RowError{Entry: table.Name, Column: key, Err: err}
+			if col.Source.Call != nil {
+				rerr.Func = col.Source.Call.Name
+			}
+			return nil, rerr
 		}
+	}
 
-		for _, f := range col.Functions {
+	var i reflect.Value
+	if rv != nil {
+		i = reflect.ValueOf(rv)
+	} else {
+		i = reflect.Zero(reflect.TypeOf((*error)(nil)).Elem())
+	}
+	values := []reflect.Value{i}
+	if col.Explode {
+		var err error
+		if values, err = explode(i); err != nil {
+			rerr := &RowError{Entry: table.Name, Column: key, Err: err}
+			if col.Source != nil && col.Source.Call != nil {
+				rerr.Func = col.Source.Call.Name
+			}
+			return nil, rerr
+		}
+	}
+
+	for _, f := range col.Functions {
+		fn, ok := funcs.Lookup(f.Name)
+		if !ok {
+			return nil, &RowError{Entry: table.Name, Column: key, Func: f.Name, Err: fmt.Errorf("function %s is not registered", f.Name)}
+		}
+
+		results := make([]reflect.Value, 0, len(values))
+		for _, i := range values {
+			// The parameters are shared by every row, so they are copied before filling in the variables
+			in := make([]reflect.Value, len(f.Parameters), len(f.Parameters)+1)
+			copy(in, f.Parameters)
 			if v.Func == f.Name {
 				for i, k := range v.index {
-					f.Parameters[k] = reflect.ValueOf(row[v.Column[i]])
+					in[k] = reflect.ValueOf(row[v.Column[i]])
 				}
 			}
-			in := append(f.Parameters, i)
+			in = append(in, i)
 
-			rt := funcMap[f.Name].Call(in)
-			if len(rt) == 1 {
-				i = rt[0]
+			rt, err := callFunc(fn, in)
+			if err != nil {
+				return nil, &RowError{Entry: table.Name, Column: key, Func: f.Name, Inputs: redact(in), Err: err}
+			}
+			if len(rt) == 2 && !rt[1].IsNil() {
+				return nil, &RowError{Entry: table.Name, Column: key, Func: f.Name, Inputs: redact(in), Err: rt[1].Interface().(error)} // The check for error on 2nd return is done by goodFunc()
 			}
 
-			if len(rt) == 2 {
-				if !rt[1].IsNil() {
-					return nil, rt[1].Interface().(error) // The check for error on 2nd return is done by goodFunc()
-				}
-
-				i = rt[0]
+			if !f.Explode {
+				results = append(results, rt[0])
+				continue
+			}
+			elems, err := explode(rt[0])
+			if err != nil {
+				return nil, &RowError{Entry: table.Name, Column: key, Func: f.Name, Inputs: redact(in), Err: err}
 			}
+			results = append(results, elems...)
 		}
-		data = append(data, i.Interface())
+		values = results
 	}
 
-	return data, nil
+	res := make([]interface{}, len(values))
+	for i, v := range values {
+		res[i] = v.Interface()
+	}
+
+	return res, nil
 }
 
-// BuildInsertStatement ...
-func BuildInsertStatement(table Table, tableName string, format sqrl.PlaceholderFormat) *sqrl.InsertBuilder {
-	cols := make([]string, 0, len(table.Keys))
-	for _, key := range table.Ordered {
-		cols = append(cols, key)
+// explodes tells whether the column explodes the row
+func (c Column) explodes() bool {
+	if c.Explode {
+		return true
+	}
+	for _, f := range c.Functions {
+		if f.Explode {
+			return true
+		}
 	}
 
-	return sqrl.Insert(tableName).PlaceholderFormat(format).Columns(cols...).Suffix("ON CONFLICT DO NOTHING")
+	return false
+}
+
+// explode returns the elements of a slice or an array, NULL has none
+func explode(v reflect.Value) ([]reflect.Value, error) {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array || isBytes(v.Type()) {
+		return nil, fmt.Errorf("can't explode a %s, it isn't a slice", v.Type())
+	}
+
+	res := make([]reflect.Value, v.Len())
+	for i := range res {
+		res[i] = v.Index(i)
+	}
+
+	return res, nil
+}
+
+// BuildInsertStatements ...
+func BuildInsertStatements(table Table, tableName string, dialect Dialect, values [][]interface{}) ([]sqrl.Sqlizer, error) {
+	return dialect.Insert(tableName, table.Ordered, values, table.Conflict)
 }
 
 // BuildSingleInsertQuery ...
-func BuildSingleInsertQuery(table Table, tableName string, format sqrl.PlaceholderFormat) (string, error) {
-	query, _, err := BuildInsertStatement(table, tableName, format).
-		Values(make([]interface{}, len(table.Keys))).
-		ToSql()
+func BuildSingleInsertQuery(table Table, tableName string, dialect Dialect) (string, error) {
+	stmts, err := BuildInsertStatements(table, tableName, dialect, [][]interface{}{make([]interface{}, len(table.Keys))})
+	if err != nil {
+		return "", err
+	}
+
+	queries := make([]string, 0, len(stmts))
+	for _, stmt := range stmts {
+		query, _, err := stmt.ToSql()
+		if err != nil {
+			return "", err
+		}
+		queries = append(queries, query)
+	}
 
-	return query, err
+	return strings.Join(queries, ";\n"), nil
 }
 
 // SetLimit ...
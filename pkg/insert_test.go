@@ -2,18 +2,20 @@ package flock_test
 
 import (
 	"context"
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
-	"github.com/elgris/sqrl"
 	flock "github.com/srikrsna/flock/pkg"
 )
 
 func TestInsertBulk(t *testing.T) {
 
-	columns := map[string]flock.Column{"First": {"one", []flock.Func{}}, "Second": {"two", []flock.Func{}}, "Third": {"three", []flock.Func{}}}
+	columns := map[string]flock.Column{"First": {Value: "one", Functions: []flock.Func{}}, "Second": {Value: "two", Functions: []flock.Func{}}, "Third": {Value: "three", Functions: []flock.Func{}}}
 
-	table := flock.Table{"Random", columns, []string{"First", "Second", "Third"}}
+	table := flock.Table{Name: "Random", Keys: columns, Ordered: []string{"First", "Second", "Third"}, Conflict: flock.Conflict{Action: flock.ConflictIgnore}}
 
 	rows := make([]map[string]interface{}, 4)
 
@@ -68,7 +70,7 @@ func TestInsertBulk(t *testing.T) {
 				mock.ExpectExec("INSERT INTO ").WillReturnResult(sqlmock.NewResult(1, 1))
 			}
 
-			if err := flock.InsertBulk(context.Background(), tx, rows, table, "Random", sqrl.Dollar, nil); err != nil {
+			if err := flock.InsertBulk(context.Background(), tx, rows, table, "Random", flock.Postgres, flock.NewFuncs(), nil); err != nil {
 				t.Errorf("Couldn't insert data: %v", err)
 			}
 
@@ -79,3 +81,188 @@ func TestInsertBulk(t *testing.T) {
 	}
 
 }
+
+func TestPrepareRows(t *testing.T) {
+	funcs := flock.NewFuncs()
+	if err := funcs.Register(flock.FuncMap{
+		"Positive": func(v int) (int, error) {
+			if v < 0 {
+				return 0, errors.New("negative")
+			}
+			return v, nil
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	table := flock.Table{
+		Name:    "Random",
+		Keys:    map[string]flock.Column{"First": {Value: "one"}, "Second": {Value: "two", Functions: []flock.Func{{Name: "Positive"}}}},
+		Ordered: []string{"First", "Second"},
+	}
+
+	values, err := flock.PrepareRows([]map[string]interface{}{{"one": 1, "two": 2}}, table, funcs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := [][]interface{}{{1, 2}}; !reflect.DeepEqual(values, exp) {
+		t.Errorf("expected: %v, got: %v", exp, values)
+	}
+
+	_, err = flock.PrepareRows([]map[string]interface{}{{"one": 1, "two": 2}, {"one": 1, "two": -2}}, table, funcs, nil)
+	rerr, ok := err.(*flock.RowError)
+	if !ok {
+		t.Fatalf("expected a row error, got: %v", err)
+	}
+	if rerr.Row != 1 || rerr.Column != "Second" || rerr.Func != "Positive" {
+		t.Errorf("unexpected row error: %v", rerr)
+	}
+}
+
+func TestPrepareRowsPanic(t *testing.T) {
+	funcs := flock.NewFuncs()
+	if err := funcs.Register(flock.FuncMap{
+		"Index": func(i int64, v string) string { return string(v[i]) },
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	table := flock.Table{
+		Name:    "Users",
+		Keys:    map[string]flock.Column{"initial": {Value: "name", Functions: []flock.Func{{Name: "Index", Parameters: []reflect.Value{reflect.ValueOf(int64(5))}}}}},
+		Ordered: []string{"initial"},
+	}
+
+	_, err := flock.PrepareRows([]map[string]interface{}{{"name": "Elizabeth"}, {"name": "Jane"}}, table, funcs, nil)
+	rerr, ok := err.(*flock.RowError)
+	if !ok {
+		t.Fatalf("expected a row error, got: %v", err)
+	}
+	if rerr.Entry != "Users" || rerr.Row != 1 || rerr.Column != "initial" || rerr.Func != "Index" {
+		t.Errorf("unexpected row error: %v", rerr)
+	}
+	if want := []string{"int64", "string(len 4)"}; !reflect.DeepEqual(rerr.Inputs, want) {
+		t.Errorf("got inputs %v, want %v", rerr.Inputs, want)
+	}
+	if strings.Contains(err.Error(), "Jane") || !strings.Contains(err.Error(), "panic: ") {
+		t.Errorf("unexpected message: %v", err)
+	}
+}
+
+func TestInsertValuesEach(t *testing.T) {
+	columns := map[string]flock.Column{"First": {Value: "one"}}
+	table := flock.Table{Name: "Random", Keys: columns, Ordered: []string{"First"}, Conflict: flock.Conflict{Action: flock.ConflictFail}}
+	values := [][]interface{}{{1}, {2}, {3}}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("SAVEPOINT flock_batch").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO Random`).WithArgs(1, 2, 3).WillReturnError(errors.New("bad row"))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT flock_batch").WillReturnResult(sqlmock.NewResult(0, 0))
+	for i, v := range []int{1, 2, 3} {
+		mock.ExpectExec("SAVEPOINT flock_row").WillReturnResult(sqlmock.NewResult(0, 0))
+		exp := mock.ExpectExec(`INSERT INTO Random`).WithArgs(v)
+		if i == 1 {
+			exp.WillReturnError(errors.New("bad row"))
+			mock.ExpectExec("ROLLBACK TO SAVEPOINT flock_row").WillReturnResult(sqlmock.NewResult(0, 0))
+			continue
+		}
+		exp.WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("RELEASE SAVEPOINT flock_row").WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectExec("RELEASE SAVEPOINT flock_batch").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	var rejected []int
+	inserted, err := flock.InsertValuesEach(context.Background(), db, values, table, "Random", flock.Postgres, func(i int, err error) {
+		rejected = append(rejected, i)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inserted != 2 || !reflect.DeepEqual(rejected, []int{1}) {
+		t.Errorf("expected 2 rows inserted and row 1 rejected, got: %d inserted, %v rejected", inserted, rejected)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExplode(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  string
+		row     map[string]interface{}
+		want    [][]interface{}
+		wantErr bool
+	}{
+		{"Function", `- id = ID
+			- tag = Tags | each Split "," | Upper`,
+			map[string]interface{}{"ID": int64(1), "Tags": "a,b,c"},
+			[][]interface{}{{int64(1), "A"}, {int64(1), "B"}, {int64(1), "C"}}, false},
+		{"Empty", `- id = ID
+			- tag = Tags | each Split ","`,
+			map[string]interface{}{"ID": int64(1), "Tags": nil},
+			[][]interface{}{}, false},
+		{"Column", `- tag = each Tags
+			- id = ID`,
+			map[string]interface{}{"ID": int64(1), "Tags": []interface{}{"a", "b"}},
+			[][]interface{}{{"a", int64(1)}, {"b", int64(1)}}, false},
+		{"JSON", `- id = ID
+			- tag = Tags | each JSONDecode | Upper`,
+			map[string]interface{}{"ID": int64(1), "Tags": `["x", "y"]`},
+			[][]interface{}{{int64(1), "X"}, {int64(1), "Y"}}, false},
+		{"Product", `- a = A | each Split ","
+			- b = B | each Split ","`,
+			map[string]interface{}{"A": "1,2", "B": "x,y"},
+			[][]interface{}{{"1", "x"}, {"1", "y"}, {"2", "x"}, {"2", "y"}}, false},
+		{"NotSlice", `- tag = Tags | each Upper`,
+			map[string]interface{}{"Tags": "a,b"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fl, err := flock.ParseSchema(strings.NewReader("Users {\n `SELECT * FROM Users`\n {\n" + tt.fields + "\n}\n}"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tables, vars := flock.BuildTables(fl)
+
+			got, err := flock.CalculateValuesOfRow(tt.row, tables["Users"], flock.DefaultFuncs(), vars["Users"])
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CalculateValuesOfRow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExplodePerRow(t *testing.T) {
+	fl, err := flock.ParseSchema(strings.NewReader("Tags {\n `SELECT * FROM Users`\n {\n" +
+		` - id = NewUUID() per row
+		  - user_id = ID | ToGuid "Users"
+		  - tag = Tags | each Split ","
+		 } where tag != "b"
+		}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, vars := flock.BuildTables(fl)
+
+	values, filtered, err := flock.PrepareRow(map[string]interface{}{"ID": int64(7), "Tags": "a,b,c"}, tables["Tags"], flock.DefaultFuncs(), vars["Tags"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || filtered != 1 {
+		t.Fatalf("got %d rows and %d filtered, want 2 and 1", len(values), filtered)
+	}
+	// Every row gets its own id and the mapped id of the source row
+	if values[0][0] == values[1][0] || values[0][1] != values[1][1] || values[0][2] != "a" || values[1][2] != "c" {
+		t.Errorf("unexpected rows: %v", values)
+	}
+}
@@ -0,0 +1,230 @@
+package flock
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types a param can be declared with
+const (
+	ParamString = "string"
+	ParamInt    = "int"
+	ParamFloat  = "float"
+	ParamBool   = "bool"
+	// ParamDate is a day written as 2006-01-02
+	ParamDate = "date"
+	// ParamTime is a time written in RFC 3339
+	ParamTime = "time"
+)
+
+// DeclaredParams returns the params declared by the params directives of the schema, checking the declarations
+func DeclaredParams(fl *Flock) ([]*Param, error) {
+	params := make([]*Param, 0)
+	seen := make(map[string]bool)
+	for _, d := range fl.Directives {
+		if d.Name != "params" {
+			return nil, fmt.Errorf("unknown directive: %s", d.Name)
+		}
+		for _, p := range d.Params {
+			if seen[p.Name] {
+				return nil, fmt.Errorf("param $%s is declared twice", p.Name)
+			}
+			seen[p.Name] = true
+
+			if err := p.check(); err != nil {
+				return nil, fmt.Errorf("param $%s: %v", p.Name, err)
+			}
+			params = append(params, p)
+		}
+	}
+
+	return params, nil
+}
+
+func (p *Param) check() error {
+	switch p.Type {
+	case ParamString, ParamInt, ParamFloat, ParamBool, ParamDate, ParamTime:
+	default:
+		return fmt.Errorf("unknown type: %s", p.Type)
+	}
+	if p.Pattern != nil {
+		if _, err := regexp.Compile(*p.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %v", err)
+		}
+	}
+	if p.Default != nil {
+		if p.List {
+			return fmt.Errorf("a list can't have a default")
+		}
+		v, key := p.Default.Value()
+		if key {
+			return fmt.Errorf("the default must be a literal, got %s", v)
+		}
+		if _, err := p.Value(v); err != nil {
+			return fmt.Errorf("invalid default: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Value converts the value given for the param to its type and checks it against its pattern.
+// Strings are accepted for every type as the values usually come from a form
+func (p *Param) Value(v interface{}) (interface{}, error) {
+	if !p.List {
+		return p.value(v)
+	}
+
+	rv := reflect.ValueOf(v)
+	if v == nil || rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a list of %s, got %T", p.Type, v)
+	}
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		e, err := p.value(rv.Index(i).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %v", i, err)
+		}
+		list[i] = e
+	}
+
+	return list, nil
+}
+
+func (p *Param) value(v interface{}) (interface{}, error) {
+	res, err := convertParam(p.Type, v)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Pattern != nil {
+		s := fmt.Sprint(v)
+		if ok, _ := regexp.MatchString(*p.Pattern, s); !ok {
+			return nil, fmt.Errorf("%q doesn't match the pattern %s", s, *p.Pattern)
+		}
+	}
+
+	return res, nil
+}
+
+func convertParam(typ string, v interface{}) (interface{}, error) {
+	s, isString := v.(string)
+	switch typ {
+	case ParamString:
+		if isString {
+			return s, nil
+		}
+	case ParamInt:
+		switch n := v.(type) {
+		case int:
+			return int64(n), nil
+		case int64:
+			return n, nil
+		case float64:
+			// JSON numbers are floats
+			if n == math.Trunc(n) && math.Abs(n) <= 1<<53 {
+				return int64(n), nil
+			}
+		case string:
+			if i, err := strconv.ParseInt(strings.TrimSpace(n), 10, 64); err == nil {
+				return i, nil
+			}
+		}
+	case ParamFloat:
+		switch n := v.(type) {
+		case int:
+			return float64(n), nil
+		case int64:
+			return float64(n), nil
+		case float64:
+			return n, nil
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(n), 64); err == nil {
+				return f, nil
+			}
+		}
+	case ParamBool:
+		switch b := v.(type) {
+		case bool:
+			return b, nil
+		case string:
+			if res, err := strconv.ParseBool(strings.TrimSpace(b)); err == nil {
+				return res, nil
+			}
+		}
+	case ParamDate:
+		if isString {
+			if t, err := time.Parse("2006-01-02", strings.TrimSpace(s)); err == nil {
+				return t, nil
+			}
+		}
+	case ParamTime:
+		if isString {
+			if t, err := time.Parse(time.RFC3339, strings.TrimSpace(s)); err == nil {
+				return t, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("expected %s, got %T %v", typ, v, v)
+}
+
+// CheckParams checks the values against the declarations and returns them converted to their types, with the defaults
+// of the params not given. Optional params without a default are nil. When nothing is declared the values are returned
+// as they are. The error lists every invalid param
+func CheckParams(declared []*Param, values map[string]interface{}) (map[string]interface{}, error) {
+	if len(declared) == 0 {
+		return values, nil
+	}
+
+	res := make(map[string]interface{}, len(declared))
+	errs := make([]string, 0)
+	known := make(map[string]bool, len(declared))
+	for _, p := range declared {
+		known[p.Name] = true
+
+		v, ok := values[p.Name]
+		if !ok || v == nil {
+			switch {
+			case p.Default != nil:
+				v, _ = p.Default.Value()
+			case p.Required:
+				errs = append(errs, fmt.Sprintf("$%s is required", p.Name))
+				continue
+			default:
+				res[p.Name] = nil
+				continue
+			}
+		}
+
+		v, err := p.Value(v)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("$%s: %v", p.Name, err))
+			continue
+		}
+		res[p.Name] = v
+	}
+
+	unknown := make([]string, 0)
+	for name := range values {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, fmt.Sprintf("$%s is not declared", name))
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid params: %s", strings.Join(errs, "; "))
+	}
+
+	return res, nil
+}
@@ -0,0 +1,115 @@
+package flock_test
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	flock "github.com/srikrsna/flock/pkg"
+)
+
+func TestDeclaredParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		want    []string
+		wantErr bool
+	}{
+		{"None", "Users { `SELECT * FROM Users` { - id = ID } }", []string{}, false},
+		{"Declared", "params ( $a string $b []int required )", []string{"a", "b"}, false},
+		{"UnknownType", "params ( $a money )", nil, true},
+		{"Twice", "params ( $a string ) params ( $a int )", nil, true},
+		{"UnknownDirective", "vars ( $a string )", nil, true},
+		{"InvalidPattern", "params ( $a string pattern \"(\" )", nil, true},
+		{"InvalidDefault", "params ( $a int = \"one\" )", nil, true},
+		{"DefaultNotMatching", "params ( $a string = \"EU\" pattern \"^[a-z]+$\" )", nil, true},
+		{"ListDefault", "params ( $a []int = 1 )", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fl, err := flock.ParseSchema(strings.NewReader(tt.schema))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			params, err := flock.DeclaredParams(fl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DeclaredParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			names := make([]string, 0)
+			for _, p := range params {
+				names = append(names, p.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("DeclaredParams() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckParams(t *testing.T) {
+	f, err := os.Open("./test_files/inputs/params.fl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	fl, err := flock.ParseSchema(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	declared, err := flock.DeclaredParams(fl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	since := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		values  map[string]interface{}
+		want    map[string]interface{}
+		wantErr string
+	}{
+		{
+			"Defaults",
+			map[string]interface{}{"since": "2019-06-01"},
+			map[string]interface{}{"since": since, "region": "eu", "ids": nil, "limit": int64(100)},
+			"",
+		},
+		{
+			"Converted",
+			map[string]interface{}{"since": "2019-06-01", "region": "us", "ids": []interface{}{float64(1), "2"}, "limit": float64(5)},
+			map[string]interface{}{"since": since, "region": "us", "ids": []interface{}{int64(1), int64(2)}, "limit": int64(5)},
+			"",
+		},
+		{"Required", map[string]interface{}{}, nil, "$since is required"},
+		{"WrongType", map[string]interface{}{"since": "June", "limit": 1.5}, nil, "$since: expected date"},
+		{"Pattern", map[string]interface{}{"since": "2019-06-01", "region": "EU"}, nil, "doesn't match the pattern"},
+		{"NotAList", map[string]interface{}{"since": "2019-06-01", "ids": 1}, nil, "expected a list of int"},
+		{"Typo", map[string]interface{}{"since": "2019-06-01", "limt": 1}, nil, "$limt is not declared"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := flock.CheckParams(declared, tt.values)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("CheckParams() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckParams() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,28 +2,135 @@ package flock
 
 import (
 	"io"
+	"text/scanner"
 
 	"github.com/alecthomas/participle"
+	"github.com/alecthomas/participle/lexer"
 )
 
 type Flock struct {
-	Entries []*Entry `@@*`
+	Directives []*Directive `@@*`
+	Entries    []*Entry     `@@*`
 }
 
 type Entry struct {
-	Name   string   `@(String|Ident|RawString) "{"`
-	Query  string   `@RawString`
-	Fields []*Field `"{" @@* "}" "}"`
+	Name  string `@(String|Ident|RawString) "{"`
+	Query string `@RawString`
+	// After are the entries loaded before this one, such as the ones holding the rows its rows reference
+	After    []string  `( "after" @(String|Ident|RawString) { "," @(String|Ident|RawString) } )?`
+	Conflict *Conflict `@@?`
+	// MaxErrors is the number of rows of the entry that may be sent to the dead letter sink
+	MaxErrors *int64   `( "max" "errors" @Int )?`
+	Fields    []*Field `( "{" @@* "}"`
+	// Where keeps the rows matching it, it is evaluated after the values of the fields
+	Where *Where `( "where" @@ )?`
+	// Targets are the tables written by an entry splitting its rows into several tables, in place of its fields
+	Targets []*Target `| @@+ ) "}"`
 }
 
+// Target is a destination table of an entry, every row of the entry is inserted into each of its targets in the
+// order they are declared. The conflict clause and error budget of the entry apply to the targets without their own
+type Target struct {
+	Name      string    `"into" @(String|Ident|RawString)`
+	Conflict  *Conflict `@@?`
+	MaxErrors *int64    `( "max" "errors" @Int )?`
+	Fields    []*Field  `"{" @@* "}"`
+	Where     *Where    `( "where" @@ )?`
+}
+
+// Destinations returns the targets of the entry, or the entry itself as the single target of its fields
+func (e *Entry) Destinations() []*Target {
+	if len(e.Targets) == 0 {
+		return []*Target{{Name: e.Name, Conflict: e.Conflict, MaxErrors: e.MaxErrors, Fields: e.Fields, Where: e.Where}}
+	}
+
+	res := make([]*Target, len(e.Targets))
+	for i, t := range e.Targets {
+		c := *t
+		if c.Conflict == nil {
+			c.Conflict = e.Conflict
+		}
+		if c.MaxErrors == nil {
+			c.MaxErrors = e.MaxErrors
+		}
+		res[i] = &c
+	}
+
+	return res
+}
+
+// Conflict strategies
+const (
+	ConflictIgnore  = "ignore"
+	ConflictUpdate  = "update"
+	ConflictFail    = "fail"
+	ConflictReplace = "replace"
+)
+
+// Conflict is the behaviour when an inserted row already exists in the destination.
+// Keys are the unique columns identifying a row, Columns the ones overwritten by an update
+type Conflict struct {
+	Keys    []string `"on" "conflict" ( "(" @Ident { "," @Ident } ")" )?`
+	Action  string   `@("ignore" | "update" | "fail" | "replace")`
+	Columns []string `( "(" @Ident { "," @Ident } ")" )?`
+}
+
+// Field maps a destination column to its value. The value is a source column, a literal, a param of the run
+// or a call. A call is evaluated once per run unless it is followed by per row, its params may name source
+// columns when it is evaluated per row
+//
+// A source column, call or function marked with each returns a slice, and every element of it makes a row of its
+// own, going through the functions that follow. The other fields of the row keep the values of the source row
+//
+//   - tenant = "acme"
+//   - batch = $batch
+//   - migrated = Now()
+//   - id = NewUUID() per row
+//   - tag = Tags | each Split ","
 type Field struct {
-	Key       string       `("-"@Ident "="`
+	Key     string   `("-"@Ident "="`
+	Literal *Literal `( @@`
+	Param   *string  `| "$" @Ident`
+	Explode Each     `| @@?`
+	// Value is the source column, or the function when Call is set
 	Value     string       `@Ident`
+	Call      *Call        `@@? )`
 	Functions []*FieldFunc `@@* )`
 }
 
+// Call is the argument list of a function giving the value of a field
+type Call struct {
+	Parameters []*FuncParameter `"(" @@* ")"`
+	PerRow     bool             `@( "per" "row" )?`
+}
+
+// Literal is a constant value of a field
+type Literal struct {
+	String *string  `  @(String | RawString)`
+	Int    *int64   `| @Int`
+	Float  *float64 `| @Float`
+	Bool   *bool    `| @("true" | "false")`
+}
+
+// Value returns the literal as a string, int64, float64 or bool
+func (l Literal) Value() interface{} {
+	switch {
+	case l.String != nil:
+		return *l.String
+	case l.Int != nil:
+		return *l.Int
+	case l.Float != nil:
+		return *l.Float
+	case l.Bool != nil:
+		return *l.Bool
+	default:
+		return nil
+	}
+}
+
 type FieldFunc struct {
-	Name       string           `( "|" @Ident`
+	Explode    Each             `( "|" @@?`
+	Name       string           `@Ident`
 	Parameters []*FuncParameter ` @@*)`
 }
 
@@ -55,9 +162,111 @@ func (f FuncParameter) Value() (interface{}, bool) {
 	}
 }
 
+// Where is a condition on the rows of an entry. Names are the fields of the entry, or the source columns
+// when the entry has no field of that name. A name alone or a function call must be a bool
+//
+//	where id != null and (not Blank(email) or active)
+type Where struct {
+	Or []*WhereAnd `@@ { "or" @@ }`
+}
+
+type WhereAnd struct {
+	And []*WhereNot `@@ { "and" @@ }`
+}
+
+type WhereNot struct {
+	Not        Not         `@@?`
+	Sub        *Where      `( "(" @@ ")"`
+	Comparison *Comparison `| @@ )`
+}
+
+// Comparison compares two values with =, !=, <, <=, > or >=, or is a single bool value when Op is empty
+type Comparison struct {
+	Left  *Operand `@@`
+	Op    string   `( @( "!" "=" | "<" "="? | ">" "="? | "=" )`
+	Right *Operand `  @@ )?`
+}
+
+type Operand struct {
+	Null    bool     `  @"null"`
+	Literal *Literal `| @@`
+	Param   *string  `| "$" @Ident`
+	// Name is a field or a source column, or the function when Args is set
+	Name string `| @Ident`
+	Args *Args  `@@?`
+
+	resolved bool
+	value    interface{}
+}
+
+// Args are the arguments of a function called by a where clause
+type Args struct {
+	Parameters []*FuncParameter `"(" @@* ")"`
+}
+
+// Directive is a block written before the entries. The params directive declares the params of the queries
+//
+//	params (
+//		$since date required
+//		$ids []int
+//		$region string = "eu" pattern "^[a-z]{2}$"
+//	)
 type Directive struct {
-	Name string   `@Ident "("`
-	Vars []string `{ ("$"@Ident) } ")"`
+	Name   string   `@Ident "("`
+	Params []*Param `{ @@ } ")"`
+}
+
+// Param declares a param of the queries, its type, whether it must be given, its default and the pattern its values must match.
+// A list param holds several values of its type
+type Param struct {
+	Name     string         `"$" @Ident`
+	List     bool           `@( "[" "]" )?`
+	Type     string         `@Ident`
+	Required bool           `@"required"?`
+	Default  *FuncParameter `( "=" @@ )?`
+	Pattern  *string        `( "pattern" @(String | RawString) )?`
+}
+
+// Each marks a value whose elements make rows of their own. each is only a keyword when a name follows it, so that
+// a column or a function may be named each
+type Each bool
+
+func (e *Each) Parse(lex lexer.PeekingLexer) error {
+	ok, err := keyword(lex, "each", scanner.Ident)
+	*e = Each(ok)
+	return err
+}
+
+// Not negates a condition. not is only a keyword when an operand follows it, so that a column may be named not
+type Not bool
+
+func (n *Not) Parse(lex lexer.PeekingLexer) error {
+	ok, err := keyword(lex, "not", scanner.Ident, scanner.String, scanner.RawString, scanner.Int, scanner.Float, '(', '$')
+	*n = Not(ok)
+	return err
+}
+
+// keyword consumes the word when the token after it is one of follows, it returns participle.NextMatch otherwise
+func keyword(lex lexer.PeekingLexer, word string, follows ...rune) (bool, error) {
+	t, err := lex.Peek(0)
+	if err != nil {
+		return false, err
+	}
+	if t.Type != scanner.Ident || t.Value != word {
+		return false, participle.NextMatch
+	}
+	next, err := lex.Peek(1)
+	if err != nil {
+		return false, err
+	}
+	for _, f := range follows {
+		if next.Type == f {
+			_, err := lex.Next()
+			return err == nil, err
+		}
+	}
+
+	return false, participle.NextMatch
 }
 
 var parser = participle.MustBuild(&Flock{}, participle.UseLookahead(1))
@@ -33,6 +33,22 @@ func TestParseSchema(t *testing.T) {
 			"test.fl",
 			false,
 		},
+		{
+			"conflict.fl",
+			false,
+		},
+		{
+			"params.fl",
+			false,
+		},
+		{
+			"sources.fl",
+			false,
+		},
+		{
+			"targets.fl",
+			false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -75,3 +91,56 @@ func TestParseSchema(t *testing.T) {
 		})
 	}
 }
+
+func TestParseKeywordNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   string
+		where   string
+		want    flock.Field
+		wantNot bool
+	}{
+		{"Each", "- x = each", "", flock.Field{Key: "x", Value: "each"}, false},
+		{"EachFunctions", "- x = each | Lower", "", flock.Field{Key: "x", Value: "each"}, false},
+		{"EachCall", "- x = each()", "", flock.Field{Key: "x", Value: "each"}, false},
+		{"Explode", "- x = each Tags", "", flock.Field{Key: "x", Value: "Tags", Explode: true}, false},
+		{"ExplodeEach", "- x = each each", "", flock.Field{Key: "x", Value: "each", Explode: true}, false},
+		{"Keys", "- each = per", "", flock.Field{Key: "each", Value: "per"}, false},
+		{"Params", "- x = $row", "", flock.Field{Key: "x"}, false},
+		{"Columns", "- x = max", "", flock.Field{Key: "x", Value: "max"}, false},
+		{"PerRowColumns", "- per = row", "", flock.Field{Key: "per", Value: "row"}, false},
+		{"PerRowCalls", "- row = per(row) per row", "", flock.Field{Key: "row", Value: "per", Call: &flock.Call{PerRow: true}}, false},
+		{"PerRowParams", "- per = Sub(per) per row", "", flock.Field{Key: "per", Value: "Sub", Call: &flock.Call{PerRow: true}}, false},
+		{"WhereNot", "- x = a", "not = 1", flock.Field{Key: "x", Value: "a"}, false},
+		{"WhereNotAlone", "- x = a", "not", flock.Field{Key: "x", Value: "a"}, false},
+		{"WhereNegated", "- x = a", "not not", flock.Field{Key: "x", Value: "a"}, true},
+		{"WhereKeywords", "- x = a", "into = after and on != where", flock.Field{Key: "x", Value: "a"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := "A {\n `SELECT 1`\n {\n " + tt.field + "\n }"
+			if tt.where != "" {
+				schema += " where " + tt.where
+			}
+			fl, err := flock.ParseSchema(bytes.NewBufferString(schema + "\n}\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := fl.Entries[0].Fields[0]
+			if got.Key != tt.want.Key || got.Value != tt.want.Value || got.Explode != tt.want.Explode {
+				t.Errorf("got field %s = %s (each %v), want %s = %s (each %v)", got.Key, got.Value, got.Explode, tt.want.Key, tt.want.Value, tt.want.Explode)
+			}
+			if perRow := got.Call != nil && got.Call.PerRow; perRow != (tt.want.Call != nil && tt.want.Call.PerRow) {
+				t.Errorf("got per row %v for %q", perRow, tt.field)
+			}
+			if tt.where == "" {
+				return
+			}
+			not := fl.Entries[0].Where.Or[0].And[0]
+			if bool(not.Not) != tt.wantNot || not.Comparison == nil || not.Comparison.Left.Name == "" {
+				t.Errorf("unexpected where %q: not %v, %v", tt.where, not.Not, not.Comparison)
+			}
+		})
+	}
+}
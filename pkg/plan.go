@@ -0,0 +1,177 @@
+package flock
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CycleError is returned by Plan when entries depend on each other, Entries is the cycle starting and ending
+// with the same entry
+type CycleError struct {
+	Entries []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("entries can't be ordered, they depend on each other: %s", strings.Join(e.Entries, " after "))
+}
+
+// Plan returns the entries in the order they are loaded in: every entry comes after the entries it names in its after
+// clause and after the entries writing the tables its tables reference. references holds the tables referenced by the
+// foreign keys of every destination table, tables are matched regardless of case, an unqualified name matches the
+// table of that name in any schema, and a table referencing itself is ignored. Entries keep the order of the file when
+// nothing orders them. It returns a *CycleError when entries depend on each other
+func Plan(flock *Flock, references map[string][]string) ([]*Entry, error) {
+	index := make(map[string]int, len(flock.Entries))
+	writers := newTableIndex()
+	for i, e := range flock.Entries {
+		index[e.Name] = i
+		for _, t := range e.Destinations() {
+			writers.add(t.Name, i)
+		}
+	}
+	// referencing are the tables of references, indexed the same way as the writers
+	referencing := make([]string, 0, len(references))
+	refs := newTableIndex()
+	for table := range references {
+		refs.add(table, len(referencing))
+		referencing = append(referencing, table)
+	}
+
+	// deps holds the entries every entry is loaded after
+	deps := make([]map[int]bool, len(flock.Entries))
+	for i, e := range flock.Entries {
+		deps[i] = make(map[int]bool)
+		for _, a := range e.After {
+			j, ok := index[a]
+			if !ok {
+				return nil, fmt.Errorf("%s: after: unknown entry %s", e.Name, a)
+			}
+			deps[i][j] = true
+		}
+		for _, t := range e.Destinations() {
+			for _, k := range refs.find(t.Name) {
+				for _, r := range references[referencing[k]] {
+					if sameTable(r, t.Name) {
+						continue
+					}
+					for _, j := range writers.find(r) {
+						if j != i {
+							deps[i][j] = true
+						}
+					}
+				}
+			}
+		}
+	}
+
+	res := make([]*Entry, 0, len(flock.Entries))
+	done := make([]bool, len(flock.Entries))
+	for len(res) < len(flock.Entries) {
+		next := -1
+		for i := range flock.Entries {
+			if !done[i] && loaded(deps[i], done) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, cycle(flock.Entries, deps, done)
+		}
+		done[next] = true
+		res = append(res, flock.Entries[next])
+	}
+
+	return res, nil
+}
+
+// tableIndex finds values by table name regardless of case, a qualified name finds the values of the same name and
+// of its unqualified name, an unqualified name finds the values of the name in any schema
+type tableIndex struct {
+	// names holds the values by lower case name, bare by lower case name without schema
+	names, bare map[string][]int
+}
+
+func newTableIndex() *tableIndex {
+	return &tableIndex{names: make(map[string][]int), bare: make(map[string][]int)}
+}
+
+func (t *tableIndex) add(name string, v int) {
+	name = strings.ToLower(name)
+	t.names[name] = append(t.names[name], v)
+	t.bare[unqualified(name)] = append(t.bare[unqualified(name)], v)
+}
+
+func (t *tableIndex) find(name string) []int {
+	name = strings.ToLower(name)
+	if bare := unqualified(name); bare != name {
+		res := make([]int, 0, len(t.names[name])+len(t.names[bare]))
+		return append(append(res, t.names[name]...), t.names[bare]...)
+	}
+
+	return t.bare[name]
+}
+
+// sameTable tells whether the names are matched with each other by a tableIndex
+func sameTable(a, b string) bool {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	if a == b {
+		return true
+	}
+	if unqualified(a) != a && unqualified(b) != b {
+		return false
+	}
+
+	return unqualified(a) == unqualified(b)
+}
+
+// unqualified returns the name without its schema
+func unqualified(name string) string {
+	return name[strings.LastIndexByte(name, '.')+1:]
+}
+
+// loaded tells whether the entries of deps are done
+func loaded(deps map[int]bool, done []bool) bool {
+	for j := range deps {
+		if !done[j] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// cycle returns the error for a cycle among the entries that aren't done, every one of them depends on another one
+func cycle(entries []*Entry, deps []map[int]bool, done []bool) error {
+	start := 0
+	for done[start] {
+		start++
+	}
+
+	// Following the first dependency left of every entry ends up going around a cycle
+	seen := make(map[int]int)
+	path := make([]int, 0)
+	for i := start; ; {
+		if at, ok := seen[i]; ok {
+			path = append(path[at:], i)
+			break
+		}
+		seen[i] = len(path)
+		path = append(path, i)
+
+		next := -1
+		for j := range entries {
+			if deps[i][j] && !done[j] {
+				next = j
+				break
+			}
+		}
+		i = next
+	}
+
+	names := make([]string, len(path))
+	for k, i := range path {
+		names[k] = entries[i].Name
+	}
+
+	return &CycleError{Entries: names}
+}
@@ -0,0 +1,71 @@
+package flock_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	flock "github.com/srikrsna/flock/pkg"
+)
+
+func TestPlan(t *testing.T) {
+	entry := func(name, clauses string) string {
+		return name + " {\n `SELECT * FROM " + name + "`\n " + clauses + " {\n - id = ID\n }\n}\n"
+	}
+
+	tests := []struct {
+		name       string
+		schema     string
+		references map[string][]string
+		want       []string
+		wantErr    string
+	}{
+		{"FileOrder", entry("b", "") + entry("a", "") + entry("c", ""), nil, []string{"b", "a", "c"}, ""},
+		{"After", entry("lines", "after orders, products") + entry("orders", "after users") + entry("users", "") + entry("products", ""),
+			nil, []string{"users", "orders", "products", "lines"}, ""},
+		{"ForeignKeys", entry("Orders", "") + entry("Users", "") + entry("Products", ""),
+			map[string][]string{"orders": {"users", "orders"}}, []string{"Users", "Orders", "Products"}, ""},
+		{"Targets", "Accounts {\n `SELECT * FROM Accounts`\n into users {\n - id = ID\n }\n into profiles {\n - id = ID\n }\n}\n" + entry("roles", ""),
+			map[string][]string{"users": {"roles"}, "profiles": {"users"}}, []string{"roles", "Accounts"}, ""},
+		{"Qualified", entry(`"dbo.Orders"`, "") + entry(`"dbo.Users"`, "") + entry(`"audit.Users"`, ""),
+			map[string][]string{"dbo.orders": {"dbo.users"}, "audit.users": {"dbo.orders"}}, []string{"dbo.Users", "dbo.Orders", "audit.Users"}, ""},
+		{"UnqualifiedEntries", entry("Orders", "") + entry("Users", ""),
+			map[string][]string{"dbo.orders": {"dbo.users", "dbo.orders"}}, []string{"Users", "Orders"}, ""},
+		{"QualifiedEntries", entry(`"public.orders"`, "") + entry(`"public.users"`, ""),
+			map[string][]string{"orders": {"users"}}, []string{"public.users", "public.orders"}, ""},
+		{"OtherSchema", entry(`"sales.Orders"`, "") + entry(`"sales.Users"`, ""),
+			map[string][]string{"hr.orders": {"hr.users"}}, []string{"sales.Orders", "sales.Users"}, ""},
+		{"Unknown", entry("a", "after b"), nil, nil, "a: after: unknown entry b"},
+		{"Cycle", entry("a", "after c") + entry("b", "after a") + entry("c", "after b") + entry("d", ""),
+			nil, nil, "they depend on each other: a after c after b after a"},
+		{"ForeignKeyCycle", entry("a", "") + entry("b", "after a"),
+			map[string][]string{"a": {"b"}}, nil, "they depend on each other: a after b after a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fl, err := flock.ParseSchema(strings.NewReader(tt.schema))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			entries, err := flock.Plan(fl, tt.references)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(entries))
+			for i, e := range entries {
+				got[i] = e.Name
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -11,7 +11,12 @@ import (
 
 // PluginHandler ...
 func PluginHandler(content []byte) (map[string]interface{}, error) {
-	tmp := os.TempDir()
+	// Every plugin is built in its own directory so that concurrent runs don't overwrite each other's files
+	tmp, err := ioutil.TempDir("", "flock-plugin")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create build directory: %v", err)
+	}
+	defer os.RemoveAll(tmp)
 
 	gof := filepath.Join(tmp, "tmp.go")
 	if err := ioutil.WriteFile(gof, content, 0666); err != nil {
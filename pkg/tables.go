@@ -1,67 +1,255 @@
 package flock
 
-import "reflect"
+import (
+	"fmt"
+	"reflect"
+)
 
 type Table struct {
-	Name    string
-	Keys    map[string]Column
-	Ordered []string
+	Name     string
+	Keys     map[string]Column
+	Ordered  []string
+	Conflict Conflict
+	// MaxErrors limits the rows of the table sent to the dead letter sink, there is no limit when nil
+	MaxErrors *int64 `json:",omitempty"`
+	// Where keeps the rows passing it, every row is kept when nil
+	Where *Where `json:",omitempty"`
+	// Targets are the tables an entry writing to several tables inserts its rows into, in order
+	Targets []Table `json:",omitempty"`
 }
 
 type Column struct {
 	Value     string
 	Functions []Func
+	// Source is the value of a column that doesn't come from a source column
+	Source *Source `json:",omitempty"`
+	// Explode makes a row of every element of the value of the source column or the source, before the functions
+	Explode bool `json:",omitempty"`
+}
+
+// Source is a literal, a param of the run or a call giving the value of a column. Anything but a call evaluated
+// per row is resolved once per run by ResolveSources
+type Source struct {
+	Literal interface{} `json:",omitempty"`
+	Param   string      `json:",omitempty"`
+	Call    *Func       `json:",omitempty"`
+	// Columns holds the source columns named by the params of the call, empty for the literal ones
+	Columns []string `json:",omitempty"`
+	PerRow  bool     `json:",omitempty"`
+
+	resolved bool
+	value    interface{}
 }
 
 type Func struct {
 	Name       string
 	Parameters []reflect.Value
+	// Explode makes a row of every element of the result of the function
+	Explode bool `json:",omitempty"`
 }
 
 type Variable struct {
-	Func string
-	index []int
+	Func   string
+	index  []int
 	Column []string
 }
-// BuildTables
+
+// BuildTables returns the tables of the entries and the variables of their columns, the params of their functions
+// naming source columns, by destination column. The variables of the targets of an entry are found by TargetKey
 func BuildTables(flock *Flock) (map[string]Table, map[string]map[string]Variable) {
 	res := make(map[string]Table, len(flock.Entries))
 	vars := make(map[string]map[string]Variable, len(flock.Entries))
 	for _, e := range flock.Entries {
-		var t Table
-		t.Name = e.Name
-		t.Keys = make(map[string]Column, len(e.Fields))
-		t.Ordered = make([]string, 0, len(e.Fields))
-		vars[e.Name] = make(map[string]Variable)
-		for _, field := range e.Fields {
-			c := Column{Value: field.Value, Functions: make([]Func, 0, len(field.Functions))}
-			v := Variable {
-				index: make([]int, 0),
-				Column: make([]string, 0),
+		if len(e.Targets) == 0 {
+			res[e.Name], vars[e.Name] = buildTable(e.Destinations()[0])
+			continue
+		}
+
+		t := Table{Name: e.Name, Keys: map[string]Column{}, Ordered: []string{}, Conflict: Conflict{Action: ConflictIgnore}}
+		for _, target := range e.Destinations() {
+			tt, v := buildTable(target)
+			t.Targets = append(t.Targets, tt)
+			vars[TargetKey(e.Name, target.Name)] = v
+		}
+		res[e.Name] = t
+	}
+
+	return res, vars
+}
+
+// TargetKey returns the key of the variables of a target of an entry
+func TargetKey(entry, target string) string {
+	return entry + "." + target
+}
+
+// Tables returns the targets of the table, or the table itself when it has none
+func (t Table) Tables() []Table {
+	if len(t.Targets) == 0 {
+		return []Table{t}
+	}
+
+	return t.Targets
+}
+
+func buildTable(target *Target) (Table, map[string]Variable) {
+	var t Table
+	t.Name = target.Name
+	t.Keys = make(map[string]Column, len(target.Fields))
+	t.Ordered = make([]string, 0, len(target.Fields))
+	t.Conflict = Conflict{Action: ConflictIgnore}
+	if target.Conflict != nil {
+		t.Conflict = *target.Conflict
+	}
+	t.MaxErrors = target.MaxErrors
+	t.Where = target.Where
+	vars := make(map[string]Variable)
+	for _, field := range target.Fields {
+		c := Column{Value: field.Value, Functions: make([]Func, 0, len(field.Functions)), Source: buildSource(field), Explode: bool(field.Explode)}
+		if c.Source != nil {
+			c.Value = ""
+		}
+		v := Variable{
+			index:  make([]int, 0),
+			Column: make([]string, 0),
+		}
+		for _, fun := range field.Functions {
+			f := Func{
+				Name:       fun.Name,
+				Parameters: make([]reflect.Value, 0, len(fun.Parameters)),
+				Explode:    bool(fun.Explode),
 			}
-			for _, fun := range field.Functions {
-				f := Func{
-					Name:       fun.Name,
-					Parameters: make([]reflect.Value, 0, len(fun.Parameters)),
-				}
-				v.Func = fun.Name
-				for _, arg := range fun.Parameters {
-					val, flag := arg.Value()
-					if flag {
-						v.index = append(v.index, len(f.Parameters))
-						v.Column = append(v.Column, val.(string))
-					}
-					f.Parameters = append(f.Parameters, reflect.ValueOf(val))
+			v.Func = fun.Name
+			for _, arg := range fun.Parameters {
+				val, flag := arg.Value()
+				if flag {
+					v.index = append(v.index, len(f.Parameters))
+					v.Column = append(v.Column, val.(string))
 				}
-				c.Functions = append(c.Functions, f)
+				f.Parameters = append(f.Parameters, reflect.ValueOf(val))
 			}
+			c.Functions = append(c.Functions, f)
+		}
+
+		t.Keys[field.Key] = c
+		t.Ordered = append(t.Ordered, field.Key)
+		vars[field.Key] = v
+	}
+
+	return t, vars
+}
 
-			t.Keys[field.Key] = c
-			t.Ordered = append(t.Ordered, field.Key)
-			vars[e.Name][field.Value] = v
+// buildSource returns the source of a field without a source column, nil for the others
+func buildSource(field *Field) *Source {
+	switch {
+	case field.Literal != nil:
+		return &Source{Literal: field.Literal.Value(), resolved: true, value: field.Literal.Value()}
+	case field.Param != nil:
+		return &Source{Param: *field.Param}
+	case field.Call != nil:
+		src := &Source{
+			Call:    &Func{Name: field.Value, Parameters: make([]reflect.Value, len(field.Call.Parameters))},
+			Columns: make([]string, len(field.Call.Parameters)),
+			PerRow:  field.Call.PerRow,
 		}
-		res[e.Name] = t
+		for i, p := range field.Call.Parameters {
+			v, key := p.Value()
+			if key {
+				src.Columns[i] = v.(string)
+			}
+			src.Call.Parameters[i] = reflect.ValueOf(v)
+		}
+		return src
 	}
 
-	return res, vars
+	return nil
+}
+
+// ResolveSources evaluates the sources of the columns resolved once per run: it looks up the params in the values
+// of the run and calls the functions not evaluated per row. The params of the where clauses are looked up too
+func ResolveSources(tables map[string]Table, funcs *Funcs, params map[string]interface{}) error {
+	for name, t := range tables {
+		if len(t.Targets) == 0 {
+			if err := resolveTable(name, t, funcs, params); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, target := range t.Targets {
+			if err := resolveTable(TargetKey(name, target.Name), target, funcs, params); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func resolveTable(name string, t Table, funcs *Funcs, params map[string]interface{}) error {
+	if t.Where != nil {
+		if err := t.Where.resolve(params); err != nil {
+			return fmt.Errorf("%s: where: %v", name, err)
+		}
+	}
+	for _, key := range t.Ordered {
+		src := t.Keys[key].Source
+		if src == nil || src.PerRow || src.resolved {
+			continue
+		}
+
+		var err error
+		if src.Call == nil {
+			v, ok := params[src.Param]
+			if !ok {
+				return fmt.Errorf("%s.%s: the param $%s isn't given", name, key, src.Param)
+			}
+			src.value = v
+		} else if src.value, err = src.call(nil, funcs); err != nil {
+			return fmt.Errorf("%s.%s: %s: %v", name, key, src.Call.Name, err)
+		}
+		src.resolved = true
+	}
+
+	return nil
+}
+
+// Value returns the value of the source for the row
+func (s *Source) Value(row map[string]interface{}, funcs *Funcs) (interface{}, error) {
+	if s.PerRow {
+		return s.call(row, funcs)
+	}
+	if !s.resolved {
+		return nil, fmt.Errorf("the value isn't resolved for the run")
+	}
+
+	return s.value, nil
+}
+
+// call calls the function of the source, the params naming source columns take their values from the row
+func (s *Source) call(row map[string]interface{}, funcs *Funcs) (interface{}, error) {
+	fn, ok := funcs.Lookup(s.Call.Name)
+	if !ok {
+		return nil, fmt.Errorf("function %s is not registered", s.Call.Name)
+	}
+
+	in := make([]reflect.Value, len(s.Call.Parameters))
+	copy(in, s.Call.Parameters)
+	for i, c := range s.Columns {
+		if c == "" {
+			continue
+		}
+		if row == nil {
+			return nil, fmt.Errorf("a call evaluated once per run can't take the column %s", c)
+		}
+		in[i] = reflect.ValueOf(row[c])
+	}
+
+	rt, err := callFunc(fn, in)
+	if err != nil {
+		return nil, err
+	}
+	if len(rt) == 2 && !rt[1].IsNil() {
+		return nil, rt[1].Interface().(error)
+	}
+
+	return rt[0].Interface(), nil
 }
@@ -3,10 +3,13 @@ package flock_test
 import (
 	"encoding/json"
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	flock "github.com/srikrsna/flock/pkg"
 )
@@ -28,6 +31,21 @@ func TestBuildTables(t *testing.T) {
 			"test_table.txt",
 			false,
 		},
+		{
+			"conflict.fl",
+			"conflict_table.txt",
+			false,
+		},
+		{
+			"sources.fl",
+			"sources_table.txt",
+			false,
+		},
+		{
+			"targets.fl",
+			"targets_table.txt",
+			false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -65,13 +83,78 @@ func TestBuildTables(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			expectedTables := make(map[string]flock.Table)
+			// Function parameters are reflect values which do not survive a round trip through JSON,
+			// so both sides are compared in their JSON form
+			var expectedTables, gotTables interface{}
 			if err := json.Unmarshal(exp, &expectedTables); err != nil {
 				t.Errorf("failed to read output file: %v", err)
 			}
-			if !reflect.DeepEqual(tables, expectedTables) {
-				t.Errorf("mismatched table output, expected: %v, got: %v", expectedTables, tables)
+			got, err := json.Marshal(tables)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(got, &gotTables); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotTables, expectedTables) {
+				t.Errorf("mismatched table output, expected: %v, got: %v", expectedTables, gotTables)
 			}
 		})
 	}
 }
+
+func TestSources(t *testing.T) {
+	f, err := os.Open("./test_files/inputs/sources.fl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fl, err := flock.ParseSchema(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Now is replaced to count the calls and return a known value
+	calls := 0
+	migrated := time.Date(2019, 6, 10, 0, 0, 0, 0, time.UTC)
+	funcs := flock.DefaultFuncs()
+	if err := funcs.Register(flock.FuncMap{
+		"Now": func() time.Time { calls++; return migrated },
+		"Concat": func(sep string, vs ...interface{}) string {
+			parts := make([]string, len(vs))
+			for i, v := range vs {
+				parts[i] = fmt.Sprint(v)
+			}
+			return strings.Join(parts, sep)
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tables, vars := flock.BuildTables(fl)
+	if _, err := flock.CalculateValuesOfRow(map[string]interface{}{"ID": int64(1)}, tables["Users"], funcs, vars["Users"]); err == nil {
+		t.Error("expected an error before the sources are resolved")
+	}
+	if err := flock.ResolveSources(tables, funcs, map[string]interface{}{}); err == nil {
+		t.Error("expected an error for a missing param")
+	}
+	if err := flock.ResolveSources(tables, funcs, map[string]interface{}{"batch": "june"}); err != nil {
+		t.Fatal(err)
+	}
+
+	table := tables["Users"]
+	table.Keys["id"] = flock.Column{Value: "ID"}
+	for _, row := range []map[string]interface{}{{"ID": int64(1), "Name": "A"}, {"ID": int64(2), "Name": "B"}} {
+		got, err := flock.CalculateValuesOfRow(row, table, funcs, vars["Users"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := [][]interface{}{{row["ID"], "acme", int64(2), true, "june", migrated, fmt.Sprintf("%d-%s", row["ID"], strings.ToLower(row["Name"].(string)))}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %#v, want %#v", got, want)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Now was called %d times, want once per run", calls)
+	}
+}
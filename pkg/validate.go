@@ -0,0 +1,205 @@
+package flock
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Diagnostic is a problem with a function call of a .fl file
+type Diagnostic struct {
+	Entry   string
+	Column  string
+	Func    string
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s.%s: %s: %s", d.Entry, d.Column, d.Func, d.Message)
+}
+
+// ValidationError holds every problem found by Validate
+type ValidationError struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Diagnostics))
+	for i, d := range e.Diagnostics {
+		lines[i] = d.String()
+	}
+
+	return fmt.Sprintf("invalid schema: %s", strings.Join(lines, "; "))
+}
+
+// Validate checks the function calls of the schema against the functions: that every function exists, takes as many
+// arguments as it is given, that its literal params can be converted to the types of its parameters and that the value
+// piped into it can be converted to the type of its last parameter, along with the calls of the where clauses. The
+// values of the source columns are only known when the rows are read, so they aren't checked. It returns a
+// *ValidationError with every problem found
+func Validate(flock *Flock, funcs FuncMap) error {
+	diags := make([]Diagnostic, 0)
+	for _, e := range flock.Entries {
+		for _, t := range e.Destinations() {
+			// The fields of a target are reported under the entry and the table they are inserted into
+			name := e.Name
+			if len(e.Targets) > 0 {
+				name = TargetKey(e.Name, t.Name)
+			}
+			for _, field := range t.Fields {
+				// The type of the value going into the next function, nil while it is the value of a source column
+				piped := checkSource(field, funcs, func(format string, args ...interface{}) {
+					diags = append(diags, Diagnostic{name, field.Key, field.Value, fmt.Sprintf(format, args...)})
+				})
+				for _, fun := range field.Functions {
+					report := func(format string, args ...interface{}) {
+						diags = append(diags, Diagnostic{name, field.Key, fun.Name, fmt.Sprintf(format, args...)})
+					}
+
+					f, ok := funcs[fun.Name]
+					if !ok {
+						report("unknown function")
+						piped = nil
+						continue
+					}
+					fn := reflect.ValueOf(f)
+					if fn.Kind() != reflect.Func || !goodFunc(fn.Type()) {
+						report("not a valid function")
+						piped = nil
+						continue
+					}
+
+					piped = checkCall(fn.Type(), fun.Parameters, true, piped, report)
+					if fun.Explode {
+						piped = checkExplode(piped, report)
+					}
+				}
+			}
+			if t.Where != nil {
+				checkWhere(name, t.Where, funcs, func(d Diagnostic) {
+					diags = append(diags, d)
+				})
+			}
+		}
+	}
+
+	if len(diags) > 0 {
+		return &ValidationError{diags}
+	}
+
+	return nil
+}
+
+// checkSource checks the call giving the value of a field, if any, and returns the type of the value of the field
+// when it is known
+func checkSource(field *Field, funcs FuncMap, report func(string, ...interface{})) reflect.Type {
+	switch {
+	case field.Literal != nil:
+		return reflect.TypeOf(field.Literal.Value())
+	case field.Call == nil:
+		return nil
+	}
+
+	f, ok := funcs[field.Value]
+	if !ok {
+		report("unknown function")
+		return nil
+	}
+	fn := reflect.ValueOf(f)
+	if fn.Kind() != reflect.Func || !goodFunc(fn.Type()) {
+		report("not a valid function")
+		return nil
+	}
+	if !field.Call.PerRow {
+		for _, p := range field.Call.Parameters {
+			if v, key := p.Value(); key {
+				report("a call evaluated once per run can't take the column %s", v)
+			}
+		}
+	}
+
+	typ := checkCall(fn.Type(), field.Call.Parameters, false, nil, report)
+	if field.Explode {
+		return checkExplode(typ, report)
+	}
+
+	return typ
+}
+
+// checkExplode checks that a value of type typ can explode a row and returns the type of its elements when it is known
+func checkExplode(typ reflect.Type, report func(string, ...interface{})) reflect.Type {
+	if typ == nil || typ.Kind() == reflect.Interface {
+		return nil
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array || isBytes(typ) {
+		report("can't explode a %s, it isn't a slice", typ)
+		return nil
+	}
+
+	return typ.Elem()
+}
+
+// checkWhere checks the function calls of the where clause of the entry, their params can be fields or source columns
+func checkWhere(entry string, where *Where, funcs FuncMap, add func(Diagnostic)) {
+	for _, o := range where.Operands() {
+		if o.Args == nil {
+			continue
+		}
+		report := func(format string, args ...interface{}) {
+			add(Diagnostic{entry, "where", o.Name, fmt.Sprintf(format, args...)})
+		}
+
+		f, ok := funcs[o.Name]
+		if !ok {
+			report("unknown function")
+			continue
+		}
+		fn := reflect.ValueOf(f)
+		if fn.Kind() != reflect.Func || !goodFunc(fn.Type()) {
+			report("not a valid function")
+			continue
+		}
+
+		checkCall(fn.Type(), o.Args.Parameters, false, nil, report)
+	}
+}
+
+// checkCall checks the arguments of a call to a function of type typ, the params of the schema and then the piped
+// value unless the call gives the value of a field. It returns the type of the result, nil when the call is wrong
+func checkCall(typ reflect.Type, params []*FuncParameter, pipe bool, piped reflect.Type, report func(string, ...interface{})) reflect.Type {
+	// The piped value is the last argument
+	n := len(params)
+	if pipe {
+		n++
+	}
+	if typ.IsVariadic() && n < typ.NumIn()-1 || !typ.IsVariadic() && n != typ.NumIn() {
+		report("expected %d arguments, got %d", typ.NumIn(), n)
+		return nil
+	}
+
+	ok := true
+	for i, p := range params {
+		v, key := p.Value()
+		if key {
+			// The value of another column of the row
+			continue
+		}
+		if _, err := coerce(reflect.ValueOf(v), paramType(typ, i)); err != nil {
+			report("param %d: %v", i+1, err)
+			ok = false
+		}
+	}
+	if pipe && piped != nil && !canCoerce(piped, paramType(typ, n-1)) {
+		report("can't take the %s piped into it as %s", piped, paramType(typ, n-1))
+		ok = false
+	}
+
+	if !ok {
+		return nil
+	}
+
+	return typ.Out(0)
+}
@@ -0,0 +1,156 @@
+package flock_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	flock "github.com/srikrsna/flock/pkg"
+)
+
+func TestValidate(t *testing.T) {
+	funcs := flock.FuncMap{
+		"Small":  func(n int8, v interface{}) interface{} { return v },
+		"Year":   func(v time.Time) int { return v.Year() },
+		"Upper":  func(v string) string { return strings.ToUpper(v) },
+		"Concat": func(sep string, vs ...string) string { return strings.Join(vs, sep) },
+		"Flag":   func(v interface{}) bool { return v != nil },
+		"Now":    time.Now,
+		"Split":  func(sep, v string) []string { return strings.Split(v, sep) },
+	}
+
+	tests := []struct {
+		name   string
+		fields string
+		want   []flock.Diagnostic
+	}{
+		{"Valid", `- a = A | Upper | Year
+			- b = B | Concat "-" C D | Small 1
+			- c = C | Flag`, nil},
+		{"Unknown", `- a = A | Lower`, []flock.Diagnostic{{"Users", "a", "Lower", "unknown function"}}},
+		{"Arguments", `- a = A | Upper "x"`, []flock.Diagnostic{{"Users", "a", "Upper", "expected 1 arguments, got 2"}}},
+		{"Literal", `- a = A | Small 300`, []flock.Diagnostic{{"Users", "a", "Small", "param 1: int64 300 doesn't fit in int8"}}},
+		{"Piped", `- a = A | Flag | Year`, []flock.Diagnostic{{"Users", "a", "Year", "can't take the bool piped into it as time.Time"}}},
+		{"All", `- a = A | Lower
+			- b = B | Small "x"
+			- c = C | Flag | Upper`, []flock.Diagnostic{
+			{"Users", "a", "Lower", "unknown function"},
+			{"Users", "b", "Small", "param 1: can't use string x as int8"},
+			{"Users", "c", "Upper", "can't take the bool piped into it as string"},
+		}},
+		{"Sources", `- a = "x" | Upper
+			- b = Now() | Year
+			- c = Upper(Name) per row | Flag
+			- d = $batch | Upper`, nil},
+		{"SourceErrors", `- a = 1 | Year
+			- b = Missing()
+			- c = Upper(Name)
+			- d = Upper() per row`, []flock.Diagnostic{
+			{"Users", "a", "Year", "can't take the int64 piped into it as time.Time"},
+			{"Users", "b", "Missing", "unknown function"},
+			{"Users", "c", "Upper", "a call evaluated once per run can't take the column Name"},
+			{"Users", "d", "Upper", "expected 1 arguments, got 0"},
+		}},
+		{"Explode", `- a = A | each Split "," | Upper
+			- b = each B | Upper
+			- c = each Split("," B) per row | Upper`, nil},
+		{"ExplodeErrors", `- a = A | each Upper
+			- b = each Now()`, []flock.Diagnostic{
+			{"Users", "a", "Upper", "can't explode a string, it isn't a slice"},
+			{"Users", "b", "Now", "can't explode a time.Time, it isn't a slice"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fl, err := flock.ParseSchema(strings.NewReader("Users {\n `SELECT * FROM Users`\n {\n" + tt.fields + "\n}\n}"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = flock.Validate(fl, funcs)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			verr, ok := err.(*flock.ValidationError)
+			if !ok {
+				t.Fatalf("expected a validation error, got %v", err)
+			}
+			if !reflect.DeepEqual(verr.Diagnostics, tt.want) {
+				t.Errorf("got %v, want %v", verr.Diagnostics, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateBuiltins(t *testing.T) {
+	fl, err := flock.ParseSchema(strings.NewReader("Users {\n `SELECT * FROM Users`\n {\n" +
+		` - name = Name | TrimSpace | Title
+		  - born = Born | ParseDate "02/01/2006" | FormatDate "2006-01-02"
+		  - id = ID | ToGuid "Users"
+		 }
+		}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := flock.Validate(fl, flock.DefaultFuncs().Map()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidateWhere(t *testing.T) {
+	fl, err := flock.ParseSchema(strings.NewReader("Users {\n `SELECT * FROM Users`\n {\n - name = Name\n } " +
+		`where not Blank(name) and (Missing(Email) or Blank(name Email)) and Blank(7)
+		}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = flock.Validate(fl, flock.FuncMap{"Blank": func(v string) bool { return v == "" }})
+	verr, ok := err.(*flock.ValidationError)
+	if !ok {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	want := []flock.Diagnostic{
+		{"Users", "where", "Missing", "unknown function"},
+		{"Users", "where", "Blank", "expected 1 arguments, got 2"},
+		{"Users", "where", "Blank", "param 1: can't use int64 7 as string"},
+	}
+	if !reflect.DeepEqual(verr.Diagnostics, want) {
+		t.Errorf("got %v, want %v", verr.Diagnostics, want)
+	}
+}
+
+func TestValidateTargets(t *testing.T) {
+	fl, err := flock.ParseSchema(strings.NewReader("Users {\n `SELECT * FROM Users`\n" +
+		` into users {
+		  - id = ID
+		  - name = Name | Lower
+		 }
+		 into user_profiles {
+		  - bio = Bio | Lower
+		 } where Blank(bio)
+		}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = flock.Validate(fl, flock.FuncMap{"Upper": strings.ToUpper})
+	verr, ok := err.(*flock.ValidationError)
+	if !ok {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	want := []flock.Diagnostic{
+		{"Users.users", "name", "Lower", "unknown function"},
+		{"Users.user_profiles", "bio", "Lower", "unknown function"},
+		{"Users.user_profiles", "where", "Blank", "unknown function"},
+	}
+	if !reflect.DeepEqual(verr.Diagnostics, want) {
+		t.Errorf("got %v, want %v", verr.Diagnostics, want)
+	}
+}
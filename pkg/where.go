@@ -0,0 +1,272 @@
+package flock
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Keep tells whether the row passes the where clause of the table, values are the values of its columns.
+// A failing function or values that can't be compared are errors
+func (t Table) Keep(row map[string]interface{}, values []interface{}, funcs *Funcs) (bool, error) {
+	if t.Where == nil {
+		return true, nil
+	}
+
+	env := &whereEnv{row: row, values: values, funcs: funcs, index: make(map[string]int, len(t.Ordered))}
+	for i, key := range t.Ordered {
+		env.index[key] = i
+	}
+
+	return env.where(t.Where)
+}
+
+// resolve looks up the params of the clause in the values of the run
+func (w *Where) resolve(params map[string]interface{}) error {
+	for _, o := range w.Operands() {
+		if o.Param == nil {
+			continue
+		}
+		v, ok := params[*o.Param]
+		if !ok {
+			return fmt.Errorf("the param $%s isn't given", *o.Param)
+		}
+		o.value, o.resolved = v, true
+	}
+
+	return nil
+}
+
+// Operands returns the operands of every comparison of the clause
+func (w *Where) Operands() []*Operand {
+	ops := make([]*Operand, 0)
+	for _, and := range w.Or {
+		for _, not := range and.And {
+			if not.Sub != nil {
+				ops = append(ops, not.Sub.Operands()...)
+				continue
+			}
+			ops = append(ops, not.Comparison.Left)
+			if not.Comparison.Right != nil {
+				ops = append(ops, not.Comparison.Right)
+			}
+		}
+	}
+
+	return ops
+}
+
+// whereEnv holds what the names of a where clause refer to
+type whereEnv struct {
+	row    map[string]interface{}
+	values []interface{}
+	funcs  *Funcs
+	index  map[string]int
+}
+
+func (env *whereEnv) where(w *Where) (bool, error) {
+	for _, and := range w.Or {
+		ok, err := env.and(and)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
+
+func (env *whereEnv) and(w *WhereAnd) (bool, error) {
+	for _, not := range w.And {
+		ok, err := env.not(not)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func (env *whereEnv) not(w *WhereNot) (bool, error) {
+	var (
+		ok  bool
+		err error
+	)
+	if w.Sub != nil {
+		ok, err = env.where(w.Sub)
+	} else {
+		ok, err = env.comparison(w.Comparison)
+	}
+
+	return ok != bool(w.Not), err
+}
+
+func (env *whereEnv) comparison(c *Comparison) (bool, error) {
+	left, err := env.operand(c.Left)
+	if err != nil {
+		return false, err
+	}
+	if c.Op == "" {
+		if left == nil {
+			return false, nil
+		}
+		b, ok := left.(bool)
+		if !ok {
+			return false, fmt.Errorf("%s is a %T, not a bool", c.Left, left)
+		}
+		return b, nil
+	}
+
+	right, err := env.operand(c.Right)
+	if err != nil {
+		return false, err
+	}
+	// Only = null and != null test for NULL, like IS NULL and IS NOT NULL
+	if (c.Left.Null || c.Right.Null) && (c.Op == "=" || c.Op == "!=") {
+		return (left == nil && right == nil) == (c.Op == "="), nil
+	}
+
+	return compare(left, c.Op, right)
+}
+
+func (env *whereEnv) operand(o *Operand) (interface{}, error) {
+	switch {
+	case o.Null:
+		return nil, nil
+	case o.Literal != nil:
+		return o.Literal.Value(), nil
+	case o.Param != nil:
+		if !o.resolved {
+			return nil, fmt.Errorf("the param $%s isn't resolved for the run", *o.Param)
+		}
+		return o.value, nil
+	case o.Args == nil:
+		return env.name(o.Name), nil
+	}
+
+	fn, ok := env.funcs.Lookup(o.Name)
+	if !ok {
+		return nil, fmt.Errorf("function %s is not registered", o.Name)
+	}
+	in := make([]reflect.Value, len(o.Args.Parameters))
+	for i, p := range o.Args.Parameters {
+		v, key := p.Value()
+		if key {
+			v = env.name(v.(string))
+		}
+		in[i] = reflect.ValueOf(v)
+	}
+	rt, err := callFunc(fn, in)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", o.Name, err)
+	}
+	if len(rt) == 2 && !rt[1].IsNil() {
+		return nil, fmt.Errorf("%s: %v", o.Name, rt[1].Interface())
+	}
+
+	return indirect(rt[0].Interface()), nil
+}
+
+// name returns the value of the field, or of the source column when there is no field of that name
+func (env *whereEnv) name(name string) interface{} {
+	if i, ok := env.index[name]; ok {
+		return indirect(env.values[i])
+	}
+
+	return indirect(env.row[name])
+}
+
+// indirect returns the value v points to, such as the *time.Time values of decoded rows
+func indirect(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr {
+		return v
+	}
+	if rv.IsNil() {
+		return nil
+	}
+
+	return rv.Elem().Interface()
+}
+
+func (o *Operand) String() string {
+	switch {
+	case o.Null:
+		return "null"
+	case o.Literal != nil:
+		return fmt.Sprintf("%#v", o.Literal.Value())
+	case o.Param != nil:
+		return "$" + *o.Param
+	case o.Args != nil:
+		return o.Name + "()"
+	}
+
+	return o.Name
+}
+
+// compare compares the values like SQL does, any comparison with NULL is false
+func compare(left interface{}, op string, right interface{}) (bool, error) {
+	if left == nil || right == nil {
+		return false, nil
+	}
+
+	var c int
+	l, r := reflect.ValueOf(left), reflect.ValueOf(right)
+	lt, lok := left.(time.Time)
+	rt, rok := right.(time.Time)
+	switch {
+	case isInt(l.Kind()) && isInt(r.Kind()):
+		c = compareOrdered(l.Int() < r.Int(), l.Int() > r.Int())
+	case isNumber(l.Kind()) && isNumber(r.Kind()):
+		lf, rf := toFloat(l), toFloat(r)
+		c = compareOrdered(lf < rf, lf > rf)
+	case (l.Kind() == reflect.String || isBytes(l.Type())) && (r.Kind() == reflect.String || isBytes(r.Type())):
+		c = strings.Compare(l.Convert(reflect.TypeOf("")).String(), r.Convert(reflect.TypeOf("")).String())
+	case lok && rok:
+		c = compareOrdered(lt.Before(rt), lt.After(rt))
+	case op == "=" || op == "!=":
+		if l.Type() != r.Type() {
+			return false, fmt.Errorf("can't compare %T with %T", left, right)
+		}
+		return reflect.DeepEqual(left, right) == (op == "="), nil
+	default:
+		return false, fmt.Errorf("can't compare %T with %T using %s", left, right, op)
+	}
+
+	switch op {
+	case "=":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+func compareOrdered(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+
+	return 0
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch {
+	case isInt(v.Kind()):
+		return float64(v.Int())
+	case isUint(v.Kind()):
+		return float64(v.Uint())
+	}
+
+	return v.Float()
+}
@@ -0,0 +1,120 @@
+package flock_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	flock "github.com/srikrsna/flock/pkg"
+	pb "github.com/srikrsna/flock/protos"
+)
+
+func TestWhere(t *testing.T) {
+	funcs := flock.DefaultFuncs()
+	if err := funcs.Register(flock.FuncMap{
+		"Blank": func(v string) bool { return strings.TrimSpace(v) == "" },
+		"Len":   func(v string) int { return len(v) },
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	joined := time.Date(2019, 6, 10, 0, 0, 0, 0, time.UTC)
+	// UUIDs sent typed are compared with string literals
+	v, err := flock.EncodeValue(&pb.Column{Name: "Key", DatabaseType: "UUID"}, "6f9619ff-8b86-d011-b42d-00c04fc964ff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := flock.DecodeValue(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := map[string]interface{}{
+		"Key":    key,
+		"ID":     int64(7),
+		"Email":  "a@b.com",
+		"Name":   "  ",
+		"Score":  float32(2.5),
+		"Active": true,
+		"Notes":  nil,
+		"Joined": &joined,
+	}
+
+	tests := []struct {
+		name    string
+		where   string
+		want    bool
+		wantErr bool
+	}{
+		{"Equal", `id = 7`, true, false},
+		{"Field", `email = "A@B.COM"`, true, false},
+		{"Column", `Email = "A@B.COM"`, false, false},
+		{"Numbers", `Score > 2 and Score <= 2.5 and id >= 7.0`, true, false},
+		{"Strings", `Email < "b" and Email != "a"`, true, false},
+		{"Null", `Notes = null and id != null`, true, false},
+		{"NullCompare", `Notes < 1 or Notes != 1`, false, false},
+		{"Bool", `Active`, true, false},
+		{"UUID", `Key = "6f9619ff-8b86-d011-b42d-00c04fc964ff"`, true, false},
+		{"NullBool", `Notes`, false, false},
+		{"Not", `not Active or not (id = 7)`, false, false},
+		{"Precedence", `id = 1 and id = 2 or Active`, true, false},
+		{"Call", `Blank(Name) and not Blank(email) and Len(email) = 7`, true, false},
+		{"TimeText", `Joined < "2020-01-01"`, false, true},
+		{"Param", `Joined > $since and id != $skip`, true, false},
+		{"NotBool", `id`, false, true},
+		{"Types", `id = "7"`, false, true},
+		{"Unknown", `Missing(id)`, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fl, err := flock.ParseSchema(strings.NewReader("Users {\n `SELECT * FROM Users`\n {\n - id = ID\n - email = Email | Upper\n } where " + tt.where + "\n}"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tables, vars := flock.BuildTables(fl)
+			params := map[string]interface{}{"since": time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), "skip": int64(3)}
+			if err := flock.ResolveSources(tables, funcs, params); err != nil {
+				t.Fatal(err)
+			}
+
+			values, err := flock.CalculateValuesOfRow(row, tables["Users"], funcs, vars["Users"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := tables["Users"].Keep(row, values[0], funcs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrepareRowsWhere(t *testing.T) {
+	fl, err := flock.ParseSchema(strings.NewReader("Users {\n `SELECT * FROM Users`\n {\n - id = ID\n } where id > 1\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, vars := flock.BuildTables(fl)
+	funcs := flock.DefaultFuncs()
+
+	rows := []map[string]interface{}{{"ID": int64(1)}, {"ID": int64(2)}, {"ID": int64(3)}}
+	values, err := flock.PrepareRows(rows, tables["Users"], funcs, vars["Users"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values[0][0] != int64(2) {
+		t.Errorf("unexpected values: %v", values)
+	}
+
+	_, err = flock.PrepareRows([]map[string]interface{}{{"ID": int64(2)}, {"ID": "x"}}, tables["Users"], funcs, vars["Users"])
+	rerr, ok := err.(*flock.RowError)
+	if !ok {
+		t.Fatalf("expected a row error, got: %v", err)
+	}
+	if rerr.Entry != "Users" || rerr.Row != 1 || !strings.Contains(err.Error(), "where: ") {
+		t.Errorf("unexpected row error: %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	context "context"
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	grpc "google.golang.org/grpc"
 	math "math"
 )
@@ -22,12 +23,145 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+// TransactionScope decides how much of a run is inserted in a single transaction
+type TransactionScope int32
+
+const (
+	// One transaction for the whole run committed at the end of the stream
+	TransactionScope_RUN TransactionScope = 0
+	// One transaction for every table
+	TransactionScope_TABLE TransactionScope = 1
+	// A commit after every commit_every batches
+	TransactionScope_BATCH TransactionScope = 2
+)
+
+var TransactionScope_name = map[int32]string{
+	0: "RUN",
+	1: "TABLE",
+	2: "BATCH",
+}
+
+var TransactionScope_value = map[string]int32{
+	"RUN":   0,
+	"TABLE": 1,
+	"BATCH": 2,
+}
+
+func (x TransactionScope) String() string {
+	return proto.EnumName(TransactionScope_name, int32(x))
+}
+
+func (TransactionScope) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{0}
+}
+
+type Encoding int32
+
+const (
+	// gob encoded []map[string]interface{}, kept for older clients
+	Encoding_GOB Encoding = 0
+	// Rows message
+	Encoding_PROTO Encoding = 1
+)
+
+var Encoding_name = map[int32]string{
+	0: "GOB",
+	1: "PROTO",
+}
+
+var Encoding_value = map[string]int32{
+	"GOB":   0,
+	"PROTO": 1,
+}
+
+func (x Encoding) String() string {
+	return proto.EnumName(Encoding_name, int32(x))
+}
+
+func (Encoding) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{1}
+}
+
+type DeadLetter_Sink int32
+
+const (
+	// Rejected rows fail the run
+	DeadLetter_NONE DeadLetter_Sink = 0
+	// A table of the destination database with the columns run_id, table_name, column_name, row_data and error
+	DeadLetter_TABLE DeadLetter_Sink = 1
+	// An NDJSON file named after the run in the dead letter directory of the server
+	DeadLetter_FILE DeadLetter_Sink = 2
+)
+
+var DeadLetter_Sink_name = map[int32]string{
+	0: "NONE",
+	1: "TABLE",
+	2: "FILE",
+}
+
+var DeadLetter_Sink_value = map[string]int32{
+	"NONE":  0,
+	"TABLE": 1,
+	"FILE":  2,
+}
+
+func (x DeadLetter_Sink) String() string {
+	return proto.EnumName(DeadLetter_Sink_name, int32(x))
+}
+
+func (DeadLetter_Sink) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{3, 0}
+}
+
+type BatchError_Code int32
+
+const (
+	BatchError_UNKNOWN BatchError_Code = 0
+	// The data of the batch could not be decoded
+	BatchError_DECODE BatchError_Code = 1
+	// The table of the batch is not in the schema
+	BatchError_TABLE BatchError_Code = 2
+	// A function of a column failed
+	BatchError_FUNCTION BatchError_Code = 3
+	// The destination database rejected the insert
+	BatchError_INSERT BatchError_Code = 4
+	// The run or the entry rejected more rows than it may
+	BatchError_BUDGET BatchError_Code = 5
+)
+
+var BatchError_Code_name = map[int32]string{
+	0: "UNKNOWN",
+	1: "DECODE",
+	2: "TABLE",
+	3: "FUNCTION",
+	4: "INSERT",
+	5: "BUDGET",
+}
+
+var BatchError_Code_value = map[string]int32{
+	"UNKNOWN":  0,
+	"DECODE":   1,
+	"TABLE":    2,
+	"FUNCTION": 3,
+	"INSERT":   4,
+	"BUDGET":   5,
+}
+
+func (x BatchError_Code) String() string {
+	return proto.EnumName(BatchError_Code_name, int32(x))
+}
+
+func (BatchError_Code) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{29, 0}
+}
+
 type FlockRequest struct {
 	// Types that are valid to be assigned to Value:
 	//	*FlockRequest_Start
 	//	*FlockRequest_Ping
 	//	*FlockRequest_Batch
 	//	*FlockRequest_End
+	//	*FlockRequest_Resume
 	Value                isFlockRequest_Value `protobuf_oneof:"value"`
 	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
 	XXX_unrecognized     []byte               `json:"-"`
@@ -79,6 +213,10 @@ type FlockRequest_End struct {
 	End *EndStream `protobuf:"bytes,4,opt,name=end,proto3,oneof"`
 }
 
+type FlockRequest_Resume struct {
+	Resume *Resume `protobuf:"bytes,5,opt,name=resume,proto3,oneof"`
+}
+
 func (*FlockRequest_Start) isFlockRequest_Value() {}
 
 func (*FlockRequest_Ping) isFlockRequest_Value() {}
@@ -87,6 +225,8 @@ func (*FlockRequest_Batch) isFlockRequest_Value() {}
 
 func (*FlockRequest_End) isFlockRequest_Value() {}
 
+func (*FlockRequest_Resume) isFlockRequest_Value() {}
+
 func (m *FlockRequest) GetValue() isFlockRequest_Value {
 	if m != nil {
 		return m.Value
@@ -122,6 +262,13 @@ func (m *FlockRequest) GetEnd() *EndStream {
 	return nil
 }
 
+func (m *FlockRequest) GetResume() *Resume {
+	if x, ok := m.GetValue().(*FlockRequest_Resume); ok {
+		return x.Resume
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*FlockRequest) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
@@ -129,6 +276,7 @@ func (*FlockRequest) XXX_OneofWrappers() []interface{} {
 		(*FlockRequest_Ping)(nil),
 		(*FlockRequest_Batch)(nil),
 		(*FlockRequest_End)(nil),
+		(*FlockRequest_Resume)(nil),
 	}
 }
 
@@ -136,6 +284,8 @@ type FlockResponse struct {
 	// Types that are valid to be assigned to Value:
 	//	*FlockResponse_Pong
 	//	*FlockResponse_Batch
+	//	*FlockResponse_Checkpoint
+	//	*FlockResponse_Commit
 	Value                isFlockResponse_Value `protobuf_oneof:"value"`
 	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
 	XXX_unrecognized     []byte                `json:"-"`
@@ -179,10 +329,22 @@ type FlockResponse_Batch struct {
 	Batch *BatchInsertResponse `protobuf:"bytes,2,opt,name=batch,proto3,oneof"`
 }
 
+type FlockResponse_Checkpoint struct {
+	Checkpoint *Checkpoint `protobuf:"bytes,3,opt,name=checkpoint,proto3,oneof"`
+}
+
+type FlockResponse_Commit struct {
+	Commit *Commit `protobuf:"bytes,4,opt,name=commit,proto3,oneof"`
+}
+
 func (*FlockResponse_Pong) isFlockResponse_Value() {}
 
 func (*FlockResponse_Batch) isFlockResponse_Value() {}
 
+func (*FlockResponse_Checkpoint) isFlockResponse_Value() {}
+
+func (*FlockResponse_Commit) isFlockResponse_Value() {}
+
 func (m *FlockResponse) GetValue() isFlockResponse_Value {
 	if m != nil {
 		return m.Value
@@ -204,20 +366,46 @@ func (m *FlockResponse) GetBatch() *BatchInsertResponse {
 	return nil
 }
 
+func (m *FlockResponse) GetCheckpoint() *Checkpoint {
+	if x, ok := m.GetValue().(*FlockResponse_Checkpoint); ok {
+		return x.Checkpoint
+	}
+	return nil
+}
+
+func (m *FlockResponse) GetCommit() *Commit {
+	if x, ok := m.GetValue().(*FlockResponse_Commit); ok {
+		return x.Commit
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*FlockResponse) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
 		(*FlockResponse_Pong)(nil),
 		(*FlockResponse_Batch)(nil),
+		(*FlockResponse_Checkpoint)(nil),
+		(*FlockResponse_Commit)(nil),
 	}
 }
 
 type Start struct {
-	Url                  string   `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
-	Database             string   `protobuf:"bytes,2,opt,name=database,proto3" json:"database,omitempty"`
-	Dollar               bool     `protobuf:"varint,3,opt,name=dollar,proto3" json:"dollar,omitempty"`
-	Schema               []byte   `protobuf:"bytes,4,opt,name=schema,proto3" json:"schema,omitempty"`
-	Plugin               []byte   `protobuf:"bytes,5,opt,name=plugin,proto3" json:"plugin,omitempty"`
+	Url      string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	Database string `protobuf:"bytes,2,opt,name=database,proto3" json:"database,omitempty"`
+	Schema   []byte `protobuf:"bytes,4,opt,name=schema,proto3" json:"schema,omitempty"`
+	Plugin   []byte `protobuf:"bytes,5,opt,name=plugin,proto3" json:"plugin,omitempty"`
+	// Name of the destination SQL dialect, defaults to the one of the database driver
+	Dialect string `protobuf:"bytes,6,opt,name=dialect,proto3" json:"dialect,omitempty"`
+	// Identifies the run in the checkpoint store of the server
+	RunId string           `protobuf:"bytes,7,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	Scope TransactionScope `protobuf:"varint,8,opt,name=scope,proto3,enum=flock.TransactionScope" json:"scope,omitempty"`
+	// Number of batches in a transaction when the scope is BATCH, defaults to 1
+	CommitEvery int64 `protobuf:"varint,9,opt,name=commit_every,json=commitEvery,proto3" json:"commit_every,omitempty"`
+	// Rows failing a function or the insert are stored here instead of failing the run
+	DeadLetter *DeadLetter `protobuf:"bytes,10,opt,name=dead_letter,json=deadLetter,proto3" json:"dead_letter,omitempty"`
+	// JSON object of the params of the run, used by the fields taking the value of a param
+	Params               []byte   `protobuf:"bytes,11,opt,name=params,proto3" json:"params,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -262,13 +450,6 @@ func (m *Start) GetDatabase() string {
 	return ""
 }
 
-func (m *Start) GetDollar() bool {
-	if m != nil {
-		return m.Dollar
-	}
-	return false
-}
-
 func (m *Start) GetSchema() []byte {
 	if m != nil {
 		return m.Schema
@@ -283,6 +464,235 @@ func (m *Start) GetPlugin() []byte {
 	return nil
 }
 
+func (m *Start) GetDialect() string {
+	if m != nil {
+		return m.Dialect
+	}
+	return ""
+}
+
+func (m *Start) GetRunId() string {
+	if m != nil {
+		return m.RunId
+	}
+	return ""
+}
+
+func (m *Start) GetScope() TransactionScope {
+	if m != nil {
+		return m.Scope
+	}
+	return TransactionScope_RUN
+}
+
+func (m *Start) GetCommitEvery() int64 {
+	if m != nil {
+		return m.CommitEvery
+	}
+	return 0
+}
+
+func (m *Start) GetDeadLetter() *DeadLetter {
+	if m != nil {
+		return m.DeadLetter
+	}
+	return nil
+}
+
+func (m *Start) GetParams() []byte {
+	if m != nil {
+		return m.Params
+	}
+	return nil
+}
+
+type DeadLetter struct {
+	Sink DeadLetter_Sink `protobuf:"varint,1,opt,name=sink,proto3,enum=flock.DeadLetter_Sink" json:"sink,omitempty"`
+	// Name of the table of the TABLE sink
+	Table string `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`
+	// Number of rows the run may reject before failing, 0 for no limit
+	MaxErrors            int64    `protobuf:"varint,3,opt,name=max_errors,json=maxErrors,proto3" json:"max_errors,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeadLetter) Reset()         { *m = DeadLetter{} }
+func (m *DeadLetter) String() string { return proto.CompactTextString(m) }
+func (*DeadLetter) ProtoMessage()    {}
+func (*DeadLetter) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{3}
+}
+
+func (m *DeadLetter) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeadLetter.Unmarshal(m, b)
+}
+func (m *DeadLetter) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeadLetter.Marshal(b, m, deterministic)
+}
+func (m *DeadLetter) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeadLetter.Merge(m, src)
+}
+func (m *DeadLetter) XXX_Size() int {
+	return xxx_messageInfo_DeadLetter.Size(m)
+}
+func (m *DeadLetter) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeadLetter.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeadLetter proto.InternalMessageInfo
+
+func (m *DeadLetter) GetSink() DeadLetter_Sink {
+	if m != nil {
+		return m.Sink
+	}
+	return DeadLetter_NONE
+}
+
+func (m *DeadLetter) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *DeadLetter) GetMaxErrors() int64 {
+	if m != nil {
+		return m.MaxErrors
+	}
+	return 0
+}
+
+// Commit reports the progress made durable by a commit
+type Commit struct {
+	// Last batch committed for every table
+	Batches map[string]int64 `protobuf:"bytes,1,rep,name=batches,proto3" json:"batches,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// Rows committed by this attempt of the run for every table
+	Rows                 map[string]int64 `protobuf:"bytes,2,rep,name=rows,proto3" json:"rows,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *Commit) Reset()         { *m = Commit{} }
+func (m *Commit) String() string { return proto.CompactTextString(m) }
+func (*Commit) ProtoMessage()    {}
+func (*Commit) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{4}
+}
+
+func (m *Commit) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Commit.Unmarshal(m, b)
+}
+func (m *Commit) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Commit.Marshal(b, m, deterministic)
+}
+func (m *Commit) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Commit.Merge(m, src)
+}
+func (m *Commit) XXX_Size() int {
+	return xxx_messageInfo_Commit.Size(m)
+}
+func (m *Commit) XXX_DiscardUnknown() {
+	xxx_messageInfo_Commit.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Commit proto.InternalMessageInfo
+
+func (m *Commit) GetBatches() map[string]int64 {
+	if m != nil {
+		return m.Batches
+	}
+	return nil
+}
+
+func (m *Commit) GetRows() map[string]int64 {
+	if m != nil {
+		return m.Rows
+	}
+	return nil
+}
+
+// Resume asks for the checkpoint of the run started
+type Resume struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Resume) Reset()         { *m = Resume{} }
+func (m *Resume) String() string { return proto.CompactTextString(m) }
+func (*Resume) ProtoMessage()    {}
+func (*Resume) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{5}
+}
+
+func (m *Resume) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Resume.Unmarshal(m, b)
+}
+func (m *Resume) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Resume.Marshal(b, m, deterministic)
+}
+func (m *Resume) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Resume.Merge(m, src)
+}
+func (m *Resume) XXX_Size() int {
+	return xxx_messageInfo_Resume.Size(m)
+}
+func (m *Resume) XXX_DiscardUnknown() {
+	xxx_messageInfo_Resume.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Resume proto.InternalMessageInfo
+
+// Checkpoint holds the number of batches of every table committed by previous attempts of a run
+type Checkpoint struct {
+	RunId                string           `protobuf:"bytes,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	Tables               map[string]int64 `protobuf:"bytes,2,rep,name=tables,proto3" json:"tables,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *Checkpoint) Reset()         { *m = Checkpoint{} }
+func (m *Checkpoint) String() string { return proto.CompactTextString(m) }
+func (*Checkpoint) ProtoMessage()    {}
+func (*Checkpoint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{6}
+}
+
+func (m *Checkpoint) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Checkpoint.Unmarshal(m, b)
+}
+func (m *Checkpoint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Checkpoint.Marshal(b, m, deterministic)
+}
+func (m *Checkpoint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Checkpoint.Merge(m, src)
+}
+func (m *Checkpoint) XXX_Size() int {
+	return xxx_messageInfo_Checkpoint.Size(m)
+}
+func (m *Checkpoint) XXX_DiscardUnknown() {
+	xxx_messageInfo_Checkpoint.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Checkpoint proto.InternalMessageInfo
+
+func (m *Checkpoint) GetRunId() string {
+	if m != nil {
+		return m.RunId
+	}
+	return ""
+}
+
+func (m *Checkpoint) GetTables() map[string]int64 {
+	if m != nil {
+		return m.Tables
+	}
+	return nil
+}
+
 type Ping struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -293,7 +703,7 @@ func (m *Ping) Reset()         { *m = Ping{} }
 func (m *Ping) String() string { return proto.CompactTextString(m) }
 func (*Ping) ProtoMessage()    {}
 func (*Ping) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0dfcec39829db5bd, []int{3}
+	return fileDescriptor_0dfcec39829db5bd, []int{7}
 }
 
 func (m *Ping) XXX_Unmarshal(b []byte) error {
@@ -324,7 +734,7 @@ func (m *Pong) Reset()         { *m = Pong{} }
 func (m *Pong) String() string { return proto.CompactTextString(m) }
 func (*Pong) ProtoMessage()    {}
 func (*Pong) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0dfcec39829db5bd, []int{4}
+	return fileDescriptor_0dfcec39829db5bd, []int{8}
 }
 
 func (m *Pong) XXX_Unmarshal(b []byte) error {
@@ -345,97 +755,487 @@ func (m *Pong) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_Pong proto.InternalMessageInfo
 
-type DBPing struct {
-	Url                  string   `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
-	Database             string   `protobuf:"bytes,2,opt,name=database,proto3" json:"database,omitempty"`
+// IDsRequest names the table of the ID mappings. The destination database is only needed
+// when the server keeps the mappings in the destination
+type IDsRequest struct {
+	Table                string   `protobuf:"bytes,1,opt,name=table,proto3" json:"table,omitempty"`
+	Url                  string   `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	Database             string   `protobuf:"bytes,3,opt,name=database,proto3" json:"database,omitempty"`
+	Dialect              string   `protobuf:"bytes,4,opt,name=dialect,proto3" json:"dialect,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *DBPing) Reset()         { *m = DBPing{} }
-func (m *DBPing) String() string { return proto.CompactTextString(m) }
-func (*DBPing) ProtoMessage()    {}
-func (*DBPing) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0dfcec39829db5bd, []int{5}
+func (m *IDsRequest) Reset()         { *m = IDsRequest{} }
+func (m *IDsRequest) String() string { return proto.CompactTextString(m) }
+func (*IDsRequest) ProtoMessage()    {}
+func (*IDsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{9}
 }
 
-func (m *DBPing) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_DBPing.Unmarshal(m, b)
+func (m *IDsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_IDsRequest.Unmarshal(m, b)
 }
-func (m *DBPing) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_DBPing.Marshal(b, m, deterministic)
+func (m *IDsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_IDsRequest.Marshal(b, m, deterministic)
 }
-func (m *DBPing) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_DBPing.Merge(m, src)
+func (m *IDsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IDsRequest.Merge(m, src)
 }
-func (m *DBPing) XXX_Size() int {
-	return xxx_messageInfo_DBPing.Size(m)
+func (m *IDsRequest) XXX_Size() int {
+	return xxx_messageInfo_IDsRequest.Size(m)
 }
-func (m *DBPing) XXX_DiscardUnknown() {
-	xxx_messageInfo_DBPing.DiscardUnknown(m)
+func (m *IDsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_IDsRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_DBPing proto.InternalMessageInfo
+var xxx_messageInfo_IDsRequest proto.InternalMessageInfo
 
-func (m *DBPing) GetUrl() string {
+func (m *IDsRequest) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *IDsRequest) GetUrl() string {
 	if m != nil {
 		return m.Url
 	}
 	return ""
 }
 
-func (m *DBPing) GetDatabase() string {
+func (m *IDsRequest) GetDatabase() string {
 	if m != nil {
 		return m.Database
 	}
 	return ""
 }
 
-type DBPong struct {
-	Schema               []byte   `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`
+func (m *IDsRequest) GetDialect() string {
+	if m != nil {
+		return m.Dialect
+	}
+	return ""
+}
+
+type ForeignKeysRequest struct {
+	Url      string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	Database string `protobuf:"bytes,2,opt,name=database,proto3" json:"database,omitempty"`
+	// Name of the destination SQL dialect, defaults to the one of the database driver
+	Dialect              string   `protobuf:"bytes,3,opt,name=dialect,proto3" json:"dialect,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *DBPong) Reset()         { *m = DBPong{} }
-func (m *DBPong) String() string { return proto.CompactTextString(m) }
-func (*DBPong) ProtoMessage()    {}
-func (*DBPong) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0dfcec39829db5bd, []int{6}
+func (m *ForeignKeysRequest) Reset()         { *m = ForeignKeysRequest{} }
+func (m *ForeignKeysRequest) String() string { return proto.CompactTextString(m) }
+func (*ForeignKeysRequest) ProtoMessage()    {}
+func (*ForeignKeysRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{10}
 }
 
-func (m *DBPong) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_DBPong.Unmarshal(m, b)
+func (m *ForeignKeysRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ForeignKeysRequest.Unmarshal(m, b)
 }
-func (m *DBPong) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_DBPong.Marshal(b, m, deterministic)
+func (m *ForeignKeysRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ForeignKeysRequest.Marshal(b, m, deterministic)
 }
-func (m *DBPong) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_DBPong.Merge(m, src)
+func (m *ForeignKeysRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ForeignKeysRequest.Merge(m, src)
 }
-func (m *DBPong) XXX_Size() int {
-	return xxx_messageInfo_DBPong.Size(m)
+func (m *ForeignKeysRequest) XXX_Size() int {
+	return xxx_messageInfo_ForeignKeysRequest.Size(m)
 }
-func (m *DBPong) XXX_DiscardUnknown() {
-	xxx_messageInfo_DBPong.DiscardUnknown(m)
+func (m *ForeignKeysRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ForeignKeysRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_DBPong proto.InternalMessageInfo
+var xxx_messageInfo_ForeignKeysRequest proto.InternalMessageInfo
 
-func (m *DBPong) GetSchema() []byte {
+func (m *ForeignKeysRequest) GetUrl() string {
 	if m != nil {
-		return m.Schema
+		return m.Url
 	}
-	return nil
+	return ""
 }
 
-type Batch struct {
-	// Types that are valid to be assigned to Value:
-	//	*Batch_Head
-	//	*Batch_Chunk
-	//	*Batch_Tail
+func (m *ForeignKeysRequest) GetDatabase() string {
+	if m != nil {
+		return m.Database
+	}
+	return ""
+}
+
+func (m *ForeignKeysRequest) GetDialect() string {
+	if m != nil {
+		return m.Dialect
+	}
+	return ""
+}
+
+type ForeignKeysResponse struct {
+	Keys                 []*ForeignKey `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *ForeignKeysResponse) Reset()         { *m = ForeignKeysResponse{} }
+func (m *ForeignKeysResponse) String() string { return proto.CompactTextString(m) }
+func (*ForeignKeysResponse) ProtoMessage()    {}
+func (*ForeignKeysResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{11}
+}
+
+func (m *ForeignKeysResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ForeignKeysResponse.Unmarshal(m, b)
+}
+func (m *ForeignKeysResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ForeignKeysResponse.Marshal(b, m, deterministic)
+}
+func (m *ForeignKeysResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ForeignKeysResponse.Merge(m, src)
+}
+func (m *ForeignKeysResponse) XXX_Size() int {
+	return xxx_messageInfo_ForeignKeysResponse.Size(m)
+}
+func (m *ForeignKeysResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ForeignKeysResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ForeignKeysResponse proto.InternalMessageInfo
+
+func (m *ForeignKeysResponse) GetKeys() []*ForeignKey {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+// ForeignKey lists the tables referenced by a table
+type ForeignKey struct {
+	Table                string   `protobuf:"bytes,1,opt,name=table,proto3" json:"table,omitempty"`
+	References           []string `protobuf:"bytes,2,rep,name=references,proto3" json:"references,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ForeignKey) Reset()         { *m = ForeignKey{} }
+func (m *ForeignKey) String() string { return proto.CompactTextString(m) }
+func (*ForeignKey) ProtoMessage()    {}
+func (*ForeignKey) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{12}
+}
+
+func (m *ForeignKey) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ForeignKey.Unmarshal(m, b)
+}
+func (m *ForeignKey) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ForeignKey.Marshal(b, m, deterministic)
+}
+func (m *ForeignKey) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ForeignKey.Merge(m, src)
+}
+func (m *ForeignKey) XXX_Size() int {
+	return xxx_messageInfo_ForeignKey.Size(m)
+}
+func (m *ForeignKey) XXX_DiscardUnknown() {
+	xxx_messageInfo_ForeignKey.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ForeignKey proto.InternalMessageInfo
+
+func (m *ForeignKey) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *ForeignKey) GetReferences() []string {
+	if m != nil {
+		return m.References
+	}
+	return nil
+}
+
+type IDMapping struct {
+	OldId                string   `protobuf:"bytes,1,opt,name=old_id,json=oldId,proto3" json:"old_id,omitempty"`
+	NewId                string   `protobuf:"bytes,2,opt,name=new_id,json=newId,proto3" json:"new_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *IDMapping) Reset()         { *m = IDMapping{} }
+func (m *IDMapping) String() string { return proto.CompactTextString(m) }
+func (*IDMapping) ProtoMessage()    {}
+func (*IDMapping) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{13}
+}
+
+func (m *IDMapping) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_IDMapping.Unmarshal(m, b)
+}
+func (m *IDMapping) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_IDMapping.Marshal(b, m, deterministic)
+}
+func (m *IDMapping) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IDMapping.Merge(m, src)
+}
+func (m *IDMapping) XXX_Size() int {
+	return xxx_messageInfo_IDMapping.Size(m)
+}
+func (m *IDMapping) XXX_DiscardUnknown() {
+	xxx_messageInfo_IDMapping.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_IDMapping proto.InternalMessageInfo
+
+func (m *IDMapping) GetOldId() string {
+	if m != nil {
+		return m.OldId
+	}
+	return ""
+}
+
+func (m *IDMapping) GetNewId() string {
+	if m != nil {
+		return m.NewId
+	}
+	return ""
+}
+
+type IDMappings struct {
+	Mappings             []*IDMapping `protobuf:"bytes,1,rep,name=mappings,proto3" json:"mappings,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *IDMappings) Reset()         { *m = IDMappings{} }
+func (m *IDMappings) String() string { return proto.CompactTextString(m) }
+func (*IDMappings) ProtoMessage()    {}
+func (*IDMappings) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{14}
+}
+
+func (m *IDMappings) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_IDMappings.Unmarshal(m, b)
+}
+func (m *IDMappings) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_IDMappings.Marshal(b, m, deterministic)
+}
+func (m *IDMappings) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IDMappings.Merge(m, src)
+}
+func (m *IDMappings) XXX_Size() int {
+	return xxx_messageInfo_IDMappings.Size(m)
+}
+func (m *IDMappings) XXX_DiscardUnknown() {
+	xxx_messageInfo_IDMappings.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_IDMappings proto.InternalMessageInfo
+
+func (m *IDMappings) GetMappings() []*IDMapping {
+	if m != nil {
+		return m.Mappings
+	}
+	return nil
+}
+
+// ImportIDsRequest carries the table with the first message of the stream and mappings with any of them
+type ImportIDsRequest struct {
+	Table                *IDsRequest  `protobuf:"bytes,1,opt,name=table,proto3" json:"table,omitempty"`
+	Mappings             []*IDMapping `protobuf:"bytes,2,rep,name=mappings,proto3" json:"mappings,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *ImportIDsRequest) Reset()         { *m = ImportIDsRequest{} }
+func (m *ImportIDsRequest) String() string { return proto.CompactTextString(m) }
+func (*ImportIDsRequest) ProtoMessage()    {}
+func (*ImportIDsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{15}
+}
+
+func (m *ImportIDsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ImportIDsRequest.Unmarshal(m, b)
+}
+func (m *ImportIDsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ImportIDsRequest.Marshal(b, m, deterministic)
+}
+func (m *ImportIDsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ImportIDsRequest.Merge(m, src)
+}
+func (m *ImportIDsRequest) XXX_Size() int {
+	return xxx_messageInfo_ImportIDsRequest.Size(m)
+}
+func (m *ImportIDsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ImportIDsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ImportIDsRequest proto.InternalMessageInfo
+
+func (m *ImportIDsRequest) GetTable() *IDsRequest {
+	if m != nil {
+		return m.Table
+	}
+	return nil
+}
+
+func (m *ImportIDsRequest) GetMappings() []*IDMapping {
+	if m != nil {
+		return m.Mappings
+	}
+	return nil
+}
+
+type ImportIDsResponse struct {
+	Received int64 `protobuf:"varint,1,opt,name=received,proto3" json:"received,omitempty"`
+	// Mappings stored, the others were already mapped
+	Imported             int64    `protobuf:"varint,2,opt,name=imported,proto3" json:"imported,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ImportIDsResponse) Reset()         { *m = ImportIDsResponse{} }
+func (m *ImportIDsResponse) String() string { return proto.CompactTextString(m) }
+func (*ImportIDsResponse) ProtoMessage()    {}
+func (*ImportIDsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{16}
+}
+
+func (m *ImportIDsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ImportIDsResponse.Unmarshal(m, b)
+}
+func (m *ImportIDsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ImportIDsResponse.Marshal(b, m, deterministic)
+}
+func (m *ImportIDsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ImportIDsResponse.Merge(m, src)
+}
+func (m *ImportIDsResponse) XXX_Size() int {
+	return xxx_messageInfo_ImportIDsResponse.Size(m)
+}
+func (m *ImportIDsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ImportIDsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ImportIDsResponse proto.InternalMessageInfo
+
+func (m *ImportIDsResponse) GetReceived() int64 {
+	if m != nil {
+		return m.Received
+	}
+	return 0
+}
+
+func (m *ImportIDsResponse) GetImported() int64 {
+	if m != nil {
+		return m.Imported
+	}
+	return 0
+}
+
+type DBPing struct {
+	Url                  string   `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	Database             string   `protobuf:"bytes,2,opt,name=database,proto3" json:"database,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DBPing) Reset()         { *m = DBPing{} }
+func (m *DBPing) String() string { return proto.CompactTextString(m) }
+func (*DBPing) ProtoMessage()    {}
+func (*DBPing) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{17}
+}
+
+func (m *DBPing) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DBPing.Unmarshal(m, b)
+}
+func (m *DBPing) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DBPing.Marshal(b, m, deterministic)
+}
+func (m *DBPing) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DBPing.Merge(m, src)
+}
+func (m *DBPing) XXX_Size() int {
+	return xxx_messageInfo_DBPing.Size(m)
+}
+func (m *DBPing) XXX_DiscardUnknown() {
+	xxx_messageInfo_DBPing.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DBPing proto.InternalMessageInfo
+
+func (m *DBPing) GetUrl() string {
+	if m != nil {
+		return m.Url
+	}
+	return ""
+}
+
+func (m *DBPing) GetDatabase() string {
+	if m != nil {
+		return m.Database
+	}
+	return ""
+}
+
+type DBPong struct {
+	Schema               []byte   `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DBPong) Reset()         { *m = DBPong{} }
+func (m *DBPong) String() string { return proto.CompactTextString(m) }
+func (*DBPong) ProtoMessage()    {}
+func (*DBPong) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{18}
+}
+
+func (m *DBPong) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DBPong.Unmarshal(m, b)
+}
+func (m *DBPong) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DBPong.Marshal(b, m, deterministic)
+}
+func (m *DBPong) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DBPong.Merge(m, src)
+}
+func (m *DBPong) XXX_Size() int {
+	return xxx_messageInfo_DBPong.Size(m)
+}
+func (m *DBPong) XXX_DiscardUnknown() {
+	xxx_messageInfo_DBPong.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DBPong proto.InternalMessageInfo
+
+func (m *DBPong) GetSchema() []byte {
+	if m != nil {
+		return m.Schema
+	}
+	return nil
+}
+
+type Batch struct {
+	// Types that are valid to be assigned to Value:
+	//	*Batch_Head
+	//	*Batch_Chunk
+	//	*Batch_Tail
 	Value                isBatch_Value `protobuf_oneof:"value"`
 	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
 	XXX_unrecognized     []byte        `json:"-"`
@@ -446,7 +1246,7 @@ func (m *Batch) Reset()         { *m = Batch{} }
 func (m *Batch) String() string { return proto.CompactTextString(m) }
 func (*Batch) ProtoMessage()    {}
 func (*Batch) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0dfcec39829db5bd, []int{7}
+	return fileDescriptor_0dfcec39829db5bd, []int{19}
 }
 
 func (m *Batch) XXX_Unmarshal(b []byte) error {
@@ -537,7 +1337,7 @@ func (m *EndStream) Reset()         { *m = EndStream{} }
 func (m *EndStream) String() string { return proto.CompactTextString(m) }
 func (*EndStream) ProtoMessage()    {}
 func (*EndStream) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0dfcec39829db5bd, []int{8}
+	return fileDescriptor_0dfcec39829db5bd, []int{20}
 }
 
 func (m *EndStream) XXX_Unmarshal(b []byte) error {
@@ -546,80 +1346,436 @@ func (m *EndStream) XXX_Unmarshal(b []byte) error {
 func (m *EndStream) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
 	return xxx_messageInfo_EndStream.Marshal(b, m, deterministic)
 }
-func (m *EndStream) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_EndStream.Merge(m, src)
+func (m *EndStream) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EndStream.Merge(m, src)
+}
+func (m *EndStream) XXX_Size() int {
+	return xxx_messageInfo_EndStream.Size(m)
+}
+func (m *EndStream) XXX_DiscardUnknown() {
+	xxx_messageInfo_EndStream.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EndStream proto.InternalMessageInfo
+
+func (m *EndStream) GetRecords() []byte {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
+type BatchInsertHead struct {
+	BatchId   string `protobuf:"bytes,1,opt,name=BatchId,proto3" json:"BatchId,omitempty"`
+	TableName string `protobuf:"bytes,2,opt,name=tableName,proto3" json:"tableName,omitempty"`
+	Chunks    int64  `protobuf:"varint,3,opt,name=chunks,proto3" json:"chunks,omitempty"`
+	// Position of the batch in its table starting from 1
+	Sequence int64 `protobuf:"varint,4,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Encoding of the data of the chunks
+	Encoding             Encoding `protobuf:"varint,5,opt,name=encoding,proto3,enum=flock.Encoding" json:"encoding,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BatchInsertHead) Reset()         { *m = BatchInsertHead{} }
+func (m *BatchInsertHead) String() string { return proto.CompactTextString(m) }
+func (*BatchInsertHead) ProtoMessage()    {}
+func (*BatchInsertHead) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{21}
+}
+
+func (m *BatchInsertHead) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchInsertHead.Unmarshal(m, b)
+}
+func (m *BatchInsertHead) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchInsertHead.Marshal(b, m, deterministic)
+}
+func (m *BatchInsertHead) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchInsertHead.Merge(m, src)
+}
+func (m *BatchInsertHead) XXX_Size() int {
+	return xxx_messageInfo_BatchInsertHead.Size(m)
+}
+func (m *BatchInsertHead) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchInsertHead.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchInsertHead proto.InternalMessageInfo
+
+func (m *BatchInsertHead) GetBatchId() string {
+	if m != nil {
+		return m.BatchId
+	}
+	return ""
+}
+
+func (m *BatchInsertHead) GetTableName() string {
+	if m != nil {
+		return m.TableName
+	}
+	return ""
+}
+
+func (m *BatchInsertHead) GetChunks() int64 {
+	if m != nil {
+		return m.Chunks
+	}
+	return 0
+}
+
+func (m *BatchInsertHead) GetSequence() int64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *BatchInsertHead) GetEncoding() Encoding {
+	if m != nil {
+		return m.Encoding
+	}
+	return Encoding_GOB
+}
+
+// Rows is a batch of rows of a source query
+type Rows struct {
+	Columns              []*Column `protobuf:"bytes,1,rep,name=columns,proto3" json:"columns,omitempty"`
+	Rows                 []*Row    `protobuf:"bytes,2,rep,name=rows,proto3" json:"rows,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *Rows) Reset()         { *m = Rows{} }
+func (m *Rows) String() string { return proto.CompactTextString(m) }
+func (*Rows) ProtoMessage()    {}
+func (*Rows) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{22}
+}
+
+func (m *Rows) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Rows.Unmarshal(m, b)
+}
+func (m *Rows) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Rows.Marshal(b, m, deterministic)
+}
+func (m *Rows) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Rows.Merge(m, src)
+}
+func (m *Rows) XXX_Size() int {
+	return xxx_messageInfo_Rows.Size(m)
+}
+func (m *Rows) XXX_DiscardUnknown() {
+	xxx_messageInfo_Rows.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Rows proto.InternalMessageInfo
+
+func (m *Rows) GetColumns() []*Column {
+	if m != nil {
+		return m.Columns
+	}
+	return nil
+}
+
+func (m *Rows) GetRows() []*Row {
+	if m != nil {
+		return m.Rows
+	}
+	return nil
+}
+
+type Column struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Type of the column in the source database, as reported by its driver
+	DatabaseType         string   `protobuf:"bytes,2,opt,name=database_type,json=databaseType,proto3" json:"database_type,omitempty"`
+	Nullable             bool     `protobuf:"varint,3,opt,name=nullable,proto3" json:"nullable,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Column) Reset()         { *m = Column{} }
+func (m *Column) String() string { return proto.CompactTextString(m) }
+func (*Column) ProtoMessage()    {}
+func (*Column) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{23}
+}
+
+func (m *Column) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Column.Unmarshal(m, b)
+}
+func (m *Column) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Column.Marshal(b, m, deterministic)
+}
+func (m *Column) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Column.Merge(m, src)
+}
+func (m *Column) XXX_Size() int {
+	return xxx_messageInfo_Column.Size(m)
+}
+func (m *Column) XXX_DiscardUnknown() {
+	xxx_messageInfo_Column.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Column proto.InternalMessageInfo
+
+func (m *Column) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Column) GetDatabaseType() string {
+	if m != nil {
+		return m.DatabaseType
+	}
+	return ""
+}
+
+func (m *Column) GetNullable() bool {
+	if m != nil {
+		return m.Nullable
+	}
+	return false
+}
+
+// Row holds a value for every column, in the order of the columns
+type Row struct {
+	Values               []*Value `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Row) Reset()         { *m = Row{} }
+func (m *Row) String() string { return proto.CompactTextString(m) }
+func (*Row) ProtoMessage()    {}
+func (*Row) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{24}
+}
+
+func (m *Row) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Row.Unmarshal(m, b)
+}
+func (m *Row) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Row.Marshal(b, m, deterministic)
+}
+func (m *Row) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Row.Merge(m, src)
+}
+func (m *Row) XXX_Size() int {
+	return xxx_messageInfo_Row.Size(m)
+}
+func (m *Row) XXX_DiscardUnknown() {
+	xxx_messageInfo_Row.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Row proto.InternalMessageInfo
+
+func (m *Row) GetValues() []*Value {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+type Value struct {
+	// Types that are valid to be assigned to Kind:
+	//	*Value_NullValue
+	//	*Value_IntValue
+	//	*Value_FloatValue
+	//	*Value_StringValue
+	//	*Value_BytesValue
+	//	*Value_BoolValue
+	//	*Value_TimestampValue
+	//	*Value_DecimalValue
+	//	*Value_UuidValue
+	Kind isValue_Kind `protobuf_oneof:"kind"`
+	// Offset east of UTC of the zone of a timestamp, in seconds
+	TimestampOffset      int32    `protobuf:"varint,10,opt,name=timestamp_offset,json=timestampOffset,proto3" json:"timestamp_offset,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Value) Reset()         { *m = Value{} }
+func (m *Value) String() string { return proto.CompactTextString(m) }
+func (*Value) ProtoMessage()    {}
+func (*Value) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{25}
+}
+
+func (m *Value) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Value.Unmarshal(m, b)
+}
+func (m *Value) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Value.Marshal(b, m, deterministic)
+}
+func (m *Value) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Value.Merge(m, src)
+}
+func (m *Value) XXX_Size() int {
+	return xxx_messageInfo_Value.Size(m)
+}
+func (m *Value) XXX_DiscardUnknown() {
+	xxx_messageInfo_Value.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Value proto.InternalMessageInfo
+
+type isValue_Kind interface {
+	isValue_Kind()
+}
+
+type Value_NullValue struct {
+	NullValue bool `protobuf:"varint,1,opt,name=null_value,json=nullValue,proto3,oneof"`
+}
+
+type Value_IntValue struct {
+	IntValue int64 `protobuf:"varint,2,opt,name=int_value,json=intValue,proto3,oneof"`
+}
+
+type Value_FloatValue struct {
+	FloatValue float64 `protobuf:"fixed64,3,opt,name=float_value,json=floatValue,proto3,oneof"`
+}
+
+type Value_StringValue struct {
+	StringValue string `protobuf:"bytes,4,opt,name=string_value,json=stringValue,proto3,oneof"`
+}
+
+type Value_BytesValue struct {
+	BytesValue []byte `protobuf:"bytes,5,opt,name=bytes_value,json=bytesValue,proto3,oneof"`
+}
+
+type Value_BoolValue struct {
+	BoolValue bool `protobuf:"varint,6,opt,name=bool_value,json=boolValue,proto3,oneof"`
 }
-func (m *EndStream) XXX_Size() int {
-	return xxx_messageInfo_EndStream.Size(m)
+
+type Value_TimestampValue struct {
+	TimestampValue *timestamp.Timestamp `protobuf:"bytes,7,opt,name=timestamp_value,json=timestampValue,proto3,oneof"`
 }
-func (m *EndStream) XXX_DiscardUnknown() {
-	xxx_messageInfo_EndStream.DiscardUnknown(m)
+
+type Value_DecimalValue struct {
+	DecimalValue string `protobuf:"bytes,8,opt,name=decimal_value,json=decimalValue,proto3,oneof"`
 }
 
-var xxx_messageInfo_EndStream proto.InternalMessageInfo
+type Value_UuidValue struct {
+	UuidValue []byte `protobuf:"bytes,9,opt,name=uuid_value,json=uuidValue,proto3,oneof"`
+}
 
-func (m *EndStream) GetRecords() []byte {
+func (*Value_NullValue) isValue_Kind() {}
+
+func (*Value_IntValue) isValue_Kind() {}
+
+func (*Value_FloatValue) isValue_Kind() {}
+
+func (*Value_StringValue) isValue_Kind() {}
+
+func (*Value_BytesValue) isValue_Kind() {}
+
+func (*Value_BoolValue) isValue_Kind() {}
+
+func (*Value_TimestampValue) isValue_Kind() {}
+
+func (*Value_DecimalValue) isValue_Kind() {}
+
+func (*Value_UuidValue) isValue_Kind() {}
+
+func (m *Value) GetKind() isValue_Kind {
 	if m != nil {
-		return m.Records
+		return m.Kind
 	}
 	return nil
 }
 
-type BatchInsertHead struct {
-	BatchId              string   `protobuf:"bytes,1,opt,name=BatchId,proto3" json:"BatchId,omitempty"`
-	TableName            string   `protobuf:"bytes,2,opt,name=tableName,proto3" json:"tableName,omitempty"`
-	Chunks               int64    `protobuf:"varint,3,opt,name=chunks,proto3" json:"chunks,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *Value) GetNullValue() bool {
+	if x, ok := m.GetKind().(*Value_NullValue); ok {
+		return x.NullValue
+	}
+	return false
 }
 
-func (m *BatchInsertHead) Reset()         { *m = BatchInsertHead{} }
-func (m *BatchInsertHead) String() string { return proto.CompactTextString(m) }
-func (*BatchInsertHead) ProtoMessage()    {}
-func (*BatchInsertHead) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0dfcec39829db5bd, []int{9}
+func (m *Value) GetIntValue() int64 {
+	if x, ok := m.GetKind().(*Value_IntValue); ok {
+		return x.IntValue
+	}
+	return 0
 }
 
-func (m *BatchInsertHead) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_BatchInsertHead.Unmarshal(m, b)
-}
-func (m *BatchInsertHead) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_BatchInsertHead.Marshal(b, m, deterministic)
+func (m *Value) GetFloatValue() float64 {
+	if x, ok := m.GetKind().(*Value_FloatValue); ok {
+		return x.FloatValue
+	}
+	return 0
 }
-func (m *BatchInsertHead) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_BatchInsertHead.Merge(m, src)
+
+func (m *Value) GetStringValue() string {
+	if x, ok := m.GetKind().(*Value_StringValue); ok {
+		return x.StringValue
+	}
+	return ""
 }
-func (m *BatchInsertHead) XXX_Size() int {
-	return xxx_messageInfo_BatchInsertHead.Size(m)
+
+func (m *Value) GetBytesValue() []byte {
+	if x, ok := m.GetKind().(*Value_BytesValue); ok {
+		return x.BytesValue
+	}
+	return nil
 }
-func (m *BatchInsertHead) XXX_DiscardUnknown() {
-	xxx_messageInfo_BatchInsertHead.DiscardUnknown(m)
+
+func (m *Value) GetBoolValue() bool {
+	if x, ok := m.GetKind().(*Value_BoolValue); ok {
+		return x.BoolValue
+	}
+	return false
 }
 
-var xxx_messageInfo_BatchInsertHead proto.InternalMessageInfo
+func (m *Value) GetTimestampValue() *timestamp.Timestamp {
+	if x, ok := m.GetKind().(*Value_TimestampValue); ok {
+		return x.TimestampValue
+	}
+	return nil
+}
 
-func (m *BatchInsertHead) GetBatchId() string {
-	if m != nil {
-		return m.BatchId
+func (m *Value) GetDecimalValue() string {
+	if x, ok := m.GetKind().(*Value_DecimalValue); ok {
+		return x.DecimalValue
 	}
 	return ""
 }
 
-func (m *BatchInsertHead) GetTableName() string {
-	if m != nil {
-		return m.TableName
+func (m *Value) GetUuidValue() []byte {
+	if x, ok := m.GetKind().(*Value_UuidValue); ok {
+		return x.UuidValue
 	}
-	return ""
+	return nil
 }
 
-func (m *BatchInsertHead) GetChunks() int64 {
+func (m *Value) GetTimestampOffset() int32 {
 	if m != nil {
-		return m.Chunks
+		return m.TimestampOffset
 	}
 	return 0
 }
 
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*Value) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*Value_NullValue)(nil),
+		(*Value_IntValue)(nil),
+		(*Value_FloatValue)(nil),
+		(*Value_StringValue)(nil),
+		(*Value_BytesValue)(nil),
+		(*Value_BoolValue)(nil),
+		(*Value_TimestampValue)(nil),
+		(*Value_DecimalValue)(nil),
+		(*Value_UuidValue)(nil),
+	}
+}
+
 type DataStream struct {
 	BatchId              string   `protobuf:"bytes,1,opt,name=BatchId,proto3" json:"BatchId,omitempty"`
 	Index                int64    `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
@@ -633,7 +1789,7 @@ func (m *DataStream) Reset()         { *m = DataStream{} }
 func (m *DataStream) String() string { return proto.CompactTextString(m) }
 func (*DataStream) ProtoMessage()    {}
 func (*DataStream) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0dfcec39829db5bd, []int{10}
+	return fileDescriptor_0dfcec39829db5bd, []int{26}
 }
 
 func (m *DataStream) XXX_Unmarshal(b []byte) error {
@@ -686,7 +1842,7 @@ func (m *BatchInsertTail) Reset()         { *m = BatchInsertTail{} }
 func (m *BatchInsertTail) String() string { return proto.CompactTextString(m) }
 func (*BatchInsertTail) ProtoMessage()    {}
 func (*BatchInsertTail) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0dfcec39829db5bd, []int{11}
+	return fileDescriptor_0dfcec39829db5bd, []int{27}
 }
 
 func (m *BatchInsertTail) XXX_Unmarshal(b []byte) error {
@@ -714,8 +1870,22 @@ func (m *BatchInsertTail) GetBatchId() string {
 	return ""
 }
 
+// BatchInsertResponse is the result of a batch. The counts other than rows_received are of destination rows, they add
+// up the rows of every table an entry with several targets writes to and every row made by exploding a source row
 type BatchInsertResponse struct {
-	Success              bool     `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Success      bool   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	BatchId      string `protobuf:"bytes,2,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	TableName    string `protobuf:"bytes,3,opt,name=table_name,json=tableName,proto3" json:"table_name,omitempty"`
+	RowsReceived int64  `protobuf:"varint,4,opt,name=rows_received,json=rowsReceived,proto3" json:"rows_received,omitempty"`
+	RowsInserted int64  `protobuf:"varint,5,opt,name=rows_inserted,json=rowsInserted,proto3" json:"rows_inserted,omitempty"`
+	// Rows left out by the conflict clause of the table
+	RowsSkipped int64 `protobuf:"varint,6,opt,name=rows_skipped,json=rowsSkipped,proto3" json:"rows_skipped,omitempty"`
+	// Set when the batch failed
+	Error *BatchError `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"`
+	// Rows stored in the dead letter sink
+	RowsRejected int64 `protobuf:"varint,8,opt,name=rows_rejected,json=rowsRejected,proto3" json:"rows_rejected,omitempty"`
+	// Rows left out by the where clause of the entry
+	RowsFiltered         int64    `protobuf:"varint,9,opt,name=rows_filtered,json=rowsFiltered,proto3" json:"rows_filtered,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -725,7 +1895,7 @@ func (m *BatchInsertResponse) Reset()         { *m = BatchInsertResponse{} }
 func (m *BatchInsertResponse) String() string { return proto.CompactTextString(m) }
 func (*BatchInsertResponse) ProtoMessage()    {}
 func (*BatchInsertResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0dfcec39829db5bd, []int{12}
+	return fileDescriptor_0dfcec39829db5bd, []int{28}
 }
 
 func (m *BatchInsertResponse) XXX_Unmarshal(b []byte) error {
@@ -753,60 +1923,281 @@ func (m *BatchInsertResponse) GetSuccess() bool {
 	return false
 }
 
+func (m *BatchInsertResponse) GetBatchId() string {
+	if m != nil {
+		return m.BatchId
+	}
+	return ""
+}
+
+func (m *BatchInsertResponse) GetTableName() string {
+	if m != nil {
+		return m.TableName
+	}
+	return ""
+}
+
+func (m *BatchInsertResponse) GetRowsReceived() int64 {
+	if m != nil {
+		return m.RowsReceived
+	}
+	return 0
+}
+
+func (m *BatchInsertResponse) GetRowsInserted() int64 {
+	if m != nil {
+		return m.RowsInserted
+	}
+	return 0
+}
+
+func (m *BatchInsertResponse) GetRowsSkipped() int64 {
+	if m != nil {
+		return m.RowsSkipped
+	}
+	return 0
+}
+
+func (m *BatchInsertResponse) GetError() *BatchError {
+	if m != nil {
+		return m.Error
+	}
+	return nil
+}
+
+func (m *BatchInsertResponse) GetRowsRejected() int64 {
+	if m != nil {
+		return m.RowsRejected
+	}
+	return 0
+}
+
+func (m *BatchInsertResponse) GetRowsFiltered() int64 {
+	if m != nil {
+		return m.RowsFiltered
+	}
+	return 0
+}
+
+type BatchError struct {
+	Code    BatchError_Code `protobuf:"varint,1,opt,name=code,proto3,enum=flock.BatchError_Code" json:"code,omitempty"`
+	Message string          `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// Index of the failing row in the batch, -1 when the error isn't tied to a row
+	Row                  int64    `protobuf:"varint,3,opt,name=row,proto3" json:"row,omitempty"`
+	Column               string   `protobuf:"bytes,4,opt,name=column,proto3" json:"column,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BatchError) Reset()         { *m = BatchError{} }
+func (m *BatchError) String() string { return proto.CompactTextString(m) }
+func (*BatchError) ProtoMessage()    {}
+func (*BatchError) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0dfcec39829db5bd, []int{29}
+}
+
+func (m *BatchError) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchError.Unmarshal(m, b)
+}
+func (m *BatchError) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchError.Marshal(b, m, deterministic)
+}
+func (m *BatchError) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchError.Merge(m, src)
+}
+func (m *BatchError) XXX_Size() int {
+	return xxx_messageInfo_BatchError.Size(m)
+}
+func (m *BatchError) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchError.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchError proto.InternalMessageInfo
+
+func (m *BatchError) GetCode() BatchError_Code {
+	if m != nil {
+		return m.Code
+	}
+	return BatchError_UNKNOWN
+}
+
+func (m *BatchError) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *BatchError) GetRow() int64 {
+	if m != nil {
+		return m.Row
+	}
+	return 0
+}
+
+func (m *BatchError) GetColumn() string {
+	if m != nil {
+		return m.Column
+	}
+	return ""
+}
+
 func init() {
+	proto.RegisterEnum("flock.TransactionScope", TransactionScope_name, TransactionScope_value)
+	proto.RegisterEnum("flock.Encoding", Encoding_name, Encoding_value)
+	proto.RegisterEnum("flock.DeadLetter_Sink", DeadLetter_Sink_name, DeadLetter_Sink_value)
+	proto.RegisterEnum("flock.BatchError_Code", BatchError_Code_name, BatchError_Code_value)
 	proto.RegisterType((*FlockRequest)(nil), "flock.FlockRequest")
 	proto.RegisterType((*FlockResponse)(nil), "flock.FlockResponse")
 	proto.RegisterType((*Start)(nil), "flock.Start")
+	proto.RegisterType((*DeadLetter)(nil), "flock.DeadLetter")
+	proto.RegisterType((*Commit)(nil), "flock.Commit")
+	proto.RegisterMapType((map[string]int64)(nil), "flock.Commit.BatchesEntry")
+	proto.RegisterMapType((map[string]int64)(nil), "flock.Commit.RowsEntry")
+	proto.RegisterType((*Resume)(nil), "flock.Resume")
+	proto.RegisterType((*Checkpoint)(nil), "flock.Checkpoint")
+	proto.RegisterMapType((map[string]int64)(nil), "flock.Checkpoint.TablesEntry")
 	proto.RegisterType((*Ping)(nil), "flock.Ping")
 	proto.RegisterType((*Pong)(nil), "flock.Pong")
+	proto.RegisterType((*IDsRequest)(nil), "flock.IDsRequest")
+	proto.RegisterType((*ForeignKeysRequest)(nil), "flock.ForeignKeysRequest")
+	proto.RegisterType((*ForeignKeysResponse)(nil), "flock.ForeignKeysResponse")
+	proto.RegisterType((*ForeignKey)(nil), "flock.ForeignKey")
+	proto.RegisterType((*IDMapping)(nil), "flock.IDMapping")
+	proto.RegisterType((*IDMappings)(nil), "flock.IDMappings")
+	proto.RegisterType((*ImportIDsRequest)(nil), "flock.ImportIDsRequest")
+	proto.RegisterType((*ImportIDsResponse)(nil), "flock.ImportIDsResponse")
 	proto.RegisterType((*DBPing)(nil), "flock.DBPing")
 	proto.RegisterType((*DBPong)(nil), "flock.DBPong")
 	proto.RegisterType((*Batch)(nil), "flock.Batch")
 	proto.RegisterType((*EndStream)(nil), "flock.EndStream")
 	proto.RegisterType((*BatchInsertHead)(nil), "flock.BatchInsertHead")
+	proto.RegisterType((*Rows)(nil), "flock.Rows")
+	proto.RegisterType((*Column)(nil), "flock.Column")
+	proto.RegisterType((*Row)(nil), "flock.Row")
+	proto.RegisterType((*Value)(nil), "flock.Value")
 	proto.RegisterType((*DataStream)(nil), "flock.DataStream")
 	proto.RegisterType((*BatchInsertTail)(nil), "flock.BatchInsertTail")
 	proto.RegisterType((*BatchInsertResponse)(nil), "flock.BatchInsertResponse")
+	proto.RegisterType((*BatchError)(nil), "flock.BatchError")
 }
 
 func init() { proto.RegisterFile("protos/flock.proto", fileDescriptor_0dfcec39829db5bd) }
 
 var fileDescriptor_0dfcec39829db5bd = []byte{
-	// 542 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0xd1, 0x6e, 0xd3, 0x30,
-	0x14, 0x6d, 0x68, 0xd3, 0xb5, 0x77, 0x1d, 0x0c, 0x6f, 0x9a, 0xa2, 0x8a, 0x87, 0x61, 0x0d, 0x69,
-	0x08, 0xd4, 0xa1, 0x22, 0xed, 0x03, 0xaa, 0x82, 0xca, 0x0b, 0x9a, 0x3c, 0x7e, 0xc0, 0x4d, 0x4c,
-	0x1a, 0x2d, 0xb5, 0x4b, 0xec, 0x20, 0x5e, 0xf8, 0x0a, 0x1e, 0xf8, 0x09, 0x3e, 0x12, 0xdd, 0x6b,
-	0xa7, 0x4d, 0x61, 0x20, 0xed, 0xa9, 0x3e, 0xc7, 0xc7, 0xa7, 0xc7, 0xc7, 0x76, 0x80, 0x6d, 0x2a,
-	0xe3, 0x8c, 0xbd, 0xfa, 0x5c, 0x9a, 0xf4, 0x6e, 0x42, 0x80, 0xc5, 0x04, 0xf8, 0xaf, 0x08, 0x46,
-	0xef, 0x71, 0x24, 0xd4, 0x97, 0x5a, 0x59, 0xc7, 0x2e, 0x20, 0xb6, 0x4e, 0x56, 0x2e, 0x89, 0xce,
-	0xa3, 0xcb, 0xc3, 0xe9, 0x68, 0xe2, 0x17, 0xdd, 0x22, 0xb7, 0xe8, 0x08, 0x3f, 0xc9, 0x9e, 0x43,
-	0x6f, 0x53, 0xe8, 0x3c, 0x79, 0x44, 0xa2, 0xc3, 0x20, 0xba, 0x29, 0x74, 0xbe, 0xe8, 0x08, 0x9a,
-	0x42, 0xa3, 0xa5, 0x74, 0xe9, 0x2a, 0xe9, 0xee, 0x19, 0xcd, 0x90, 0x43, 0x23, 0x9a, 0x64, 0x17,
-	0xd0, 0x55, 0x3a, 0x4b, 0x7a, 0xa4, 0x39, 0x0e, 0x9a, 0x77, 0x3a, 0xbb, 0x75, 0x95, 0x92, 0xeb,
-	0x45, 0x47, 0xe0, 0xf4, 0xec, 0x00, 0xe2, 0xaf, 0xb2, 0xac, 0x15, 0x37, 0x70, 0x14, 0xd2, 0xda,
-	0x8d, 0xd1, 0x56, 0x51, 0x10, 0xa3, 0xf3, 0x90, 0x76, 0x1b, 0xc4, 0x84, 0x20, 0x46, 0xe7, 0x6c,
-	0xda, 0x04, 0xf1, 0x61, 0xc7, 0xed, 0x20, 0x1f, 0xb4, 0x55, 0x95, 0x6b, 0xdc, 0xb6, 0xb1, 0x76,
-	0x7f, 0xf8, 0x1d, 0x62, 0xda, 0x3a, 0x3b, 0x86, 0x6e, 0x5d, 0x95, 0xf4, 0x3f, 0x43, 0x81, 0x43,
-	0x36, 0x86, 0x41, 0x26, 0x9d, 0x5c, 0x4a, 0xab, 0xc8, 0x7a, 0x28, 0xb6, 0x98, 0x9d, 0x41, 0x3f,
-	0x33, 0x65, 0x29, 0x2b, 0xda, 0xfd, 0x40, 0x04, 0x84, 0xbc, 0x4d, 0x57, 0x6a, 0x2d, 0x69, 0xc7,
-	0x23, 0x11, 0x10, 0xf2, 0x9b, 0xb2, 0xce, 0x0b, 0x9d, 0xc4, 0x9e, 0xf7, 0x88, 0xf7, 0xa1, 0x87,
-	0xa5, 0xd2, 0xaf, 0xd1, 0x39, 0xbf, 0x86, 0xfe, 0x7c, 0x86, 0xcc, 0xc3, 0xf2, 0xf0, 0x73, 0x5a,
-	0x87, 0x6d, 0xec, 0x12, 0x44, 0xed, 0x04, 0xfc, 0x67, 0x04, 0x31, 0x55, 0xc2, 0x5e, 0x43, 0x6f,
-	0xa5, 0x64, 0x16, 0x2a, 0x3d, 0xfb, 0xbb, 0xae, 0x85, 0x92, 0x19, 0xb6, 0x8b, 0x2a, 0xf6, 0x12,
-	0xe2, 0x74, 0x55, 0xeb, 0xbb, 0xd0, 0xee, 0xd3, 0x20, 0x9f, 0x4b, 0x27, 0xb7, 0x67, 0xe8, 0x15,
-	0x68, 0xec, 0x64, 0x51, 0x86, 0x0b, 0x71, 0x8f, 0xf1, 0x27, 0x59, 0x94, 0x68, 0x8c, 0xaa, 0xdd,
-	0x11, 0xbc, 0x80, 0xe1, 0xf6, 0x42, 0xb0, 0x04, 0x0e, 0x2a, 0x95, 0x9a, 0x2a, 0xb3, 0x21, 0x7f,
-	0x03, 0xb9, 0x84, 0x27, 0x7f, 0x64, 0x44, 0xb1, 0xa7, 0xb2, 0xd0, 0x53, 0x03, 0xd9, 0x33, 0x18,
-	0x3a, 0xb9, 0x2c, 0xd5, 0x47, 0xb9, 0x6e, 0xca, 0xda, 0x11, 0xd8, 0x11, 0x25, 0xb6, 0x14, 0xb5,
-	0x2b, 0x02, 0xe2, 0x37, 0x00, 0xbb, 0x7d, 0xfd, 0xc7, 0xfd, 0x14, 0xe2, 0x42, 0x67, 0xea, 0x1b,
-	0x39, 0x77, 0x85, 0x07, 0x8c, 0x41, 0x0f, 0xcf, 0x83, 0x3c, 0x47, 0x82, 0xc6, 0xfc, 0xd5, 0x5e,
-	0x68, 0xdc, 0xff, 0xbf, 0x6d, 0xf9, 0x15, 0x9c, 0xdc, 0x73, 0x69, 0x71, 0x81, 0xad, 0xd3, 0x54,
-	0x59, 0x5f, 0xc9, 0x40, 0x34, 0x70, 0xfa, 0x23, 0x82, 0x98, 0x9e, 0x0b, 0xe3, 0xd0, 0x5f, 0x28,
-	0x59, 0xba, 0x15, 0x6b, 0xbf, 0xd5, 0x71, 0xfb, 0xbd, 0xb0, 0x09, 0x3c, 0x9e, 0x87, 0xfb, 0x12,
-	0xb4, 0x47, 0xcd, 0x61, 0xd2, 0x95, 0x1b, 0xb7, 0x20, 0xea, 0xaf, 0x1b, 0xf3, 0x93, 0xc0, 0xb7,
-	0xbf, 0x23, 0xe3, 0xd3, 0x7d, 0xd2, 0x67, 0xbd, 0x8c, 0xde, 0x44, 0xcb, 0x3e, 0x7d, 0x80, 0xde,
-	0xfe, 0x0e, 0x00, 0x00, 0xff, 0xff, 0xae, 0x14, 0xe6, 0xe6, 0x96, 0x04, 0x00, 0x00,
+	// 1768 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x57, 0x51, 0x93, 0xdb, 0x48,
+	0x11, 0xb6, 0x2c, 0xc9, 0xb6, 0xda, 0xde, 0xc4, 0x99, 0xe4, 0x72, 0x3a, 0x17, 0xc9, 0x6d, 0x94,
+	0xa4, 0x92, 0x4b, 0x8e, 0xcd, 0xb1, 0x81, 0x83, 0x4b, 0xf1, 0xc0, 0x79, 0xed, 0x3d, 0x9b, 0x04,
+	0x3b, 0x35, 0xeb, 0xc0, 0x0b, 0x94, 0x4b, 0x2b, 0xcd, 0x7a, 0x75, 0x96, 0x35, 0x46, 0x92, 0xb3,
+	0xf1, 0xff, 0xe0, 0x8a, 0xff, 0xc0, 0x4f, 0xe0, 0x1f, 0xf0, 0x00, 0xc5, 0x0b, 0xef, 0xfc, 0x14,
+	0x6a, 0x7a, 0x66, 0x24, 0x79, 0x37, 0xe1, 0x2a, 0x4f, 0x56, 0x7f, 0xfd, 0x4d, 0x4f, 0xf7, 0x4c,
+	0x77, 0x4f, 0x1b, 0xc8, 0x3a, 0xe5, 0x39, 0xcf, 0x9e, 0x9d, 0xc5, 0x3c, 0x58, 0x1e, 0xa0, 0x40,
+	0x6c, 0x14, 0x7a, 0x9f, 0x2f, 0x38, 0x5f, 0xc4, 0xec, 0x19, 0x82, 0xa7, 0x9b, 0xb3, 0x67, 0x79,
+	0xb4, 0x62, 0x59, 0xee, 0xaf, 0xd6, 0x92, 0xe7, 0xfd, 0xc7, 0x80, 0xce, 0xb1, 0xa0, 0x52, 0xf6,
+	0xe7, 0x0d, 0xcb, 0x72, 0xf2, 0x00, 0xec, 0x2c, 0xf7, 0xd3, 0xdc, 0x35, 0xf6, 0x8d, 0xc7, 0xed,
+	0xc3, 0xce, 0x81, 0xb4, 0x7a, 0x22, 0xb0, 0x51, 0x8d, 0x4a, 0x25, 0xb9, 0x07, 0xd6, 0x3a, 0x4a,
+	0x16, 0x6e, 0x1d, 0x49, 0x6d, 0x45, 0x7a, 0x1d, 0x25, 0x8b, 0x51, 0x8d, 0xa2, 0x4a, 0x18, 0x3a,
+	0xf5, 0xf3, 0xe0, 0xdc, 0x35, 0x77, 0x0c, 0xf5, 0x05, 0x26, 0x0c, 0xa1, 0x92, 0x3c, 0x00, 0x93,
+	0x25, 0xa1, 0x6b, 0x21, 0xa7, 0xab, 0x38, 0xc3, 0x24, 0x3c, 0xc9, 0x53, 0xe6, 0xaf, 0x46, 0x35,
+	0x2a, 0xd4, 0xe4, 0x11, 0x34, 0x52, 0x96, 0x6d, 0x56, 0xcc, 0xb5, 0x91, 0xb8, 0xa7, 0x88, 0x14,
+	0xc1, 0x51, 0x8d, 0x2a, 0x75, 0xbf, 0x09, 0xf6, 0x5b, 0x3f, 0xde, 0x30, 0xef, 0x9f, 0x06, 0xec,
+	0xa9, 0xb8, 0xb2, 0x35, 0x4f, 0x32, 0x86, 0x2e, 0xf3, 0x64, 0xe1, 0x1a, 0xbb, 0x2e, 0x73, 0xe5,
+	0x32, 0x4f, 0x16, 0xe4, 0x50, 0xbb, 0x2c, 0xc3, 0xea, 0x55, 0x5d, 0x1e, 0x27, 0x19, 0x4b, 0x73,
+	0x6d, 0xad, 0x0c, 0xe0, 0x39, 0x40, 0x70, 0xce, 0x82, 0xe5, 0x9a, 0x47, 0x49, 0xae, 0x62, 0xbd,
+	0xa1, 0x16, 0x1e, 0x15, 0x8a, 0x51, 0x8d, 0x56, 0x68, 0x22, 0x9e, 0x80, 0xaf, 0x56, 0x51, 0xee,
+	0x5a, 0x3b, 0xf1, 0x1c, 0x21, 0x28, 0xe2, 0x91, 0xea, 0x32, 0x9e, 0xbf, 0xd7, 0xc1, 0xc6, 0x3b,
+	0x20, 0x5d, 0x30, 0x37, 0x69, 0x8c, 0x61, 0x38, 0x54, 0x7c, 0x92, 0x1e, 0xb4, 0x42, 0x3f, 0xf7,
+	0x4f, 0xfd, 0x8c, 0xa1, 0xe7, 0x0e, 0x2d, 0x64, 0x72, 0x1b, 0x1a, 0x59, 0x70, 0xce, 0x56, 0x3e,
+	0xee, 0xd4, 0xa1, 0x4a, 0x12, 0xf8, 0x3a, 0xde, 0x2c, 0xa2, 0x04, 0x4f, 0xb4, 0x43, 0x95, 0x44,
+	0x5c, 0x68, 0x86, 0x91, 0x1f, 0xb3, 0x20, 0x77, 0x1b, 0x68, 0x4a, 0x8b, 0xe4, 0x13, 0x68, 0xa4,
+	0x9b, 0x64, 0x1e, 0x85, 0x6e, 0x13, 0x15, 0x76, 0xba, 0x49, 0xc6, 0x21, 0xf9, 0x29, 0xd8, 0x59,
+	0xc0, 0xd7, 0xcc, 0x6d, 0xed, 0x1b, 0x8f, 0xaf, 0x1d, 0x7e, 0xaa, 0x22, 0x99, 0xa5, 0x7e, 0x92,
+	0xf9, 0x41, 0x1e, 0xf1, 0xe4, 0x44, 0xa8, 0xa9, 0x64, 0x91, 0x7b, 0xd0, 0x91, 0xa1, 0xcd, 0xd9,
+	0x5b, 0x96, 0x6e, 0x5d, 0x67, 0xdf, 0x78, 0x6c, 0xd2, 0xb6, 0xc4, 0x86, 0x02, 0x22, 0x87, 0xd0,
+	0x0e, 0x99, 0x1f, 0xce, 0x63, 0x96, 0xe7, 0x2c, 0x75, 0x61, 0xe7, 0x48, 0x07, 0xcc, 0x0f, 0x5f,
+	0xa1, 0x82, 0x42, 0x58, 0x7c, 0x63, 0x38, 0x7e, 0xea, 0xaf, 0x32, 0xb7, 0xad, 0xc2, 0x41, 0xe9,
+	0xb7, 0x56, 0xcb, 0xec, 0x5a, 0xde, 0x5f, 0x0c, 0x80, 0x72, 0x21, 0x79, 0x02, 0x56, 0x16, 0x25,
+	0x4b, 0x3c, 0xc2, 0x6b, 0x87, 0xb7, 0xaf, 0x58, 0x3e, 0x38, 0x89, 0x92, 0x25, 0x45, 0x0e, 0xb9,
+	0x05, 0x76, 0xee, 0x9f, 0xc6, 0xfa, 0x60, 0xa5, 0x40, 0xee, 0x00, 0xac, 0xfc, 0x77, 0x73, 0x96,
+	0xa6, 0x3c, 0xcd, 0xf0, 0xd2, 0x4d, 0xea, 0xac, 0xfc, 0x77, 0x43, 0x04, 0xbc, 0x87, 0x60, 0x09,
+	0x13, 0xa4, 0x05, 0xd6, 0x64, 0x3a, 0x19, 0x76, 0x6b, 0xc4, 0x01, 0x7b, 0xf6, 0x6d, 0xff, 0xd5,
+	0xb0, 0x6b, 0x08, 0xf0, 0x78, 0xfc, 0x6a, 0xd8, 0xad, 0x7b, 0xff, 0x35, 0xa0, 0x21, 0x6f, 0x9c,
+	0xfc, 0x1c, 0x9a, 0x98, 0x4e, 0x2c, 0x73, 0x8d, 0x7d, 0xb3, 0x92, 0x7b, 0x52, 0x2f, 0x53, 0x90,
+	0x65, 0xc3, 0x24, 0x4f, 0xb7, 0x54, 0x53, 0xc9, 0x53, 0xb0, 0x52, 0x7e, 0x91, 0xb9, 0x75, 0x5c,
+	0xf2, 0xe9, 0xee, 0x12, 0xca, 0x2f, 0x14, 0x1f, 0x49, 0xbd, 0x17, 0xd0, 0xa9, 0x5a, 0x11, 0x79,
+	0xb4, 0x64, 0x5b, 0x9d, 0x47, 0x4b, 0xb6, 0x25, 0xb7, 0x54, 0xb2, 0x61, 0xac, 0x26, 0x95, 0xc2,
+	0x8b, 0xfa, 0xaf, 0x8c, 0xde, 0x2f, 0xc1, 0x29, 0xcc, 0x7d, 0xcc, 0x42, 0xaf, 0x05, 0x0d, 0x59,
+	0xa3, 0xde, 0x0f, 0x06, 0x40, 0x59, 0x0f, 0x95, 0x6c, 0x32, 0xaa, 0xd9, 0xf4, 0x0b, 0x68, 0xe0,
+	0x09, 0xeb, 0x98, 0xee, 0x5c, 0xa9, 0xa4, 0x83, 0x19, 0xea, 0x65, 0x64, 0x8a, 0xdc, 0xfb, 0x06,
+	0xda, 0x15, 0xf8, 0xa3, 0x3c, 0x6c, 0x80, 0x25, 0xda, 0x16, 0xfe, 0xf2, 0x64, 0xe1, 0x7d, 0x0f,
+	0x30, 0x1e, 0x64, 0xba, 0x1b, 0x16, 0xd7, 0x6f, 0x54, 0xaf, 0x5f, 0x95, 0x60, 0xfd, 0xfd, 0x25,
+	0x68, 0x5e, 0x2a, 0xc1, 0x4a, 0x49, 0x59, 0x3b, 0x25, 0xe5, 0xfd, 0x11, 0xc8, 0x31, 0x4f, 0x59,
+	0xb4, 0x48, 0x5e, 0xb2, 0x6d, 0xb1, 0xe7, 0xc7, 0x15, 0x78, 0xc5, 0xba, 0xb9, 0x6b, 0xfd, 0xd7,
+	0x70, 0x73, 0xc7, 0xba, 0xea, 0x83, 0x0f, 0xc1, 0x5a, 0xb2, 0xad, 0xce, 0x33, 0x5d, 0x57, 0x25,
+	0x93, 0xa2, 0xda, 0xeb, 0x03, 0x94, 0xd8, 0x07, 0xce, 0xe1, 0x2e, 0x40, 0xca, 0xce, 0x58, 0xca,
+	0x92, 0x40, 0xdd, 0x98, 0x43, 0x2b, 0x88, 0xf7, 0x0d, 0x38, 0xe3, 0xc1, 0xef, 0xfc, 0x35, 0xbe,
+	0x07, 0x9f, 0x40, 0x83, 0xc7, 0x61, 0xe5, 0xc6, 0x79, 0x1c, 0x8e, 0x43, 0x01, 0x27, 0xec, 0x42,
+	0xc0, 0xaa, 0xc2, 0x12, 0x76, 0x31, 0x0e, 0xbd, 0x17, 0x00, 0xc5, 0xd2, 0x8c, 0x7c, 0x09, 0xad,
+	0x95, 0xfa, 0x56, 0x7e, 0xeb, 0xa7, 0xa2, 0x20, 0xd1, 0x82, 0xe1, 0x45, 0xd0, 0x1d, 0xaf, 0xd6,
+	0x3c, 0xcd, 0x2b, 0x17, 0xf9, 0xa8, 0x1a, 0x40, 0x19, 0x76, 0xc9, 0xd0, 0x31, 0x55, 0xb7, 0xaa,
+	0xff, 0xe8, 0x56, 0x2f, 0xe1, 0x46, 0x65, 0x2b, 0x75, 0xc2, 0x3d, 0x68, 0xa5, 0x2c, 0x60, 0xd1,
+	0x5b, 0x26, 0x63, 0x35, 0x69, 0x21, 0x0b, 0x5d, 0x84, 0x0b, 0x58, 0xa8, 0x72, 0xb1, 0x90, 0xbd,
+	0xaf, 0xa1, 0x31, 0xe8, 0x8b, 0x64, 0xfc, 0xb8, 0x14, 0xf0, 0xf6, 0x71, 0x9d, 0x78, 0xc0, 0xca,
+	0x6e, 0x6f, 0x54, 0xbb, 0xbd, 0xf7, 0x57, 0x03, 0x6c, 0x2c, 0x7e, 0xf2, 0x25, 0x58, 0xe7, 0xcc,
+	0x0f, 0xd5, 0x31, 0xdc, 0xbe, 0xfa, 0xc2, 0x8d, 0x98, 0x1f, 0x8a, 0x07, 0x51, 0xb0, 0xc8, 0x17,
+	0x60, 0x07, 0xe7, 0x9b, 0x64, 0xe9, 0xd6, 0x77, 0x4e, 0x6d, 0xe0, 0xe7, 0x7e, 0xf1, 0x40, 0x4b,
+	0x86, 0x30, 0x9c, 0xfb, 0x51, 0xec, 0x9a, 0x1f, 0x32, 0x3c, 0xf3, 0xa3, 0x58, 0x18, 0x16, 0xac,
+	0xf2, 0x5d, 0x7b, 0x08, 0x4e, 0xf1, 0xda, 0x8b, 0x5c, 0x4e, 0x59, 0xc0, 0xd3, 0x30, 0x53, 0xfe,
+	0x6b, 0xd1, 0xfb, 0x9b, 0x01, 0xd7, 0x2f, 0x39, 0x29, 0xd8, 0x12, 0xd2, 0x19, 0xa5, 0x45, 0xf2,
+	0x13, 0x70, 0xf0, 0x32, 0x27, 0xfe, 0x4a, 0x9f, 0x56, 0x09, 0x88, 0x43, 0x42, 0x97, 0x75, 0xe3,
+	0x56, 0x92, 0x38, 0xe2, 0x4c, 0xe4, 0x42, 0x12, 0x30, 0x2c, 0x54, 0x93, 0x16, 0x32, 0x79, 0x0a,
+	0x2d, 0x96, 0x04, 0x3c, 0x14, 0x33, 0x8f, 0x8d, 0xcf, 0xc6, 0xf5, 0x62, 0x56, 0x91, 0x30, 0x2d,
+	0x08, 0xde, 0x14, 0x2c, 0xd1, 0x2d, 0xc9, 0x23, 0x68, 0x06, 0x3c, 0xde, 0xac, 0x12, 0x9d, 0xb4,
+	0xe5, 0x33, 0x2f, 0x50, 0xaa, 0xb5, 0xe4, 0xee, 0x4e, 0x1f, 0x07, 0xc5, 0xa2, 0xfc, 0x42, 0xb6,
+	0x6e, 0xef, 0x4f, 0xe2, 0x9d, 0x10, 0x54, 0x42, 0xc0, 0x4a, 0x44, 0x50, 0x32, 0x60, 0xfc, 0x26,
+	0xf7, 0x61, 0x4f, 0xa7, 0xc2, 0x3c, 0xdf, 0xae, 0x75, 0xc4, 0x1d, 0x0d, 0xce, 0xb6, 0x6b, 0xcc,
+	0xc9, 0x64, 0x13, 0xc7, 0x58, 0x02, 0x22, 0xec, 0x16, 0x2d, 0x64, 0xef, 0x29, 0x98, 0x94, 0x5f,
+	0x90, 0x07, 0xd0, 0xc0, 0x3b, 0xd1, 0xde, 0xea, 0x89, 0xed, 0xf7, 0x02, 0xa4, 0x4a, 0xe7, 0xfd,
+	0x60, 0x82, 0x8d, 0x08, 0xf9, 0x1c, 0x40, 0x98, 0x98, 0xa3, 0x02, 0x3d, 0x6a, 0x8d, 0x6a, 0xd4,
+	0x11, 0x98, 0x24, 0xdc, 0x01, 0x27, 0x4a, 0xf2, 0x79, 0xa5, 0xf1, 0x8e, 0x6a, 0xb4, 0x15, 0x25,
+	0xb9, 0x54, 0xdf, 0x83, 0xf6, 0x59, 0xcc, 0x7d, 0x4d, 0x10, 0x5e, 0x19, 0x62, 0x4e, 0x42, 0x50,
+	0x52, 0xee, 0x43, 0x27, 0xcb, 0xd3, 0x28, 0x59, 0x28, 0x0e, 0xf6, 0xcf, 0x51, 0x8d, 0xb6, 0x25,
+	0x5a, 0xd8, 0x39, 0xdd, 0xe6, 0x2c, 0x53, 0x1c, 0x9c, 0x67, 0x84, 0x1d, 0x04, 0x0b, 0x57, 0x4f,
+	0x39, 0xd7, 0xae, 0x36, 0xb4, 0xab, 0x02, 0x93, 0x84, 0x21, 0x5c, 0x2f, 0x26, 0x63, 0xc5, 0x6a,
+	0xaa, 0x19, 0x50, 0x4e, 0xd0, 0x07, 0x7a, 0x82, 0x3e, 0x98, 0x69, 0xde, 0xa8, 0x46, 0xaf, 0x15,
+	0x8b, 0xa4, 0x99, 0x87, 0xb0, 0x17, 0xb2, 0x20, 0x5a, 0xf9, 0x7a, 0xab, 0x96, 0x72, 0xb8, 0xa3,
+	0xe0, 0xc2, 0x9d, 0xcd, 0x26, 0x0a, 0x15, 0xc7, 0x51, 0x0e, 0x3b, 0x02, 0x93, 0x84, 0x2f, 0xa0,
+	0x5b, 0xba, 0xc3, 0xcf, 0xce, 0x32, 0x96, 0xe3, 0x1c, 0x64, 0xd3, 0xd2, 0xcd, 0x29, 0xc2, 0xfd,
+	0x06, 0x58, 0xcb, 0x28, 0x09, 0xbd, 0xd7, 0x00, 0x65, 0x59, 0xfe, 0x9f, 0xda, 0xb8, 0x05, 0x76,
+	0x94, 0x84, 0xec, 0x9d, 0x7e, 0x09, 0x51, 0x10, 0x79, 0x25, 0xd2, 0x05, 0x2f, 0xa1, 0x43, 0xf1,
+	0xdb, 0x7b, 0x0a, 0xd7, 0x2f, 0x95, 0xef, 0x87, 0xcd, 0x7a, 0xff, 0xa8, 0xc3, 0xcd, 0xf7, 0xcc,
+	0xc9, 0x62, 0x45, 0xb6, 0x09, 0x02, 0x96, 0xc9, 0x92, 0x6e, 0x51, 0x2d, 0x92, 0xcf, 0xa0, 0x85,
+	0x73, 0x4c, 0xd9, 0xfa, 0xe5, 0x5c, 0x33, 0x0e, 0xc5, 0x78, 0x85, 0xe5, 0x3a, 0xc7, 0x5c, 0x37,
+	0x2f, 0x17, 0xf0, 0x7d, 0xd8, 0x13, 0x65, 0x31, 0x2f, 0x9a, 0xac, 0xac, 0xd6, 0x8e, 0x00, 0xa9,
+	0xc2, 0x0a, 0x52, 0x84, 0xfe, 0xb0, 0xd0, 0xb5, 0x4b, 0xd2, 0x58, 0x61, 0x62, 0x1a, 0x45, 0x52,
+	0xb6, 0x8c, 0xd6, 0x6b, 0x16, 0x62, 0x66, 0x98, 0xb4, 0x2d, 0xb0, 0x13, 0x09, 0x89, 0x87, 0x03,
+	0xc7, 0x3c, 0xb7, 0xb9, 0xd3, 0x02, 0x31, 0x56, 0x1c, 0xf7, 0xa8, 0xd4, 0x57, 0xbc, 0xfa, 0x9e,
+	0x05, 0x62, 0xc3, 0x56, 0xd5, 0x2b, 0x89, 0x15, 0xa4, 0xb3, 0x28, 0xce, 0x59, 0xca, 0x42, 0xd7,
+	0x29, 0x49, 0xc7, 0x0a, 0xf3, 0xfe, 0x65, 0x00, 0x94, 0xf6, 0xc5, 0xb8, 0x1a, 0xf0, 0x90, 0x5d,
+	0x1a, 0x57, 0x4b, 0xc2, 0xc1, 0x11, 0x0f, 0x19, 0x45, 0x8e, 0x38, 0xee, 0x15, 0xcb, 0x32, 0x7f,
+	0xa1, 0xbb, 0x80, 0x16, 0xc5, 0x93, 0x92, 0xf2, 0x0b, 0xd5, 0xf2, 0xc4, 0x27, 0xf6, 0x41, 0xec,
+	0x2a, 0x6a, 0x2c, 0x51, 0x92, 0xf7, 0x1a, 0x2c, 0x61, 0x91, 0xb4, 0xa1, 0xf9, 0x66, 0xf2, 0x72,
+	0x32, 0xfd, 0xc3, 0xa4, 0x5b, 0x23, 0x00, 0x8d, 0xc1, 0xf0, 0x68, 0x3a, 0x10, 0x13, 0x6c, 0x31,
+	0xcc, 0xd6, 0x49, 0x07, 0x5a, 0xc7, 0x6f, 0x26, 0x47, 0xb3, 0xf1, 0x74, 0xd2, 0x35, 0x05, 0x69,
+	0x3c, 0x39, 0x19, 0xd2, 0x59, 0xd7, 0x12, 0xdf, 0xfd, 0x37, 0x83, 0xef, 0x86, 0xb3, 0xae, 0xfd,
+	0xe4, 0x67, 0xd0, 0xbd, 0xfc, 0x7f, 0x80, 0x34, 0xc1, 0xa4, 0x6f, 0x26, 0xbb, 0xa3, 0xb1, 0x03,
+	0x76, 0xff, 0xdb, 0xd9, 0xd1, 0xa8, 0x5b, 0x7f, 0x72, 0x17, 0x5a, 0xba, 0xb3, 0x0a, 0xea, 0x77,
+	0xd3, 0xbe, 0xa4, 0xbe, 0xa6, 0xd3, 0xd9, 0xb4, 0x6b, 0x1c, 0xfe, 0xbb, 0x0e, 0x36, 0xfe, 0xbf,
+	0x23, 0x1e, 0x34, 0x46, 0xcc, 0x8f, 0xf3, 0x73, 0x52, 0xfd, 0x1b, 0xda, 0xab, 0xfe, 0xc1, 0x23,
+	0x07, 0x70, 0x6d, 0xa0, 0xba, 0xa1, 0xe2, 0xea, 0x56, 0x2c, 0x1f, 0xdc, 0x5e, 0x45, 0x14, 0xfc,
+	0xaf, 0xb5, 0xf1, 0x9b, 0x7a, 0x3c, 0xaa, 0xfc, 0x45, 0xee, 0xdd, 0xda, 0x05, 0x65, 0xa6, 0x3f,
+	0x36, 0xbe, 0x32, 0xc8, 0x73, 0x70, 0x86, 0xef, 0xd4, 0x38, 0x40, 0xae, 0xce, 0x18, 0xbd, 0x1b,
+	0x97, 0x47, 0x89, 0xec, 0x2b, 0x83, 0xfc, 0x06, 0x9c, 0x62, 0x86, 0x20, 0x7a, 0x88, 0xbf, 0x3c,
+	0xc0, 0xf4, 0xdc, 0xab, 0x0a, 0xbd, 0x31, 0x19, 0x40, 0xbb, 0x32, 0xe9, 0x91, 0xcf, 0xae, 0xcc,
+	0x74, 0x85, 0x95, 0xde, 0xfb, 0x54, 0xd2, 0xce, 0x69, 0x03, 0x5b, 0xdc, 0xf3, 0xff, 0x0d, 0x00,
+	0x81, 0x35, 0x52, 0x00, 0x4f, 0x10, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -824,6 +2215,12 @@ type FlockClient interface {
 	Health(ctx context.Context, in *Ping, opts ...grpc.CallOption) (*Pong, error)
 	DatabaseHealth(ctx context.Context, in *DBPing, opts ...grpc.CallOption) (*DBPong, error)
 	Flock(ctx context.Context, opts ...grpc.CallOption) (Flock_FlockClient, error)
+	// ExportIDs streams the ID mappings of a table kept by the ID mapper of the server
+	ExportIDs(ctx context.Context, in *IDsRequest, opts ...grpc.CallOption) (Flock_ExportIDsClient, error)
+	// ImportIDs loads ID mappings into the ID mapper of the server, IDs already mapped keep their UUID
+	ImportIDs(ctx context.Context, opts ...grpc.CallOption) (Flock_ImportIDsClient, error)
+	// ForeignKeys lists the tables referenced by the foreign keys of the tables of the destination
+	ForeignKeys(ctx context.Context, in *ForeignKeysRequest, opts ...grpc.CallOption) (*ForeignKeysResponse, error)
 }
 
 type flockClient struct {
@@ -883,11 +2280,92 @@ func (x *flockFlockClient) Recv() (*FlockResponse, error) {
 	return m, nil
 }
 
+func (c *flockClient) ExportIDs(ctx context.Context, in *IDsRequest, opts ...grpc.CallOption) (Flock_ExportIDsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Flock_serviceDesc.Streams[1], "/flock.Flock/ExportIDs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &flockExportIDsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Flock_ExportIDsClient interface {
+	Recv() (*IDMappings, error)
+	grpc.ClientStream
+}
+
+type flockExportIDsClient struct {
+	grpc.ClientStream
+}
+
+func (x *flockExportIDsClient) Recv() (*IDMappings, error) {
+	m := new(IDMappings)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *flockClient) ImportIDs(ctx context.Context, opts ...grpc.CallOption) (Flock_ImportIDsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Flock_serviceDesc.Streams[2], "/flock.Flock/ImportIDs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &flockImportIDsClient{stream}
+	return x, nil
+}
+
+type Flock_ImportIDsClient interface {
+	Send(*ImportIDsRequest) error
+	CloseAndRecv() (*ImportIDsResponse, error)
+	grpc.ClientStream
+}
+
+type flockImportIDsClient struct {
+	grpc.ClientStream
+}
+
+func (x *flockImportIDsClient) Send(m *ImportIDsRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *flockImportIDsClient) CloseAndRecv() (*ImportIDsResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportIDsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *flockClient) ForeignKeys(ctx context.Context, in *ForeignKeysRequest, opts ...grpc.CallOption) (*ForeignKeysResponse, error) {
+	out := new(ForeignKeysResponse)
+	err := c.cc.Invoke(ctx, "/flock.Flock/ForeignKeys", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FlockServer is the server API for Flock service.
 type FlockServer interface {
 	Health(context.Context, *Ping) (*Pong, error)
 	DatabaseHealth(context.Context, *DBPing) (*DBPong, error)
 	Flock(Flock_FlockServer) error
+	// ExportIDs streams the ID mappings of a table kept by the ID mapper of the server
+	ExportIDs(*IDsRequest, Flock_ExportIDsServer) error
+	// ImportIDs loads ID mappings into the ID mapper of the server, IDs already mapped keep their UUID
+	ImportIDs(Flock_ImportIDsServer) error
+	// ForeignKeys lists the tables referenced by the foreign keys of the tables of the destination
+	ForeignKeys(context.Context, *ForeignKeysRequest) (*ForeignKeysResponse, error)
 }
 
 func RegisterFlockServer(s *grpc.Server, srv FlockServer) {
@@ -956,6 +2434,71 @@ func (x *flockFlockServer) Recv() (*FlockRequest, error) {
 	return m, nil
 }
 
+func _Flock_ExportIDs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(IDsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FlockServer).ExportIDs(m, &flockExportIDsServer{stream})
+}
+
+type Flock_ExportIDsServer interface {
+	Send(*IDMappings) error
+	grpc.ServerStream
+}
+
+type flockExportIDsServer struct {
+	grpc.ServerStream
+}
+
+func (x *flockExportIDsServer) Send(m *IDMappings) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Flock_ImportIDs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(FlockServer).ImportIDs(&flockImportIDsServer{stream})
+}
+
+type Flock_ImportIDsServer interface {
+	SendAndClose(*ImportIDsResponse) error
+	Recv() (*ImportIDsRequest, error)
+	grpc.ServerStream
+}
+
+type flockImportIDsServer struct {
+	grpc.ServerStream
+}
+
+func (x *flockImportIDsServer) SendAndClose(m *ImportIDsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *flockImportIDsServer) Recv() (*ImportIDsRequest, error) {
+	m := new(ImportIDsRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Flock_ForeignKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForeignKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FlockServer).ForeignKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/flock.Flock/ForeignKeys",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FlockServer).ForeignKeys(ctx, req.(*ForeignKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Flock_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "flock.Flock",
 	HandlerType: (*FlockServer)(nil),
@@ -968,6 +2511,10 @@ var _Flock_serviceDesc = grpc.ServiceDesc{
 			MethodName: "DatabaseHealth",
 			Handler:    _Flock_DatabaseHealth_Handler,
 		},
+		{
+			MethodName: "ForeignKeys",
+			Handler:    _Flock_ForeignKeys_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -976,6 +2523,16 @@ var _Flock_serviceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "ExportIDs",
+			Handler:       _Flock_ExportIDs_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ImportIDs",
+			Handler:       _Flock_ImportIDs_Handler,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "protos/flock.proto",
 }
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/dgraph-io/badger"
+	pb "github.com/srikrsna/flock/protos"
+)
+
+// CheckpointStore persists the progress of runs so that a failed run can be resumed
+type CheckpointStore interface {
+	// Load returns the number of committed batches of every table of the run
+	Load(runID string) (map[string]int64, error)
+	// Save stores the number of committed batches of the given tables of the run
+	Save(runID string, tables map[string]int64) error
+}
+
+// NewMemoryCheckpoints returns a checkpoint store that lives as long as the server process
+func NewMemoryCheckpoints() CheckpointStore {
+	return &memoryCheckpoints{runs: make(map[string]map[string]int64)}
+}
+
+type memoryCheckpoints struct {
+	lock sync.Mutex
+	runs map[string]map[string]int64
+}
+
+func (m *memoryCheckpoints) Load(runID string) (map[string]int64, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	res := make(map[string]int64, len(m.runs[runID]))
+	for table, n := range m.runs[runID] {
+		res[table] = n
+	}
+
+	return res, nil
+}
+
+func (m *memoryCheckpoints) Save(runID string, tables map[string]int64) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	run, ok := m.runs[runID]
+	if !ok {
+		run = make(map[string]int64, len(tables))
+		m.runs[runID] = run
+	}
+	for table, n := range tables {
+		run[table] = n
+	}
+
+	return nil
+}
+
+// NewBadgerCheckpoints returns a checkpoint store that keeps the progress in a badger database
+// so that it survives server restarts
+func NewBadgerCheckpoints(db *badger.DB) CheckpointStore {
+	return &badgerCheckpoints{db}
+}
+
+type badgerCheckpoints struct {
+	db *badger.DB
+}
+
+// checkpointPrefix is the prefix of the keys of the run, the length of the ID keeps run "a" with table "b/c" apart
+// from run "a/b" with table "c"
+func checkpointPrefix(runID string) []byte {
+	return []byte(fmt.Sprintf("checkpoint/%d:%s/", len(runID), runID))
+}
+
+func (b *badgerCheckpoints) Load(runID string) (map[string]int64, error) {
+	res := make(map[string]int64)
+	prefix := checkpointPrefix(runID)
+
+	err := b.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			v, err := it.Item().Value()
+			if err != nil {
+				return err
+			}
+
+			table := string(bytes.TrimPrefix(it.Item().Key(), prefix))
+			res[table] = int64(binary.BigEndian.Uint64(v))
+		}
+
+		return nil
+	})
+
+	return res, err
+}
+
+func (b *badgerCheckpoints) Save(runID string, tables map[string]int64) error {
+	prefix := checkpointPrefix(runID)
+
+	return b.db.Update(func(txn *badger.Txn) error {
+		for table, n := range tables {
+			v := make([]byte, 8)
+			binary.BigEndian.PutUint64(v, uint64(n))
+			if err := txn.Set(append(append([]byte{}, prefix...), table...), v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// runProgress tracks the batches inserted by the open transaction of a run
+// and moves them to the checkpoint store once it commits
+type runProgress struct {
+	lock      sync.Mutex
+	store     CheckpointStore
+	runID     string
+	committed map[string]int64
+	pending   map[string]int64
+	// Rows are only counted for the current attempt of the run
+	committedRows map[string]int64
+	pendingRows   map[string]int64
+}
+
+func newRunProgress(store CheckpointStore, runID string) (*runProgress, error) {
+	p := &runProgress{
+		store:         store,
+		runID:         runID,
+		committed:     make(map[string]int64),
+		pending:       make(map[string]int64),
+		committedRows: make(map[string]int64),
+		pendingRows:   make(map[string]int64),
+	}
+
+	// Without a run ID there is nothing to resume
+	if store == nil || runID == "" {
+		return p, nil
+	}
+
+	var err error
+	p.committed, err = store.Load(runID)
+
+	return p, err
+}
+
+// inserted records a batch of the table as inserted but not yet committed
+func (p *runProgress) inserted(table string, sequence, rows int64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if sequence > p.pending[table] {
+		p.pending[table] = sequence
+	}
+	p.pendingRows[table] += rows
+}
+
+// commit is called after the transaction holding the pending batches has committed,
+// it returns the progress of the run to be reported to the client
+func (p *runProgress) commit() (*pb.Commit, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	changed := make(map[string]int64, len(p.pending))
+	for table, n := range p.pending {
+		if n > p.committed[table] {
+			p.committed[table] = n
+			changed[table] = n
+		}
+	}
+	for table, n := range p.pendingRows {
+		p.committedRows[table] += n
+	}
+	p.pending = make(map[string]int64)
+	p.pendingRows = make(map[string]int64)
+
+	res := &pb.Commit{Batches: make(map[string]int64, len(p.committed)), Rows: make(map[string]int64, len(p.committedRows))}
+	for table, n := range p.committed {
+		res.Batches[table] = n
+	}
+	for table, n := range p.committedRows {
+		res.Rows[table] = n
+	}
+
+	if p.store == nil || p.runID == "" || len(changed) == 0 {
+		return res, nil
+	}
+
+	return res, p.store.Save(p.runID, changed)
+}
+
+// rollback forgets the batches of a transaction that was rolled back
+func (p *runProgress) rollback() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.pending = make(map[string]int64)
+	p.pendingRows = make(map[string]int64)
+}
+
+// checkpoint returns the committed progress of the run
+func (p *runProgress) checkpoint() *pb.Checkpoint {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	tables := make(map[string]int64, len(p.committed))
+	for table, n := range p.committed {
+		tables[table] = n
+	}
+
+	return &pb.Checkpoint{RunId: p.runID, Tables: tables}
+}
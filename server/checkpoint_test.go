@@ -0,0 +1,113 @@
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/dgraph-io/badger"
+)
+
+func TestCheckpointStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoints")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	opts := badger.DefaultOptions
+	opts.Dir = dir
+	opts.ValueDir = dir
+	db, err := badger.Open(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	stores := map[string]CheckpointStore{
+		"memory": NewMemoryCheckpoints(),
+		"badger": NewBadgerCheckpoints(db),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			if err := store.Save("run-1", map[string]int64{"Users": 3, "Orders": 1}); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Save("run-1", map[string]int64{"Orders": 7}); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Save("run-10", map[string]int64{"Users": 100}); err != nil {
+				t.Fatal(err)
+			}
+			// Run IDs and table names may contain the separator of the keys
+			if err := store.Save("run-1/dbo", map[string]int64{"Users": 9}); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Save("run-1", map[string]int64{"dbo/Users": 5}); err != nil {
+				t.Fatal(err)
+			}
+			got, err := store.Load("run-1/dbo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exp := map[string]int64{"Users": 9}; !reflect.DeepEqual(got, exp) {
+				t.Errorf("expected: %v, got: %v", exp, got)
+			}
+
+			got, err = store.Load("run-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exp := map[string]int64{"Users": 3, "Orders": 7, "dbo/Users": 5}; !reflect.DeepEqual(got, exp) {
+				t.Errorf("expected: %v, got: %v", exp, got)
+			}
+
+			got, err = store.Load("unknown")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 0 {
+				t.Errorf("expected no progress for an unknown run, got: %v", got)
+			}
+		})
+	}
+}
+
+func TestRunProgress(t *testing.T) {
+	store := NewMemoryCheckpoints()
+	if err := store.Save("run", map[string]int64{"Users": 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := newRunProgress(store, "run")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.inserted("Users", 4, 100)
+	p.inserted("Users", 3, 100)
+	p.inserted("Orders", 1, 10)
+
+	// Nothing is saved before the commit
+	if got, _ := store.Load("run"); got["Users"] != 2 || got["Orders"] != 0 {
+		t.Errorf("uncommitted progress saved: %v", got)
+	}
+
+	commit, err := p.commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := map[string]int64{"Users": 200, "Orders": 10}; !reflect.DeepEqual(commit.Rows, exp) {
+		t.Errorf("expected committed rows: %v, got: %v", exp, commit.Rows)
+	}
+
+	exp := map[string]int64{"Users": 4, "Orders": 1}
+	if got, _ := store.Load("run"); !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected: %v, got: %v", exp, got)
+	}
+	if got := p.checkpoint().Tables; !reflect.DeepEqual(got, exp) {
+		t.Errorf("expected: %v, got: %v", exp, got)
+	}
+}
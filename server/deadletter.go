@@ -0,0 +1,233 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	flock "github.com/srikrsna/flock/pkg"
+	pb "github.com/srikrsna/flock/protos"
+)
+
+// deadLetter is a row rejected by a run along with the reason
+type deadLetter struct {
+	RunID  string                 `json:"run_id"`
+	Table  string                 `json:"table"`
+	Column string                 `json:"column,omitempty"`
+	Row    map[string]interface{} `json:"row"`
+	Error  string                 `json:"error"`
+}
+
+// deadLetterSink stores the rows rejected by a run
+type deadLetterSink interface {
+	// write stores the rows, tx is the transaction of the batch they belong to
+	write(ctx context.Context, tx *sql.Tx, letters []*deadLetter) error
+	// commit keeps the rows written since the last commit, once their transaction has committed
+	commit() error
+	// rollback drops the rows written since the last commit
+	rollback()
+	close() error
+}
+
+// tableSink inserts the rejected rows into a table of the destination database.
+// They are part of the transaction of their batch, so they are only kept when the batch commits
+type tableSink struct {
+	table   string
+	dialect flock.Dialect
+}
+
+var deadLetterColumns = []string{"run_id", "table_name", "column_name", "row_data", "error"}
+
+func (s *tableSink) write(ctx context.Context, tx *sql.Tx, letters []*deadLetter) error {
+	values := make([][]interface{}, 0, len(letters))
+	for _, l := range letters {
+		row, err := json.Marshal(l.Row)
+		if err != nil {
+			return err
+		}
+		values = append(values, []interface{}{l.RunID, l.Table, l.Column, string(row), l.Error})
+	}
+
+	stmts, err := s.dialect.Insert(s.table, deadLetterColumns, values, flock.Conflict{Action: flock.ConflictFail})
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		query, args, err := stmt.ToSql()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to store rejected rows in %s: %v", s.table, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *tableSink) commit() error {
+	return nil
+}
+
+func (s *tableSink) rollback() {}
+
+func (s *tableSink) close() error {
+	return nil
+}
+
+// fileSink appends the rejected rows to an NDJSON file once the transaction of their batch commits, the rows of
+// a batch rolled back never reach the file
+type fileSink struct {
+	lock    sync.Mutex
+	f       *os.File
+	enc     *json.Encoder
+	pending []*deadLetter
+}
+
+// newFileSink opens the file of the run in the directory
+func newFileSink(dir, runID string) (*fileSink, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("the server has no dead letter directory")
+	}
+	if runID == "" || runID != filepath.Base(runID) || runID == "." || runID == ".." {
+		return nil, fmt.Errorf("invalid run id for a dead letter file: %q", runID)
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, runID+".ndjson"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileSink{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (s *fileSink) write(ctx context.Context, tx *sql.Tx, letters []*deadLetter) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.pending = append(s.pending, letters...)
+	return nil
+}
+
+func (s *fileSink) commit() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	pending := s.pending
+	s.pending = nil
+	for _, l := range pending {
+		if err := s.enc.Encode(l); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *fileSink) rollback() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.pending = nil
+}
+
+func (s *fileSink) close() error {
+	return s.f.Close()
+}
+
+// deadLetters counts the rows rejected by a run against the budgets of the run and of its entries.
+// The rows of a transaction rolled back are taken off the counts
+type deadLetters struct {
+	lock     sync.Mutex
+	sink     deadLetterSink
+	runID    string
+	max      int64
+	rejected int64
+	tables   map[string]int64
+	// committed are the counts as of the last commit
+	committed       int64
+	committedTables map[string]int64
+}
+
+// newDeadLetters returns nil when rejected rows must fail the run
+func newDeadLetters(config *pb.DeadLetter, dir, runID string, dialect flock.Dialect) (*deadLetters, error) {
+	var sink deadLetterSink
+	switch config.GetSink() {
+	case pb.DeadLetter_NONE:
+		return nil, nil
+	case pb.DeadLetter_TABLE:
+		if config.Table == "" {
+			return nil, fmt.Errorf("the table sink needs a table")
+		}
+		sink = &tableSink{config.Table, dialect}
+	case pb.DeadLetter_FILE:
+		f, err := newFileSink(dir, runID)
+		if err != nil {
+			return nil, err
+		}
+		sink = f
+	default:
+		return nil, fmt.Errorf("unknown dead letter sink: %v", config.Sink)
+	}
+
+	return &deadLetters{sink: sink, runID: runID, max: config.MaxErrors, tables: make(map[string]int64), committedTables: make(map[string]int64)}, nil
+}
+
+// reject stores the rows rejected from the table, failing once a budget is exceeded
+func (d *deadLetters) reject(ctx context.Context, tx *sql.Tx, table flock.Table, letters []*deadLetter) error {
+	if len(letters) == 0 {
+		return nil
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.rejected += int64(len(letters))
+	d.tables[table.Name] += int64(len(letters))
+	if d.max > 0 && d.rejected > d.max {
+		return &batchError{pb.BatchError_BUDGET, -1, "", fmt.Errorf("the run rejected %d rows, more than its budget of %d", d.rejected, d.max)}
+	}
+	if table.MaxErrors != nil && d.tables[table.Name] > *table.MaxErrors {
+		return &batchError{pb.BatchError_BUDGET, -1, "", fmt.Errorf("%s rejected %d rows, more than its budget of %d", table.Name, d.tables[table.Name], *table.MaxErrors)}
+	}
+
+	for _, l := range letters {
+		l.RunID = d.runID
+	}
+
+	return d.sink.write(ctx, tx, letters)
+}
+
+// commit keeps the rows rejected since the last commit, once their transaction has committed
+func (d *deadLetters) commit() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.committed = d.rejected
+	for table, n := range d.tables {
+		d.committedTables[table] = n
+	}
+
+	return d.sink.commit()
+}
+
+// rollback drops the rows rejected since the last commit
+func (d *deadLetters) rollback() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.rejected = d.committed
+	d.tables = make(map[string]int64, len(d.committedTables))
+	for table, n := range d.committedTables {
+		d.tables[table] = n
+	}
+	d.sink.rollback()
+}
+
+func (d *deadLetters) close() error {
+	return d.sink.close()
+}
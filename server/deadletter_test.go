@@ -0,0 +1,181 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	flock "github.com/srikrsna/flock/pkg"
+	pb "github.com/srikrsna/flock/protos"
+)
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deadletters")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, runID := range []string{"", "../run", "."} {
+		if _, err := newFileSink(dir, runID); err == nil {
+			t.Errorf("expected an error for the run id %q", runID)
+		}
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	d, err := newDeadLetters(&pb.DeadLetter{Sink: pb.DeadLetter_FILE}, dir, "run", flock.Postgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	progress, err := newRunProgress(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	txs := newScopedTx(db, pb.TransactionScope_RUN, 0, progress)
+	txs.letters = d
+
+	// The letters of the committed batch are written, the ones of the batch rolled back are dropped
+	batches := [][]*deadLetter{
+		{
+			{Table: "Users", Column: "id", Row: map[string]interface{}{"ID": "one"}, Error: "not a number"},
+			{Table: "Users", Row: map[string]interface{}{"ID": "2"}, Error: "duplicate key"},
+		},
+		{
+			{Table: "Users", Row: map[string]interface{}{"ID": "3"}, Error: "duplicate key"},
+		},
+	}
+	for i, letters := range batches {
+		if _, err := txs.exec(context.Background(), &pb.BatchInsertHead{TableName: "Users"}, func(tx *sql.Tx) (int, error) {
+			return 0, d.reject(context.Background(), tx, flock.Table{Name: "Users"}, letters)
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			if _, err := txs.commit(); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			txs.rollback()
+		}
+	}
+	if d.rejected != 2 || d.tables["Users"] != 2 {
+		t.Errorf("expected the rolled back letters off the budgets, got %d rejected, %d for Users", d.rejected, d.tables["Users"])
+	}
+	if err := d.close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "run.ndjson"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var got []deadLetter
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var l deadLetter
+		if err := json.Unmarshal(sc.Bytes(), &l); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, l)
+	}
+	if len(got) != 2 || got[0].RunID != "run" || got[0].Column != "id" || got[1].Row["ID"] != "2" {
+		t.Errorf("unexpected dead letters: %v", got)
+	}
+}
+
+func TestTableSink(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "rejected" \("run_id","table_name","column_name","row_data","error"\)`).
+		WithArgs("run", "Users", "id", `{"ID":"one"}`, "not a number").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := newDeadLetters(&pb.DeadLetter{Sink: pb.DeadLetter_TABLE, Table: "rejected"}, "", "run", flock.Postgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	letters := []*deadLetter{{Table: "Users", Column: "id", Row: map[string]interface{}{"ID": "one"}, Error: "not a number"}}
+	if err := d.reject(context.Background(), tx, flock.Table{Name: "Users"}, letters); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+type discardSink struct{}
+
+func (discardSink) write(context.Context, *sql.Tx, []*deadLetter) error { return nil }
+func (discardSink) commit() error                                       { return nil }
+func (discardSink) rollback()                                           {}
+func (discardSink) close() error                                        { return nil }
+
+func TestDeadLetterBudget(t *testing.T) {
+	one := int64(1)
+	tests := []struct {
+		name    string
+		max     int64
+		table   flock.Table
+		rows    []int
+		wantErr bool
+	}{
+		{"NoLimit", 0, flock.Table{Name: "Users"}, []int{5, 5}, false},
+		{"Run", 3, flock.Table{Name: "Users"}, []int{2, 2}, true},
+		{"Entry", 0, flock.Table{Name: "Users", MaxErrors: &one}, []int{1, 1}, true},
+		{"WithinBudgets", 4, flock.Table{Name: "Users", MaxErrors: &one}, []int{1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &deadLetters{sink: discardSink{}, runID: "run", max: tt.max, tables: make(map[string]int64)}
+
+			var err error
+			for _, n := range tt.rows {
+				letters := make([]*deadLetter, n)
+				for i := range letters {
+					letters[i] = &deadLetter{Table: tt.table.Name}
+				}
+				if err = d.reject(context.Background(), nil, tt.table, letters); err != nil {
+					break
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("reject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if berr, ok := err.(*batchError); tt.wantErr && (!ok || berr.code != pb.BatchError_BUDGET) {
+				t.Errorf("expected a budget error, got: %v", err)
+			}
+		})
+	}
+}
@@ -1,8 +1,17 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/gob"
 	"io/ioutil"
+	"sort"
 	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	flock "github.com/srikrsna/flock/pkg"
+	pb "github.com/srikrsna/flock/protos"
 )
 
 func TestGenerateBase(t *testing.T) {
@@ -15,3 +24,77 @@ func TestGenerateBase(t *testing.T) {
 		t.Errorf("failed to write to file")
 	}
 }
+
+func TestHandleVerification(t *testing.T) {
+	users := func(rows, inserted int64) *destination { return &destination{"Users", "Users", rows, inserted} }
+	tests := []struct {
+		name         string
+		records      map[string]int
+		destinations map[string]*destination
+		// counts are the rows of the tables before the run and counted the rows counted before the commit
+		counts  map[string]*tableCount
+		counted map[string]int64
+		wantErr bool
+	}{
+		{"Match", map[string]int{"Users": 3, "Roles": 0}, map[string]*destination{"Users": users(3, 3)},
+			map[string]*tableCount{"Users": {2, true}}, map[string]int64{"Users": 5}, false},
+		{"Missing", map[string]int{"Users": 3}, map[string]*destination{"Users": users(2, 2)}, nil, nil, true},
+		{"Unknown", map[string]int{"Users": 3}, map[string]*destination{"Users": users(3, 3), "Roles": {"Roles", "Roles", 1, 1}}, nil, nil, true},
+		{"NotWritten", map[string]int{"Users": 3, "Roles": 1}, map[string]*destination{"Users": users(3, 3)}, nil, nil, true},
+		{"Targets", map[string]int{"Users": 3}, map[string]*destination{"Users.users": {"Users", "users", 3, 3}, "Users.profiles": {"Users", "profiles", 3, 1}},
+			map[string]*tableCount{"profiles": {0, true}, "users": {0, true}}, map[string]int64{"profiles": 1, "users": 3}, false},
+		{"TargetMissing", map[string]int{"Users": 3}, map[string]*destination{"Users.users": {"Users", "users", 3, 3}, "Users.profiles": {"Users", "profiles", 1, 1}}, nil, nil, true},
+		{"LostInsert", map[string]int{"Users": 3}, map[string]*destination{"Users": users(3, 3)},
+			map[string]*tableCount{"Users": {2, true}}, map[string]int64{"Users": 4}, true},
+		{"Updated", map[string]int{"Users": 3}, map[string]*destination{"Users": users(3, 3)},
+			map[string]*tableCount{"Users": {2, false}}, map[string]int64{"Users": 4}, false},
+		{"UpdatedLost", map[string]int{"Users": 3}, map[string]*destination{"Users": users(3, 3)},
+			map[string]*tableCount{"Users": {2, false}}, map[string]int64{"Users": 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			// The tables are counted in the open transaction
+			mock.ExpectBegin()
+			tables := make([]string, 0, len(tt.counted))
+			for table := range tt.counted {
+				tables = append(tables, table)
+			}
+			sort.Strings(tables)
+			for _, table := range tables {
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM "?` + table).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.counted[table]))
+			}
+
+			progress, err := newRunProgress(nil, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			sess := &session{dialect: flock.Postgres, counts: tt.counts, txs: newScopedTx(db, pb.TransactionScope_RUN, 0, progress)}
+			if _, err := sess.txs.exec(context.Background(), &pb.BatchInsertHead{TableName: "Users"}, func(*sql.Tx) (int, error) { return 0, nil }); err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(tt.records); err != nil {
+				t.Fatal(err)
+			}
+			ok, err := handleVerification(context.Background(), sess, tt.destinations, buf.Bytes())
+			if !ok || (err != nil) != tt.wantErr {
+				t.Errorf("handleVerification() = %v, %v, wantErr %v", ok, err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	if ok, err := handleVerification(context.Background(), &session{}, nil, []byte("x")); ok || err == nil {
+		t.Errorf("expected a decoding error, got: %v, %v", ok, err)
+	}
+}
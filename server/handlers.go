@@ -4,36 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/gob"
-	"errors"
 	"fmt"
-
-	"github.com/elgris/sqrl"
-	flock "github.com/srikrsna/flock/pkg"
-	pb "github.com/srikrsna/flock/protos"
+	"sort"
 )
 
-func handleBatch(ctx context.Context, db sqrl.ExecerContext, tables map[string]flock.Table, req *pb.BatchInsertHead, data []byte, format sqrl.PlaceholderFormat, params map[string]map[string]flock.Variable) (*pb.BatchInsertResponse, error) {
-	var rows []map[string]interface{}
-
-	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&rows); err != nil {
-		return nil, err
-	}
-
-	table, ok := tables[req.GetTableName()]
-	if !ok {
-		return nil, errors.New("table not configured")
-	}
-	var varFields map[string]flock.Variable
-	if _, ok := params[req.GetTableName()]; ok {
-		varFields = params[req.GetTableName()]
-	}
-	if err := flock.InsertBulk(ctx, db, rows, table, req.GetTableName(), format, varFields); err != nil {
-		return nil, fmt.Errorf("failed to insert chunk. Table: %s\nData: %v\nError: %v", req.GetTableName(), rows, err)
-	}
-
-	return &pb.BatchInsertResponse{Success: true}, nil
-}
-
 func generateBase(info map[string]([]string)) ([]byte, error) {
 
 	var buf bytes.Buffer
@@ -55,18 +29,54 @@ func generateBase(info map[string]([]string)) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func handleVerification(db DB, tables map[string]flock.Table, recordsEnc []byte) (bool, error) {
+// handleVerification compares the number of source rows read by the client for every entry with the number handled
+// by every table the entry writes to, whatever became of them. It then counts the rows of every destination table in
+// the open transaction, they must be the rows of the table before the run and the rows inserted by the run. The
+// bool is false when the records of the client can't be decoded or the tables can't be counted
+func handleVerification(ctx context.Context, sess *session, destinations map[string]*destination, recordsEnc []byte) (bool, error) {
 	var records = make(map[string]int)
 	if err := gob.NewDecoder(bytes.NewReader(recordsEnc)).Decode(&records); err != nil {
 		return false, err
 	}
-	for table := range tables {
-		var count int
-		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
-			return false, err
+	keys := make([]string, 0, len(destinations))
+	written := make(map[string]bool, len(records))
+	inserted := make(map[string]int64)
+	for key, d := range destinations {
+		keys = append(keys, key)
+		written[d.entry] = true
+		inserted[d.table] += d.inserted
+	}
+	for entry, n := range records {
+		if !written[entry] && n != 0 {
+			keys = append(keys, entry)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		d, ok := destinations[key]
+		if !ok {
+			return true, fmt.Errorf("%s: expected: %v, got: 0", key, records[key])
+		}
+		if int64(records[d.entry]) != d.rows {
+			return true, fmt.Errorf("%s: expected: %v, got: %v", key, records[d.entry], d.rows)
+		}
+	}
+
+	tables := make([]string, 0, len(sess.counts))
+	for table := range sess.counts {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		c := sess.counts[table]
+		var count int64
+		if err := sess.txs.queryRow(ctx, sess.dialect.CountQuery(table)).Scan(&count); err != nil {
+			return false, fmt.Errorf("%s: %v", table, err)
 		}
-		if records[table] != count {
-			return true, fmt.Errorf("expected: %v, got: %v", records[table], count)
+		// Rows updated or replaced are reported inserted without adding to the table
+		want := c.before + inserted[table]
+		if count != want && (c.exact || count < c.before || count > want) {
+			return true, fmt.Errorf("%s: expected: %v rows, got: %v", table, want, count)
 		}
 	}
 	return true, nil
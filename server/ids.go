@@ -0,0 +1,138 @@
+package server
+
+import (
+	"io"
+
+	flock "github.com/srikrsna/flock/pkg"
+	pb "github.com/srikrsna/flock/protos"
+	flockSQL "github.com/srikrsna/flock/sql"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// idPageSize is the number of mappings sent in a message by ExportIDs
+const idPageSize = 1000
+
+// ExportIDs streams the mappings of the table in pages
+func (s *Server) ExportIDs(req *pb.IDsRequest, srv pb.Flock_ExportIDsServer) error {
+	ids, close, err := s.idStore(req)
+	if err != nil {
+		s.Logger.Error("failed to open the ID mapper", zap.String("error", err.Error()))
+		return err
+	}
+	defer close()
+
+	page := &pb.IDMappings{}
+	err = ids.Export(req.Table, func(m flock.IDMapping) error {
+		page.Mappings = append(page.Mappings, &pb.IDMapping{OldId: m.Old, NewId: m.New})
+		if len(page.Mappings) < idPageSize {
+			return nil
+		}
+		err := srv.Send(page)
+		page = &pb.IDMappings{}
+		return err
+	})
+	if err == nil && len(page.Mappings) > 0 {
+		err = srv.Send(page)
+	}
+	if err != nil {
+		s.Logger.Error("failed to export IDs", zap.String("table", req.Table), zap.String("error", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// ImportIDs loads the mappings of the stream, the first message names the table
+func (s *Server) ImportIDs(srv pb.Flock_ImportIDsServer) error {
+	req, err := srv.Recv()
+	if err != nil {
+		return err
+	}
+	if req.Table == nil {
+		return status.Errorf(codes.InvalidArgument, "the first message must name the table")
+	}
+
+	ids, close, err := s.idStore(req.Table)
+	if err != nil {
+		s.Logger.Error("failed to open the ID mapper", zap.String("error", err.Error()))
+		return err
+	}
+	defer close()
+
+	res := &pb.ImportIDsResponse{}
+	for {
+		mappings := make([]flock.IDMapping, len(req.Mappings))
+		for i, m := range req.Mappings {
+			mappings[i] = flock.IDMapping{Old: m.OldId, New: m.NewId}
+		}
+		res.Received += int64(len(mappings))
+
+		n, err := ids.Import(req.Table.Table, mappings)
+		res.Imported += n
+		if err != nil {
+			s.Logger.Error("failed to import IDs", zap.String("table", req.Table.Table), zap.String("error", err.Error()))
+			return status.Errorf(codes.InvalidArgument, "%v, %d of %d mappings imported", err, res.Imported, res.Received)
+		}
+
+		next, err := srv.Recv()
+		if err == io.EOF {
+			return srv.SendAndClose(res)
+		}
+		if err != nil {
+			return err
+		}
+		next.Table = req.Table
+		req = next
+	}
+}
+
+// idStore returns the ID mapper of the runs for the request, connecting to the destination when it is given
+func (s *Server) idStore(req *pb.IDsRequest) (flock.IDStore, func(), error) {
+	if req.Table == "" {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "missing table")
+	}
+	if s.IDs == nil {
+		return idStoreOf(flock.DefaultIDs(), func() {})
+	}
+
+	var (
+		db      DB
+		dialect flock.Dialect
+		close   = func() {}
+	)
+	if req.Url != "" {
+		name := req.Dialect
+		if name == "" {
+			name = req.Database
+		}
+		d, err := flock.GetDialect(name)
+		if err != nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		conn, err := flockSQL.ConnectDB(req.Url, req.Database)
+		if err != nil {
+			return nil, nil, err
+		}
+		db, dialect, close = conn, d, func() { conn.Close() }
+	}
+
+	m, err := s.IDs(db, dialect)
+	if err != nil {
+		close()
+		return nil, nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+	}
+
+	return idStoreOf(m, close)
+}
+
+func idStoreOf(m flock.IDMapper, close func()) (flock.IDStore, func(), error) {
+	ids, ok := m.(flock.IDStore)
+	if !ok {
+		close()
+		return nil, nil, status.Errorf(codes.FailedPrecondition, "the ID mapper of the server doesn't keep its mappings")
+	}
+
+	return ids, close, nil
+}
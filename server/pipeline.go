@@ -0,0 +1,487 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	flock "github.com/srikrsna/flock/pkg"
+	pb "github.com/srikrsna/flock/protos"
+	"go.uber.org/zap"
+)
+
+// receivedBatch is a batch of rows whose chunks have been received, index is the order in which its head arrived
+type receivedBatch struct {
+	index  int64
+	head   *pb.BatchInsertHead
+	chunks []*pb.DataStream
+}
+
+// preparedBatch holds the values of a batch calculated by a worker
+type preparedBatch struct {
+	*receivedBatch
+	received int
+	// targets are the tables the entry of the batch writes to, in order
+	targets []*preparedTarget
+	err     error
+}
+
+// preparedTarget holds the values of a batch for one of the tables its entry writes to
+type preparedTarget struct {
+	// key names the table among the destinations of the run
+	key   string
+	table flock.Table
+	// received is the number of source rows handled by the table
+	received int
+	// rows are the source rows of the values
+	rows     []map[string]interface{}
+	values   [][]interface{}
+	rejected []*deadLetter
+	// filtered is the number of rows left out by the where clause of the table
+	filtered int
+	// failed is the number of values rejected by the insert
+	failed int
+	// inserted is the number of rows the database reported inserted
+	inserted int64
+}
+
+// destination counts the source rows of an entry handled by one of the tables it writes to and the rows inserted
+// into the table
+type destination struct {
+	entry    string
+	table    string
+	rows     int64
+	inserted int64
+}
+
+// batchError is the failure of a batch along with the details reported to the client
+type batchError struct {
+	code   pb.BatchError_Code
+	row    int
+	column string
+	err    error
+}
+
+func (e *batchError) Error() string {
+	return e.err.Error()
+}
+
+// newBatchError wraps err with its code, the row and the column are taken from row errors
+func newBatchError(code pb.BatchError_Code, err error) *batchError {
+	if rerr, ok := err.(*flock.RowError); ok {
+		return &batchError{code, rerr.Row, rerr.Column, err}
+	}
+
+	return &batchError{code, -1, "", err}
+}
+
+// pipeline inserts the batches of a run. Workers decode the batches and calculate their values
+// concurrently while a single writer inserts them in the order they were received,
+// and a single sender writes the responses to the stream
+type pipeline struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	logger  Logger
+	session *session
+
+	// slots bounds the number of batches held in memory, from their head until they are inserted
+	slots    chan struct{}
+	jobs     chan *receivedBatch
+	prepared chan *preparedBatch
+	out      chan *pb.FlockResponse
+
+	workers sync.WaitGroup
+	written chan struct{}
+	sent    chan struct{}
+
+	once sync.Once
+	err  error
+
+	// destinations are the tables written to by key, they are only written by the writer
+	destinations map[string]*destination
+}
+
+func newPipeline(ctx context.Context, logger Logger, workers, pending int, sess *session) *pipeline {
+	if workers < 1 {
+		workers = 1
+	}
+	if pending < workers {
+		pending = workers
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p := &pipeline{
+		ctx:          ctx,
+		cancel:       cancel,
+		logger:       logger,
+		session:      sess,
+		slots:        make(chan struct{}, pending),
+		jobs:         make(chan *receivedBatch, workers),
+		prepared:     make(chan *preparedBatch, workers),
+		out:          make(chan *pb.FlockResponse, workers),
+		written:      make(chan struct{}),
+		sent:         make(chan struct{}),
+		destinations: make(map[string]*destination),
+	}
+
+	p.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	go func() {
+		p.workers.Wait()
+		close(p.prepared)
+	}()
+	go p.write()
+
+	return p
+}
+
+// fail stops the pipeline, only the first error is kept
+func (p *pipeline) fail(err error) {
+	p.once.Do(func() {
+		p.err = err
+		p.cancel()
+	})
+}
+
+// done is closed once the pipeline has failed or been stopped
+func (p *pipeline) done() <-chan struct{} {
+	return p.ctx.Done()
+}
+
+// failure returns the error that stopped the pipeline
+func (p *pipeline) failure() error {
+	<-p.ctx.Done()
+	if p.err != nil {
+		return p.err
+	}
+
+	return p.ctx.Err()
+}
+
+// reserve waits for room to hold another batch in memory
+func (p *pipeline) reserve() error {
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-p.ctx.Done():
+		return p.failure()
+	}
+}
+
+// submit hands a batch whose chunks have all been received to the workers
+func (p *pipeline) submit(b *receivedBatch) error {
+	select {
+	case p.jobs <- b:
+		return nil
+	case <-p.ctx.Done():
+		return p.failure()
+	}
+}
+
+// send queues a response for the sender
+func (p *pipeline) send(res *pb.FlockResponse) error {
+	select {
+	case p.out <- res:
+		return nil
+	case <-p.ctx.Done():
+		return p.failure()
+	}
+}
+
+// serve writes the queued responses to the stream, it must be the only caller of send on the stream
+func (p *pipeline) serve(send func(*pb.FlockResponse) error) {
+	defer close(p.sent)
+
+	for {
+		select {
+		case res, ok := <-p.out:
+			if !ok {
+				return
+			}
+			if err := send(res); err != nil {
+				p.logger.Error("unable to send response", zap.String("error", err.Error()))
+				p.fail(err)
+				return
+			}
+		case <-p.ctx.Done():
+			// The responses already queued, such as the one of a failed batch, are still sent
+			for {
+				select {
+				case res, ok := <-p.out:
+					if !ok || send(res) != nil {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// drain waits for the received batches to be inserted
+func (p *pipeline) drain() error {
+	close(p.jobs)
+
+	select {
+	case <-p.written:
+	case <-p.ctx.Done():
+	}
+	if p.ctx.Err() != nil {
+		return p.failure()
+	}
+
+	return nil
+}
+
+// close waits for the queued responses to be sent and stops the pipeline
+func (p *pipeline) close() error {
+	close(p.out)
+	<-p.sent
+	err := p.ctx.Err()
+	if err != nil {
+		err = p.failure()
+	}
+	p.cancel()
+
+	return err
+}
+
+func (p *pipeline) work() {
+	defer p.workers.Done()
+
+	for {
+		select {
+		case b, ok := <-p.jobs:
+			if !ok {
+				return
+			}
+
+			select {
+			case p.prepared <- p.prepare(b):
+			case <-p.ctx.Done():
+				return
+			}
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// prepare decodes the batch and calculates its values, leaving out the rows failing the where clause of the table.
+// With a dead letter sink, the rows whose functions fail are rejected instead of failing the batch
+func (p *pipeline) prepare(b *receivedBatch) *preparedBatch {
+	res := &preparedBatch{receivedBatch: b}
+
+	table, ok := p.session.tables[b.head.TableName]
+	if !ok {
+		res.err = newBatchError(pb.BatchError_TABLE, errors.New("table not configured"))
+		return res
+	}
+
+	sort.SliceStable(b.chunks, func(i, j int) bool {
+		return b.chunks[i].Index < b.chunks[j].Index
+	})
+	var data = make([]byte, 0)
+	for _, v := range b.chunks {
+		data = append(data, v.GetData()...)
+	}
+
+	// Older clients send gob encoded rows
+	rows, err := flock.DecodeRows(b.head.Encoding, data)
+	if err != nil {
+		res.err = newBatchError(pb.BatchError_DECODE, err)
+		return res
+	}
+	res.received = len(rows)
+
+	for _, t := range table.Tables() {
+		key := b.head.TableName
+		if len(table.Targets) > 0 {
+			key = flock.TargetKey(b.head.TableName, t.Name)
+		}
+		varFields := p.session.params[key]
+
+		target := &preparedTarget{
+			key:    key,
+			table:  t,
+			rows:   make([]map[string]interface{}, 0, len(rows)),
+			values: make([][]interface{}, 0, len(rows)),
+		}
+		for i, row := range rows {
+			target.received++
+			values, filtered, err := flock.PrepareRow(row, t, p.session.funcs, varFields)
+			if err != nil {
+				rerr, ok := err.(*flock.RowError)
+				if ok {
+					rerr.Row = i
+				}
+				if p.session.deadLetters == nil {
+					res.err = newBatchError(pb.BatchError_FUNCTION, err)
+					return res
+				}
+
+				letter := &deadLetter{Table: t.Name, Row: row, Error: err.Error()}
+				if ok {
+					letter.Column = rerr.Column
+					letter.Error = rerr.Err.Error()
+				}
+				target.rejected = append(target.rejected, letter)
+				continue
+			}
+			target.filtered += filtered
+			// A row exploded into several values is the source row of each of them
+			for _, v := range values {
+				target.rows = append(target.rows, row)
+				target.values = append(target.values, v)
+			}
+		}
+		res.targets = append(res.targets, target)
+	}
+
+	return res
+}
+
+// write inserts the prepared batches in the order their heads were received
+func (p *pipeline) write() {
+	defer close(p.written)
+
+	next := int64(0)
+	waiting := make(map[int64]*preparedBatch)
+	for {
+		select {
+		case b, ok := <-p.prepared:
+			if !ok {
+				if len(waiting) > 0 {
+					p.fail(fmt.Errorf("%d batches were not completely received", len(waiting)))
+				}
+				return
+			}
+
+			waiting[b.index] = b
+			for b, ok := waiting[next]; ok; b, ok = waiting[next] {
+				delete(waiting, next)
+				next++
+
+				if err := p.insert(b); err != nil {
+					p.logger.Error("unable to handle batch insert request", zap.String("error", err.Error()))
+					p.fail(err)
+					return
+				}
+				<-p.slots
+			}
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// insert inserts the batch into the tables of its entry, in order and in the same transaction, and sends its result.
+// A failed batch is reported to the client before the error is returned
+func (p *pipeline) insert(b *preparedBatch) error {
+	res := &pb.BatchInsertResponse{
+		BatchId:      b.head.BatchId,
+		TableName:    b.head.TableName,
+		RowsReceived: int64(b.received),
+	}
+
+	var inserted int64
+	var commits []*pb.Commit
+	err := b.err
+	if err == nil {
+		commits, err = p.session.txs.exec(p.ctx, b.head, func(tx *sql.Tx) (int, error) {
+			// The IDs mapped while preparing the batch are written before the rows using them
+			if p.session.ids != nil {
+				if err := p.session.ids.Flush(p.ctx, tx); err != nil {
+					return 0, newBatchError(pb.BatchError_INSERT, err)
+				}
+			}
+
+			written := 0
+			for _, t := range b.targets {
+				n, err := p.insertTarget(tx, b.head, t)
+				if err != nil {
+					return 0, err
+				}
+				t.inserted = n
+				inserted += n
+				written += len(t.values) - t.failed
+			}
+
+			return written, nil
+		})
+	}
+	if err != nil {
+		berr, ok := err.(*batchError)
+		if !ok {
+			berr = newBatchError(pb.BatchError_UNKNOWN, err)
+		}
+		res.Error = &pb.BatchError{Code: berr.code, Message: berr.Error(), Row: int64(berr.row), Column: berr.column}
+		if serr := p.send(&pb.FlockResponse{Value: &pb.FlockResponse_Batch{Batch: res}}); serr != nil {
+			return serr
+		}
+
+		return err
+	}
+	p.logger.Info("successfully inserted chunk", zap.String("table", b.head.TableName), zap.String("batch", b.head.BatchId))
+
+	res.Success = true
+	res.RowsInserted = inserted
+	for _, t := range b.targets {
+		res.RowsRejected += int64(len(t.rejected))
+		res.RowsFiltered += int64(t.filtered)
+		res.RowsSkipped += int64(len(t.values) - t.failed)
+
+		d, ok := p.destinations[t.key]
+		if !ok {
+			d = &destination{entry: b.head.TableName, table: t.table.Name}
+			p.destinations[t.key] = d
+		}
+		d.rows += int64(t.received)
+		d.inserted += t.inserted
+	}
+	res.RowsSkipped -= inserted
+	if err := p.send(&pb.FlockResponse{Value: &pb.FlockResponse_Batch{Batch: res}}); err != nil {
+		return err
+	}
+	for _, c := range commits {
+		if err := p.send(&pb.FlockResponse{Value: &pb.FlockResponse_Commit{Commit: c}}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertTarget inserts the values of the batch into one of the tables of its entry, with the rows rejected by it
+func (p *pipeline) insertTarget(tx *sql.Tx, head *pb.BatchInsertHead, t *preparedTarget) (int64, error) {
+	var (
+		inserted int64
+		err      error
+	)
+	if p.session.deadLetters == nil {
+		inserted, err = flock.InsertValues(p.ctx, tx, t.values, t.table, t.table.Name, p.session.dialect)
+	} else {
+		inserted, err = flock.InsertValuesEach(p.ctx, tx, t.values, t.table, t.table.Name, p.session.dialect, func(i int, err error) {
+			t.failed++
+			t.rejected = append(t.rejected, &deadLetter{Table: t.table.Name, Row: t.rows[i], Error: err.Error()})
+		})
+	}
+	if err != nil {
+		p.logger.Error("failed to insert chunk", zap.String("table", t.table.Name), zap.String("batch", head.BatchId), zap.String("error", err.Error()))
+		return 0, newBatchError(pb.BatchError_INSERT, err)
+	}
+
+	if p.session.deadLetters != nil {
+		if err := p.session.deadLetters.reject(p.ctx, tx, t.table, t.rejected); err != nil {
+			return 0, err
+		}
+	}
+
+	return inserted, nil
+}
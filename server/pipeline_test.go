@@ -0,0 +1,496 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	flock "github.com/srikrsna/flock/pkg"
+	pb "github.com/srikrsna/flock/protos"
+	"go.uber.org/zap"
+)
+
+func testBatch(t *testing.T, index int64, ids ...int) *receivedBatch {
+	rows := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		rows = append(rows, map[string]interface{}{"id": id})
+	}
+
+	return testRows(t, index, rows)
+}
+
+func testRows(t *testing.T, index int64, rows []map[string]interface{}) *receivedBatch {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(rows); err != nil {
+		t.Fatal(err)
+	}
+
+	return &receivedBatch{
+		index:  index,
+		head:   &pb.BatchInsertHead{BatchId: fmt.Sprint(index), TableName: "Users", Chunks: 1, Sequence: index + 1},
+		chunks: []*pb.DataStream{{Index: 1, Data: buf.Bytes()}},
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	tables := map[string]flock.Table{
+		"Users": {Name: "Users", Keys: map[string]flock.Column{"Id": {Value: "id"}}, Ordered: []string{"Id"}, Conflict: flock.Conflict{Action: flock.ConflictIgnore}},
+	}
+
+	tests := []struct {
+		name    string
+		failAt  int
+		wantErr bool
+	}{
+		{"Ordered", -1, false},
+		{"Failed", 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			mock.ExpectBegin()
+			for i := 0; i < 5; i++ {
+				exp := mock.ExpectExec(`INSERT INTO Users`).WithArgs(i)
+				if i == tt.failAt {
+					exp.WillReturnError(errors.New("insert failed"))
+					break
+				}
+				exp.WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+			if tt.wantErr {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectCommit()
+			}
+
+			progress, err := newRunProgress(nil, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			sess := &session{
+				db:       db,
+				dialect:  flock.Postgres,
+				tables:   tables,
+				funcs:    flock.NewFuncs(),
+				progress: progress,
+				txs:      newScopedTx(db, pb.TransactionScope_RUN, 0, progress),
+				chunks:   make(map[string]*receivedBatch),
+			}
+
+			p := newPipeline(context.Background(), zap.NewNop(), 3, 5, sess)
+			var responses []*pb.FlockResponse
+			go p.serve(func(res *pb.FlockResponse) error {
+				responses = append(responses, res)
+				return nil
+			})
+
+			// Batches are handed over in reverse, they must still be inserted in the order of their index
+			for i := int64(4); i >= 0; i-- {
+				if err := p.reserve(); err != nil {
+					t.Fatal(err)
+				}
+				if err := p.submit(testBatch(t, i, int(i))); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err = p.drain()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("drain() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				<-p.sent
+				sess.txs.rollback()
+			} else {
+				if _, err := sess.txs.commit(); err != nil {
+					t.Fatal(err)
+				}
+				if err := p.close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// The batches are answered in order, up to the failing one
+			answered := 5
+			if tt.wantErr {
+				answered = tt.failAt + 1
+			}
+			if len(responses) != answered {
+				t.Fatalf("expected %d responses, got: %d", answered, len(responses))
+			}
+			for i, res := range responses {
+				batch := res.GetBatch()
+				if batch.BatchId != fmt.Sprint(i) || batch.RowsReceived != 1 {
+					t.Errorf("unexpected response for batch %d: %v", i, batch)
+				}
+				if i == tt.failAt {
+					if batch.Success || batch.Error.GetCode() != pb.BatchError_INSERT || batch.Error.GetRow() != -1 {
+						t.Errorf("expected an insert error for batch %d, got: %v", i, batch)
+					}
+					continue
+				}
+				if !batch.Success || batch.RowsInserted != 1 || batch.RowsSkipped != 0 {
+					t.Errorf("unexpected response for batch %d: %v", i, batch)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestPrepareWhere(t *testing.T) {
+	fl, err := flock.ParseSchema(strings.NewReader("Users {\n `SELECT * FROM Users`\n {\n - Id = id\n } where Id > 2\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, params := flock.BuildTables(fl)
+
+	p := &pipeline{session: &session{tables: tables, params: params, funcs: flock.NewFuncs()}}
+	res := p.prepare(testBatch(t, 0, 1, 2, 3, 4))
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if res.received != 4 || len(res.targets) != 1 {
+		t.Fatalf("unexpected batch: %d received, %d targets", res.received, len(res.targets))
+	}
+	if target := res.targets[0]; target.filtered != 2 || len(target.values) != 2 || len(target.rows) != 2 {
+		t.Errorf("unexpected target: %d filtered, values %v", target.filtered, target.values)
+	}
+}
+
+func TestPipelineTargets(t *testing.T) {
+	fl, err := flock.ParseSchema(strings.NewReader("Users {\n `SELECT * FROM Users`\n into users {\n - Id = id\n }\n into user_profiles {\n - UserId = id\n } where UserId > 1\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, params := flock.BuildTables(fl)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// The targets are inserted in the order they are declared, in the transaction of the batch
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "users"`).WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO "user_profiles"`).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	progress, err := newRunProgress(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess := &session{
+		db:       db,
+		dialect:  flock.Postgres,
+		tables:   tables,
+		params:   params,
+		funcs:    flock.NewFuncs(),
+		progress: progress,
+		txs:      newScopedTx(db, pb.TransactionScope_RUN, 0, progress),
+		chunks:   make(map[string]*receivedBatch),
+	}
+
+	p := newPipeline(context.Background(), zap.NewNop(), 1, 1, sess)
+	var responses []*pb.FlockResponse
+	go p.serve(func(res *pb.FlockResponse) error {
+		responses = append(responses, res)
+		return nil
+	})
+
+	if err := p.reserve(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.submit(testBatch(t, 0, 1, 2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.drain(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sess.txs.commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(responses) != 1 {
+		t.Fatalf("expected 1 response, got: %d", len(responses))
+	}
+	batch := responses[0].GetBatch()
+	if !batch.Success || batch.RowsReceived != 2 || batch.RowsInserted != 3 || batch.RowsFiltered != 1 || batch.RowsSkipped != 0 {
+		t.Errorf("unexpected response: %v", batch)
+	}
+	// Every target handles every source row, whatever its where clause leaves out
+	for _, key := range []string{"Users.users", "Users.user_profiles"} {
+		if d := p.destinations[key]; d == nil || d.entry != "Users" || d.rows != 2 {
+			t.Errorf("unexpected destination %s: %v", key, d)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPipelineExplode(t *testing.T) {
+	fl, err := flock.ParseSchema(strings.NewReader("Users {\n `SELECT * FROM Users`\n {\n - Id = id\n - Tag = tags | each Split \",\"\n }\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, params := flock.BuildTables(fl)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO Users`).WithArgs(1, "a", 1, "b", 2, "c").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM Users`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+	mock.ExpectCommit()
+
+	progress, err := newRunProgress(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess := &session{
+		db:       db,
+		dialect:  flock.Postgres,
+		tables:   tables,
+		params:   params,
+		funcs:    flock.DefaultFuncs(),
+		progress: progress,
+		txs:      newScopedTx(db, pb.TransactionScope_RUN, 0, progress),
+		counts:   map[string]*tableCount{"Users": {1, true}},
+		chunks:   make(map[string]*receivedBatch),
+	}
+
+	p := newPipeline(context.Background(), zap.NewNop(), 1, 1, sess)
+	var responses []*pb.FlockResponse
+	go p.serve(func(res *pb.FlockResponse) error {
+		responses = append(responses, res)
+		return nil
+	})
+
+	if err := p.reserve(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.submit(testRows(t, 0, []map[string]interface{}{{"id": 1, "tags": "a,b"}, {"id": 2, "tags": "c"}})); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.drain(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The source rows are verified, the table holds the rows they were exploded into
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(map[string]int{"Users": 2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := handleVerification(context.Background(), sess, p.destinations, buf.Bytes()); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := sess.txs.commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(responses) != 1 {
+		t.Fatalf("expected 1 response, got: %d", len(responses))
+	}
+	if batch := responses[0].GetBatch(); !batch.Success || batch.RowsReceived != 2 || batch.RowsInserted != 3 {
+		t.Errorf("unexpected response: %v", batch)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPipelineDeadLetters(t *testing.T) {
+	fl, err := flock.ParseSchema(strings.NewReader("Users {\n `SELECT * FROM Users`\n {\n - Id = id | Check\n }\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, params := flock.BuildTables(fl)
+	funcs := flock.NewFuncs()
+	if err := funcs.Register(flock.FuncMap{"Check": func(id int) (int, error) {
+		if id == 2 {
+			return 0, errors.New("invalid id")
+		}
+		return id, nil
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Row 2 is rejected by its function and row 3 by the database
+	mock.ExpectBegin()
+	mock.ExpectExec(`^SAVEPOINT flock_batch`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO Users`).WithArgs(1, 3, 4).WillReturnError(errors.New("duplicate key"))
+	mock.ExpectExec(`^ROLLBACK TO SAVEPOINT flock_batch`).WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, id := range []int{1, 3, 4} {
+		mock.ExpectExec(`^SAVEPOINT flock_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+		if id == 3 {
+			mock.ExpectExec(`INSERT INTO Users`).WithArgs(id).WillReturnError(errors.New("duplicate key"))
+			mock.ExpectExec(`^ROLLBACK TO SAVEPOINT flock_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+			continue
+		}
+		mock.ExpectExec(`INSERT INTO Users`).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`^RELEASE SAVEPOINT flock_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectExec(`^RELEASE SAVEPOINT flock_batch`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	progress, err := newRunProgress(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess := &session{
+		db:          db,
+		dialect:     flock.Postgres,
+		tables:      tables,
+		params:      params,
+		funcs:       funcs,
+		progress:    progress,
+		txs:         newScopedTx(db, pb.TransactionScope_RUN, 0, progress),
+		deadLetters: &deadLetters{sink: discardSink{}, runID: "run", tables: make(map[string]int64)},
+		chunks:      make(map[string]*receivedBatch),
+	}
+
+	p := newPipeline(context.Background(), zap.NewNop(), 1, 1, sess)
+	var responses []*pb.FlockResponse
+	go p.serve(func(res *pb.FlockResponse) error {
+		responses = append(responses, res)
+		return nil
+	})
+
+	if err := p.reserve(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.submit(testBatch(t, 0, 1, 2, 3, 4)); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.drain(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The rejected rows were handled, they don't fail the verification
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(map[string]int{"Users": 4}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := handleVerification(context.Background(), sess, p.destinations, buf.Bytes()); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := sess.txs.commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(responses) != 1 {
+		t.Fatalf("expected 1 response, got: %d", len(responses))
+	}
+	if batch := responses[0].GetBatch(); !batch.Success || batch.RowsReceived != 4 || batch.RowsInserted != 2 || batch.RowsRejected != 2 || batch.RowsSkipped != 0 {
+		t.Errorf("unexpected response: %v", batch)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPipelineIDs(t *testing.T) {
+	fl, err := flock.ParseSchema(strings.NewReader("Users {\n `SELECT * FROM Users`\n {\n - Id = id | ToGuid \"Users\"\n }\n}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, params := flock.BuildTables(fl)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ids := flock.NewSQLIDs(db, "guid", flock.Postgres)
+	funcs := flock.NewFuncs()
+	if err := funcs.Register(flock.FuncMap{"ToGuid": flock.ToGuid(ids)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The mapping is written in the transaction of the batch, before its rows
+	mock.ExpectQuery(`SELECT "new" FROM "guid".Users`).WithArgs("1").WillReturnRows(sqlmock.NewRows([]string{"new"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "guid".Users`).WithArgs("1", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO Users`).WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	progress, err := newRunProgress(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sess := &session{
+		db:       db,
+		dialect:  flock.Postgres,
+		tables:   tables,
+		params:   params,
+		funcs:    funcs,
+		progress: progress,
+		txs:      newScopedTx(db, pb.TransactionScope_RUN, 0, progress),
+		ids:      ids.(flock.IDWriter),
+		chunks:   make(map[string]*receivedBatch),
+	}
+	sess.txs.ids = sess.ids
+
+	p := newPipeline(context.Background(), zap.NewNop(), 1, 1, sess)
+	go p.serve(func(res *pb.FlockResponse) error { return nil })
+
+	if err := p.reserve(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.submit(testBatch(t, 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.drain(); err != nil {
+		t.Fatal(err)
+	}
+	// A run rolled back takes its mappings with it, the ID is looked up again
+	sess.txs.rollback()
+	if err := p.close(); err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery(`SELECT "new" FROM "guid".Users`).WithArgs("1").WillReturnRows(sqlmock.NewRows([]string{"new"}))
+	if _, err := ids.Map("Users", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -1,14 +1,13 @@
 package server
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
 	"encoding/gob"
 	"errors"
 	"fmt"
+	"runtime"
 	"sort"
-	"sync"
 	"time"
 
 	"github.com/elgris/sqrl"
@@ -37,18 +36,25 @@ type DB interface {
 	sqrl.ExecerContext
 	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
 	QueryRow(string, ...interface{}) *sql.Row
-}
-
-var chunkMap = &sync.Map{}
-
-type errorHandle struct {
-	err  error
-	lock *sync.Mutex
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	Close() error
 }
 
 // Server ....
 type Server struct {
 	Logger Logger
+	// Checkpoints stores the progress of runs so they can be resumed, runs can't be resumed when nil
+	Checkpoints CheckpointStore
+	// Workers is the number of batches prepared concurrently by a run, defaults to the number of CPUs
+	Workers int
+	// MaxPendingBatches is the number of batches a run holds in memory before it stops reading the stream,
+	// it is never less than Workers
+	MaxPendingBatches int
+	// DeadLetterDir is the directory of the NDJSON files of the rows rejected by runs using the file sink
+	DeadLetterDir string
+	// IDs returns the mapper behind the ToGuid function of a run given its destination database,
+	// runs use the mapper built into flock when nil
+	IDs func(db DB, dialect flock.Dialect) (flock.IDMapper, error)
 }
 
 // To check whether it conforms to the interface
@@ -83,14 +89,48 @@ func (s *Server) DatabaseHealth(ctx context.Context, in *pb.DBPing) (*pb.DBPong,
 	return &pb.DBPong{Schema: base}, nil
 }
 
+// ForeignKeys lists the tables referenced by the foreign keys of the destination, the client loads the tables they
+// reference first
+func (s *Server) ForeignKeys(ctx context.Context, in *pb.ForeignKeysRequest) (*pb.ForeignKeysResponse, error) {
+	name := in.Dialect
+	if name == "" {
+		name = in.Database
+	}
+	dialect, err := flock.GetDialect(name)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	db, err := flockSQL.ConnectDB(in.Url, in.Database)
+	if err != nil {
+		s.Logger.Error("failed to connect to database", zap.String("error", err.Error()))
+		return nil, err
+	}
+	defer db.Close()
+
+	keys, err := flockSQL.ForeignKeys(ctx, db, dialect.ForeignKeysQuery())
+	if err != nil {
+		s.Logger.Error("failed to read the foreign keys", zap.String("error", err.Error()))
+		return nil, err
+	}
+
+	tables := make([]string, 0, len(keys))
+	for t := range keys {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+	res := &pb.ForeignKeysResponse{Keys: make([]*pb.ForeignKey, 0, len(tables))}
+	for _, t := range tables {
+		res.Keys = append(res.Keys, &pb.ForeignKey{Table: t, References: keys[t]})
+	}
+
+	return res, nil
+}
+
 // Flock ...
 func (s *Server) Flock(ch pb.Flock_FlockServer) error {
 	var next pb.FlockRequest
-	var inError = errorHandle{nil, &sync.Mutex{}}
-	var db DB
-	var p sqrl.PlaceholderFormat
-	var tables map[string]flock.Table
-	var params map[string]map[string]flock.Variable
+	var sess *session
 
 	if err := ch.RecvMsg(&next); err != nil {
 		return err
@@ -99,29 +139,13 @@ func (s *Server) Flock(ch pb.Flock_FlockServer) error {
 	// Prepare the server for the Flock process
 	switch v := next.Value.(type) {
 	case *pb.FlockRequest_Start:
-		// To resolve recreation of db in this scope
 		var err error
-
-		db, err = flockSQL.ConnectDB(v.Start.Url, v.Start.Database)
+		sess, err = s.newSession(v.Start)
 		if err != nil {
-			s.Logger.Error("failed to connect to database", zap.String("error", err.Error()))
-			return err
-		}
-		fl, err := flock.ParseSchema(bytes.NewBuffer(v.Start.Schema))
-		if err != nil {
-			s.Logger.Error("failed to build tables", zap.String("error", err.Error()))
+			s.Logger.Error("failed to start run", zap.String("run", v.Start.RunId), zap.String("error", err.Error()))
 			return err
 		}
-
-		tables, params = flock.BuildTables(fl)
-
-		plugins, err := flock.PluginHandler(v.Start.Plugin)
-		if err != nil {
-			s.Logger.Error("failed to build plugin", zap.String("error", err.Error()))
-			return err
-		}
-
-		flock.RegisterFunc(plugins)
+		defer sess.close()
 
 		// TODO : Fill URL
 		// url := ""
@@ -148,12 +172,6 @@ func (s *Server) Flock(ch pb.Flock_FlockServer) error {
 		// 	}
 		// }
 
-		if v.Start.Dollar == true {
-			p = sqrl.Dollar
-		} else {
-			p = sqrl.Question
-		}
-
 		if err := ch.Send(&pb.FlockResponse{Value: &pb.FlockResponse_Pong{Pong: &pb.Pong{}}}); err != nil {
 			s.Logger.Error("failed to send start response", zap.String("error", err.Error()))
 			return err
@@ -164,127 +182,134 @@ func (s *Server) Flock(ch pb.Flock_FlockServer) error {
 		return status.Errorf(codes.Unimplemented, "must be version mismatch unknown message type: %T", next.Value)
 	}
 
-	// Implementation for a single user
-	// To iterate over the multiple users wrap the below code in a for loop
-	tx, err := db.BeginTx(ch.Context(), nil)
-	if err != nil {
-		s.Logger.Error("unable to create transaction", zap.String("error", err.Error()))
-		return err
-	}
-	defer tx.Rollback()
+	p := newPipeline(ch.Context(), s.Logger, s.workers(), s.MaxPendingBatches, sess)
+	go p.serve(ch.Send)
 
-	for {
-		inError.lock.Lock()
-		if inError.err != nil {
-			s.Logger.Error("failed to process stream")
-			return inError.err
+	// The stream is received in its own goroutine so that a failure of the pipeline
+	// ends the stream even when the client is waiting for a response
+	received := make(chan error, 1)
+	go func() {
+		received <- s.receive(ch, p, sess)
+	}()
+
+	// The stream must not be written to once the handler returns, so the sender is waited for
+	select {
+	case err := <-received:
+		if err != nil {
+			p.fail(err)
+			<-p.sent
+			return err
 		}
-		inError.lock.Unlock()
+		return nil
+	case <-p.done():
+		<-p.sent
+		return p.failure()
+	}
+}
+
+// workers returns the number of batches prepared concurrently
+func (s *Server) workers() int {
+	if s.Workers > 0 {
+		return s.Workers
+	}
+
+	return runtime.NumCPU()
+}
 
+// receive reads the requests of a run after it has started and hands the batches to the pipeline
+func (s *Server) receive(ch pb.Flock_FlockServer, p *pipeline, sess *session) error {
+	var next pb.FlockRequest
+	var index int64
+
+	for {
 		if err := ch.RecvMsg(&next); err != nil {
 			return err
 		}
 
 		switch v := next.Value.(type) {
 		case *pb.FlockRequest_Ping:
-			if err := ch.Send(&pb.FlockResponse{Value: &pb.FlockResponse_Pong{}}); err != nil {
+			if err := p.send(&pb.FlockResponse{Value: &pb.FlockResponse_Pong{}}); err != nil {
 				s.Logger.Error("unable to send echo message", zap.String("error", err.Error()))
 				return err
 			}
+		case *pb.FlockRequest_Resume:
+			if err := p.send(&pb.FlockResponse{Value: &pb.FlockResponse_Checkpoint{Checkpoint: sess.progress.checkpoint()}}); err != nil {
+				s.Logger.Error("unable to send checkpoint", zap.String("error", err.Error()))
+				return err
+			}
 		case *pb.FlockRequest_Batch:
 			if v == nil || v.Batch == nil {
 				return status.Errorf(codes.InvalidArgument, "nil batch request")
 			}
 			switch batch := v.Batch.Value.(type) {
 			case *pb.Batch_Head:
-				var tempChannel = make(chan *pb.DataStream)
-
-				chunkMap.Store(batch.Head.BatchId, tempChannel)
-
-				// Passing channel to ease access from locks and nextRequest to not store it
-				go func(channel chan *pb.DataStream, nextRequest *pb.BatchInsertHead, inerror *errorHandle) {
-					receivedChunks := make([]*pb.DataStream, 0)
-					for v := range channel {
-						receivedChunks = append(receivedChunks, v)
-						if int64(len(receivedChunks)) == nextRequest.Chunks {
-							close(channel)
-							chunkMap.Delete(nextRequest.BatchId)
-						}
-					}
-					sort.SliceStable(receivedChunks, func(i, j int) bool {
-						return receivedChunks[i].Index < receivedChunks[j].Index
-					})
-					var data = make([]byte, 0)
-					for _, v := range receivedChunks {
-						data = append(data, v.GetData()...)
-					}
+				// Waits for room in memory before accepting the chunks of the batch
+				if err := p.reserve(); err != nil {
+					return err
+				}
 
-					res, err := handleBatch(ch.Context(), tx, tables, nextRequest, data, p, params)
-					if err != nil {
-						s.Logger.Error("unable to handle batch insert request", zap.String("error", err.Error()))
-						inerror.lock.Lock()
-						inerror.err = err
-						inerror.lock.Unlock()
-						return
+				b := &receivedBatch{index: index, head: batch.Head, chunks: make([]*pb.DataStream, 0, batch.Head.Chunks)}
+				index++
+				if batch.Head.Chunks == 0 {
+					if err := p.submit(b); err != nil {
+						return err
 					}
-					s.Logger.Info("successfully inserted chunk", zap.String("table", nextRequest.TableName), zap.String("batch", nextRequest.BatchId))
-					if err := ch.Send(&pb.FlockResponse{Value: &pb.FlockResponse_Batch{Batch: res}}); err != nil {
-						s.Logger.Error("unable to send batch insert response", zap.String("error", err.Error()))
-						inerror.lock.Lock()
-						inerror.err = err
-						inerror.lock.Unlock()
-						return
-					}
-				}(tempChannel, batch.Head, &inError)
+					continue
+				}
+				sess.chunks[batch.Head.BatchId] = b
 			case *pb.Batch_Chunk:
-
-				value, ok := chunkMap.Load(batch.Chunk.BatchId)
+				b, ok := sess.chunks[batch.Chunk.BatchId]
 				if !ok {
 					s.Logger.Error("unidentified stream. Please send BatchInserHead before beginning a stream")
 					return errors.New("stream not found")
 				}
-				value.(chan *pb.DataStream) <- batch.Chunk
+				b.chunks = append(b.chunks, batch.Chunk)
+				if int64(len(b.chunks)) == b.head.Chunks {
+					delete(sess.chunks, batch.Chunk.BatchId)
+					if err := p.submit(b); err != nil {
+						return err
+					}
+				}
 			case *pb.Batch_Tail:
-				// _, ok := chunkMap.Load(batch.Tail.BatchId)
-				// if !ok {
-				// 	s.Logger.Error("unidentified stream. Please send BatchInserHead before beginning a stream")
-				// 	return errors.New("stream not found")
-				// }
-
-				// close(value.(chan *pb.DataStream))
-
-				// chunkMap.Delete(batch.Tail.BatchId)
-
+				// The batch is complete once all of its chunks are received
 			default:
 				s.Logger.Error("might be a version mis match unknown message type received", zap.String("type", fmt.Sprintf("%T", v.Batch.Value)))
 				return status.Errorf(codes.Unimplemented, "must be version mismatch unknown message type: %T", v.Batch.Value)
 			}
 		case *pb.FlockRequest_End:
-			if err := tx.Commit(); err != nil {
-				s.Logger.Error("could not commit the transaction", zap.String("error", err.Error()))
+			if len(sess.chunks) > 0 {
+				return status.Errorf(codes.InvalidArgument, "%d batches were not completely received", len(sess.chunks))
+			}
+			if err := p.drain(); err != nil {
 				return err
 			}
-			ok, err := handleVerification(db, tables, v.End.Records)
+			// The rows are verified before the commit so that a mismatch rolls the run back
+			ok, err := handleVerification(ch.Context(), sess, p.destinations, v.End.Records)
 			if err != nil {
 				if ok {
 					s.Logger.Error("number of inserted records don't match number of queried records", zap.String("info", err.Error()))
 					return err
 				}
-				s.Logger.Error("inserted records could not be retreived", zap.String("error", err.Error()))
+				s.Logger.Error("inserted records could not be verified", zap.String("error", err.Error()))
+				return err
+			}
+			commit, err := sess.txs.commit()
+			if err != nil {
+				s.Logger.Error("could not commit the transaction", zap.String("error", err.Error()))
 				return err
 			}
-			if err := ch.Send(&pb.FlockResponse{Value: &pb.FlockResponse_Batch{Batch: &pb.BatchInsertResponse{Success: true}}}); err != nil {
+			if err := p.send(&pb.FlockResponse{Value: &pb.FlockResponse_Commit{Commit: commit}}); err != nil {
+				s.Logger.Error("COMMIT SUCCESSFUL but unable to send commit progress", zap.String("error", err.Error()))
+				return err
+			}
+			if err := p.send(&pb.FlockResponse{Value: &pb.FlockResponse_Batch{Batch: &pb.BatchInsertResponse{Success: true}}}); err != nil {
 				s.Logger.Error("COMMIT SUCCESSFUL but unable to send echo message", zap.String("error", err.Error()))
 				return err
 			}
-			return nil
+			return p.close()
 		default:
 			s.Logger.Error("might be a version mis match unknown message type received", zap.String("type", fmt.Sprintf("%T", next.Value)))
 			return status.Errorf(codes.Unimplemented, "must be version mismatch unknown message type: %T", next.Value)
 		}
-
-		// if err := s.Logger.Sync(); err != nil {
-		// 	return fmt.Errorf("Failed to sync log: %v", err)
-		// }
 	}
 }
@@ -0,0 +1,249 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	flock "github.com/srikrsna/flock/pkg"
+	pb "github.com/srikrsna/flock/protos"
+	flockSQL "github.com/srikrsna/flock/sql"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// session holds the state of a single Flock stream, nothing in it is shared with other streams
+type session struct {
+	db       DB
+	dialect  flock.Dialect
+	tables   map[string]flock.Table
+	params   map[string]map[string]flock.Variable
+	funcs    *flock.Funcs
+	progress *runProgress
+	txs      *scopedTx
+	// deadLetters is nil when rejected rows fail the run
+	deadLetters *deadLetters
+	// counts are the rows of the destination tables before the run, by table
+	counts map[string]*tableCount
+	// ids writes the IDs mapped by ToGuid in the transactions of the run, it is nil for the other mappers
+	ids flock.IDWriter
+	// chunks holds the batches whose chunks are still being received, by batch ID.
+	// It is only used by the goroutine receiving the stream
+	chunks map[string]*receivedBatch
+}
+
+// newSession prepares a run for the start request
+func (s *Server) newSession(start *pb.Start) (*session, error) {
+	name := start.Dialect
+	if name == "" {
+		name = start.Database
+	}
+	dialect, err := flock.GetDialect(name)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	fl, err := flock.ParseSchema(bytes.NewBuffer(start.Schema))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build tables: %v", err)
+	}
+	tables, params := flock.BuildTables(fl)
+
+	// The functions of the plugin are only visible to this session, runs without a plugin use the built-in ones
+	funcs := flock.DefaultFuncs()
+	var plugins map[string]interface{}
+	if len(start.Plugin) > 0 {
+		if plugins, err = flock.PluginHandler(start.Plugin); err != nil {
+			return nil, fmt.Errorf("failed to build plugin: %v", err)
+		}
+		if err := funcs.Register(plugins); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid plugin: %v", err)
+		}
+	}
+	// Mistakes in the function calls of the schema are reported before anything is touched
+	if err := flock.Validate(fl, funcs.Map()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if err := resolveSources(fl, tables, funcs, start.Params); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	progress, err := newRunProgress(s.Checkpoints, start.RunId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint of run %s: %v", start.RunId, err)
+	}
+
+	deadLetters, err := newDeadLetters(start.DeadLetter, s.DeadLetterDir, start.RunId, dialect)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	db, err := flockSQL.ConnectDB(start.Url, start.Database)
+	if err != nil {
+		if deadLetters != nil {
+			deadLetters.close()
+		}
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+
+	ss := &session{
+		db:          db,
+		dialect:     dialect,
+		tables:      tables,
+		params:      params,
+		funcs:       funcs,
+		progress:    progress,
+		txs:         newScopedTx(db, start.Scope, start.CommitEvery, progress),
+		deadLetters: deadLetters,
+		chunks:      make(map[string]*receivedBatch),
+	}
+	// The rejected rows are kept or dropped with the transactions of their batches
+	ss.txs.letters = deadLetters
+	if err := primaryKeys(db, dialect, tables); err != nil {
+		ss.close()
+		return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+	}
+	if ss.counts, err = countTables(db, dialect, tables); err != nil {
+		ss.close()
+		return nil, fmt.Errorf("failed to count the rows of the destination: %v", err)
+	}
+
+	// A ToGuid of the plugin takes precedence over the mapper, as it does over any built-in function
+	if _, ok := plugins["ToGuid"]; !ok && s.IDs != nil {
+		ids, err := s.IDs(db, dialect)
+		if err == nil {
+			err = funcs.Register(flock.FuncMap{"ToGuid": flock.ToGuid(ids)})
+		}
+		if w, ok := ids.(flock.IDWriter); ok {
+			ss.ids = w
+			ss.txs.ids = w
+		}
+		if err != nil {
+			ss.close()
+			return nil, fmt.Errorf("failed to set up the ID mapper: %v", err)
+		}
+	}
+
+	return ss, nil
+}
+
+// tableCount holds the rows of a destination table before the run, they are checked against the rows the run
+// inserted into the table before it commits
+type tableCount struct {
+	before int64
+	// exact is false when an entry updates or replaces the rows of the table on conflict, the rows the database
+	// reports inserted then include the rows already there
+	exact bool
+}
+
+// primaryKeys sets the keys of the tables declaring none to their primary key, for the dialects that can't skip or
+// update the rows already there without keys
+func primaryKeys(db DB, dialect flock.Dialect, tables map[string]flock.Table) error {
+	finder, ok := dialect.(flock.KeyFinder)
+	if !ok {
+		return nil
+	}
+
+	found := make(map[string][]string)
+	for name, table := range tables {
+		targets := table.Tables()
+		for i := range targets {
+			t := &targets[i]
+			if len(t.Conflict.Keys) > 0 || (t.Conflict.Action != flock.ConflictIgnore && t.Conflict.Action != flock.ConflictUpdate) {
+				continue
+			}
+			keys, ok := found[t.Name]
+			if !ok {
+				var err error
+				if keys, err = primaryKey(db, finder.PrimaryKeyQuery(), t.Name); err != nil {
+					return fmt.Errorf("failed to find the primary key of %s: %v", t.Name, err)
+				}
+				if len(keys) == 0 {
+					return fmt.Errorf("conflict strategy %q of table %s needs the key columns to be declared, the table has no primary key", t.Conflict.Action, t.Name)
+				}
+				found[t.Name] = keys
+			}
+			t.Conflict.Keys = keys
+		}
+		if len(table.Targets) == 0 {
+			tables[name] = targets[0]
+		}
+	}
+
+	return nil
+}
+
+func primaryKey(db DB, query, table string) ([]string, error) {
+	rows, err := db.QueryContext(context.Background(), query, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// countTables counts the rows of every table the entries write to
+func countTables(db DB, dialect flock.Dialect, tables map[string]flock.Table) (map[string]*tableCount, error) {
+	counts := make(map[string]*tableCount)
+	for _, table := range tables {
+		for _, t := range table.Tables() {
+			c, ok := counts[t.Name]
+			if !ok {
+				c = &tableCount{exact: true}
+				if err := db.QueryRow(dialect.CountQuery(t.Name)).Scan(&c.before); err != nil {
+					return nil, fmt.Errorf("%s: %v", t.Name, err)
+				}
+				counts[t.Name] = c
+			}
+			if t.Conflict.Action == flock.ConflictUpdate || t.Conflict.Action == flock.ConflictReplace {
+				c.exact = false
+			}
+		}
+	}
+
+	return counts, nil
+}
+
+// resolveSources evaluates the values of the fields resolved once per run, with the params of the run
+// converted to their declared types
+func resolveSources(fl *flock.Flock, tables map[string]flock.Table, funcs *flock.Funcs, raw []byte) error {
+	params := make(map[string]interface{})
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return fmt.Errorf("invalid params: %v", err)
+		}
+	}
+	declared, err := flock.DeclaredParams(fl)
+	if err != nil {
+		return err
+	}
+	if params, err = flock.CheckParams(declared, params); err != nil {
+		return err
+	}
+
+	return flock.ResolveSources(tables, funcs, params)
+}
+
+// close rolls back the open transaction, if any, and disconnects from the database
+func (ss *session) close() error {
+	ss.txs.rollback()
+	if ss.deadLetters != nil {
+		if err := ss.deadLetters.close(); err != nil {
+			ss.db.Close()
+			return err
+		}
+	}
+
+	return ss.db.Close()
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	flock "github.com/srikrsna/flock/pkg"
+)
+
+func TestPrimaryKeys(t *testing.T) {
+	ignore := flock.Conflict{Action: flock.ConflictIgnore}
+	tests := []struct {
+		name    string
+		dialect flock.Dialect
+		tables  map[string]flock.Table
+		// found are the primary keys in the database, by table, the tables missing are not looked up
+		found map[string][]string
+		// wantKeys are the keys of the destination tables, by table
+		wantKeys map[string][]string
+		wantErr  bool
+	}{
+		{
+			"Found", flock.MSSQL,
+			map[string]flock.Table{"Users": {Name: "dbo.Users", Conflict: ignore}},
+			map[string][]string{"dbo.Users": {"ID"}},
+			map[string][]string{"dbo.Users": {"ID"}}, false,
+		},
+		{
+			"Declared", flock.MSSQL,
+			map[string]flock.Table{"Users": {Name: "Users", Conflict: flock.Conflict{Keys: []string{"Email"}, Action: flock.ConflictIgnore}}},
+			nil,
+			map[string][]string{"Users": {"Email"}}, false,
+		},
+		{
+			"Fail", flock.MSSQL,
+			map[string]flock.Table{"Users": {Name: "Users", Conflict: flock.Conflict{Action: flock.ConflictFail}}},
+			nil,
+			map[string][]string{"Users": nil}, false,
+		},
+		{
+			"Targets", flock.MSSQL,
+			map[string]flock.Table{"Users": {Name: "Users", Targets: []flock.Table{
+				{Name: "Users", Conflict: ignore},
+				{Name: "Emails", Conflict: flock.Conflict{Action: flock.ConflictUpdate}},
+			}}},
+			map[string][]string{"Users": {"ID"}, "Emails": {"UserID", "Email"}},
+			map[string][]string{"Users": {"ID"}, "Emails": {"UserID", "Email"}}, false,
+		},
+		{
+			"NoPrimaryKey", flock.MSSQL,
+			map[string]flock.Table{"Users": {Name: "Users", Conflict: ignore}},
+			map[string][]string{"Users": nil},
+			nil, true,
+		},
+		{
+			"OtherDialect", flock.Postgres,
+			map[string]flock.Table{"Users": {Name: "Users", Conflict: ignore}},
+			nil,
+			map[string][]string{"Users": nil}, false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			mock.MatchExpectationsInOrder(false)
+			for table, keys := range tt.found {
+				rows := sqlmock.NewRows([]string{"name"})
+				for _, k := range keys {
+					rows.AddRow(k)
+				}
+				mock.ExpectQuery(`SELECT c.name FROM sys.indexes`).WithArgs(table).WillReturnRows(rows)
+			}
+
+			err = primaryKeys(db, tt.dialect, tt.tables)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("primaryKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+			got := make(map[string][]string)
+			for _, table := range tt.tables {
+				for _, target := range table.Tables() {
+					got[target.Name] = target.Conflict.Keys
+				}
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.wantKeys) {
+				t.Errorf("expected the keys: %v, got: %v", tt.wantKeys, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	flock "github.com/srikrsna/flock/pkg"
+	pb "github.com/srikrsna/flock/protos"
+)
+
+// scopedTx opens and commits the transactions of a run at the boundaries of the scope requested by the client
+type scopedTx struct {
+	lock     sync.Mutex
+	db       DB
+	scope    pb.TransactionScope
+	every    int64
+	progress *runProgress
+	// letters are kept or dropped with the transactions, they are nil when rejected rows fail the run
+	letters *deadLetters
+	// ids are released with the transactions, they are nil unless the mapper writes its IDs in them
+	ids flock.IDWriter
+	tx  *sql.Tx
+	// Table and number of batches of the open transaction
+	table   string
+	batches int64
+}
+
+func newScopedTx(db DB, scope pb.TransactionScope, every int64, progress *runProgress) *scopedTx {
+	if every < 1 {
+		every = 1
+	}
+
+	return &scopedTx{db: db, scope: scope, every: every, progress: progress}
+}
+
+// exec runs the insert of a batch inside the transaction of its scope. It returns the progress of
+// every commit made on the way, the one of the previous table and the one completing the scope of the batch
+func (t *scopedTx) exec(ctx context.Context, head *pb.BatchInsertHead, insert func(tx *sql.Tx) (int, error)) ([]*pb.Commit, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	commits := make([]*pb.Commit, 0, 2)
+	if t.scope == pb.TransactionScope_TABLE && t.tx != nil && t.table != head.TableName {
+		c, err := t.commitLocked()
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, c)
+	}
+
+	if t.tx == nil {
+		tx, err := t.db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		t.tx = tx
+	}
+	t.table = head.TableName
+
+	rows, err := insert(t.tx)
+	if err != nil {
+		return nil, err
+	}
+	t.progress.inserted(head.TableName, head.Sequence, int64(rows))
+	t.batches++
+
+	if t.scope == pb.TransactionScope_BATCH && t.batches >= t.every {
+		c, err := t.commitLocked()
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, c)
+	}
+
+	return commits, nil
+}
+
+// commit commits the open transaction, if any, returning the progress of the run
+func (t *scopedTx) commit() (*pb.Commit, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.commitLocked()
+}
+
+func (t *scopedTx) commitLocked() (*pb.Commit, error) {
+	if t.tx != nil {
+		tx := t.tx
+		t.tx = nil
+		t.batches = 0
+		err := tx.Commit()
+		if t.ids != nil {
+			t.ids.Release()
+		}
+		if err != nil {
+			t.progress.rollback()
+			if t.letters != nil {
+				t.letters.rollback()
+			}
+			return nil, err
+		}
+	}
+	if t.letters != nil {
+		if err := t.letters.commit(); err != nil {
+			return nil, fmt.Errorf("the transaction committed but its rejected rows could not be stored: %v", err)
+		}
+	}
+
+	return t.progress.commit()
+}
+
+// queryRow runs the query in the open transaction so that it sees the rows not committed yet, or on the database
+// when no transaction is open
+func (t *scopedTx) queryRow(ctx context.Context, query string) *sql.Row {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.tx != nil {
+		return t.tx.QueryRowContext(ctx, query)
+	}
+
+	return t.db.QueryRow(query)
+}
+
+// rollback rolls back the open transaction, if any
+func (t *scopedTx) rollback() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.tx != nil {
+		t.tx.Rollback()
+		t.tx = nil
+		t.progress.rollback()
+		if t.letters != nil {
+			t.letters.rollback()
+		}
+		if t.ids != nil {
+			t.ids.Release()
+		}
+	}
+}
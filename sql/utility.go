@@ -3,6 +3,9 @@ package flock
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 )
 
 //ConnectDB -  Return database connection interface when passed the connection string and database
@@ -36,24 +39,11 @@ func GetData(ctx context.Context, db *sql.DB, query string, args []interface{})
 	res := make([]map[string]interface{}, 0, 10)
 
 	for rows.Next() {
-		columns := make([]interface{}, len(cols))
-		columnPointers := make([]interface{}, len(cols))
-		for i := range columns {
-			columnPointers[i] = &columns[i]
-		}
-
-		if err := rows.Scan(columnPointers...); err != nil {
+		m, err := scanRow(rows, cols)
+		if err != nil {
 			return nil, err
 		}
 
-		// Create our map, and retrieve the value for each column from the pointers slice,
-		// storing it in the map with the name of the column as the key.
-		m := make(map[string]interface{})
-		for i, colTyp := range cols {
-			val := columnPointers[i].(*interface{})
-			m[colTyp.Name()] = *val
-		}
-
 		res = append(res, m)
 	}
 
@@ -64,6 +54,162 @@ func GetData(ctx context.Context, db *sql.DB, query string, args []interface{})
 	return res, nil
 }
 
+// Cursor - Reads the results of a query in batches while the underlying rows are still open,
+// so only the batches handed out and not yet released are held in memory
+type Cursor struct {
+	rows  *sql.Rows
+	cols  []*sql.ColumnType
+	size  int
+	batch []map[string]interface{}
+	err   error
+}
+
+// Query - Runs the query and returns a cursor over its results in batches of size rows
+func Query(ctx context.Context, db *sql.DB, query string, args []interface{}, size int) (*Cursor, error) {
+	if size < 1 {
+		return nil, errors.New("batch size needs to be an integer greater than 0")
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	cols, err := rows.ColumnTypes()
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+
+	return &Cursor{rows: rows, cols: cols, size: size}, nil
+}
+
+// Next - Reads the next batch of rows, returns false once the rows are exhausted or an error occurs
+func (c *Cursor) Next() bool {
+	if c.err != nil {
+		return false
+	}
+
+	// A new slice every time as the previous batch may still be in use
+	c.batch = make([]map[string]interface{}, 0, c.size)
+	for len(c.batch) < c.size && c.rows.Next() {
+		m, err := scanRow(c.rows, c.cols)
+		if err != nil {
+			c.err = err
+			return false
+		}
+
+		c.batch = append(c.batch, m)
+	}
+
+	if len(c.batch) == 0 {
+		c.err = c.rows.Err()
+		return false
+	}
+
+	return true
+}
+
+// Batch - Returns the batch read by the last call to Next
+func (c *Cursor) Batch() []map[string]interface{} {
+	return c.batch
+}
+
+// Columns - Returns the column information of the result set
+func (c *Cursor) Columns() []*sql.ColumnType {
+	return c.cols
+}
+
+// Err - Returns the error, if any, that stopped the iteration
+func (c *Cursor) Err() error {
+	if c.err != nil {
+		return c.err
+	}
+
+	return c.rows.Err()
+}
+
+// Close - Closes the underlying rows
+func (c *Cursor) Close() error {
+	return c.rows.Close()
+}
+
+// scanRow - Reads the current row into a map of column names to values
+func scanRow(rows *sql.Rows, cols []*sql.ColumnType) (map[string]interface{}, error) {
+	columns := make([]interface{}, len(cols))
+	columnPointers := make([]interface{}, len(cols))
+	for i := range columns {
+		columnPointers[i] = &columns[i]
+	}
+
+	if err := rows.Scan(columnPointers...); err != nil {
+		return nil, err
+	}
+
+	// Create our map, and retrieve the value for each column from the pointers slice,
+	// storing it in the map with the name of the column as the key.
+	m := make(map[string]interface{}, len(cols))
+	for i, colTyp := range cols {
+		val := columnPointers[i].(*interface{})
+		m[colTyp.Name()] = *val
+	}
+
+	return m, nil
+}
+
+// QueryColumns - Returns the columns of the result set of the query without reading its rows. The query is wrapped
+// so that it returns nothing, the closing parenthesis goes on a line of its own in case the query ends in a comment.
+// A query that can't be wrapped, such as one ordering its rows or starting with a CTE on SQL Server, is run as it is
+// and cancelled once its columns are known, before any row is read
+func QueryColumns(ctx context.Context, db *sql.DB, query string, args []interface{}) ([]string, error) {
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+
+	rows, err := db.QueryContext(ctx, "SELECT * FROM ("+query+"\n) flock_describe WHERE 1 = 0", args...)
+	if err == nil {
+		defer rows.Close()
+		return rows.Columns()
+	}
+
+	// Cancelling the query stops drivers that read the remaining rows on close, such as the one of SQL Server
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if rows, err = db.QueryContext(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to describe the columns of the query: %v", err)
+	}
+	cols, err := rows.Columns()
+	cancel()
+	rows.Close()
+
+	return cols, err
+}
+
+// ForeignKeys - Returns the tables referenced by the foreign keys of every table, the query lists the foreign keys as
+// rows of the referencing table and the referenced one
+func ForeignKeys(ctx context.Context, db *sql.DB, query string) (map[string][]string, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[string][]string)
+	seen := make(map[[2]string]bool)
+	for rows.Next() {
+		var table, referenced string
+		if err := rows.Scan(&table, &referenced); err != nil {
+			return nil, err
+		}
+		// A foreign key of several columns is listed once for each of them
+		if seen[[2]string{table, referenced}] {
+			continue
+		}
+		seen[[2]string{table, referenced}] = true
+		res[table] = append(res[table], referenced)
+	}
+
+	return res, rows.Err()
+}
+
 // GetSchema - Return the column names of every table
 func GetSchema(ctx context.Context, db *sql.DB) (map[string][]string, error) {
 
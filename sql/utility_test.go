@@ -3,9 +3,12 @@ package flock_test
 import (
 	"bytes"
 	"context"
+	"database/sql/driver"
 	"encoding/gob"
 	"fmt"
 	"os"
+	"reflect"
+	"regexp"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -79,3 +82,166 @@ func TestGetDataFail(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		batches []int
+		wantErr bool
+	}{
+		{"Size-1", 1, []int{1, 1, 1, 1, 1}, false},
+		{"Size-2", 2, []int{2, 2, 1}, false},
+		{"Size-5", 5, []int{5}, false},
+		{"Size-10", 10, []int{5}, false},
+		{"Size-0", 0, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Error making a mock database.")
+			}
+			defer db.Close()
+
+			rows := mock.NewRows([]string{"ID", "Name"})
+			for i := 0; i < 5; i++ {
+				rows.AddRow(i, fmt.Sprintf("name-%d", i))
+			}
+			if !tt.wantErr {
+				mock.ExpectQuery("^SELECT (.+) FROM Users$").WillReturnRows(rows).RowsWillBeClosed()
+			}
+
+			cur, err := flockSQL.Query(context.Background(), db, "SELECT * FROM Users", nil, tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Query() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			batches := make([]int, 0)
+			id := int64(0)
+			for cur.Next() {
+				batch := cur.Batch()
+				batches = append(batches, len(batch))
+				for _, row := range batch {
+					if row["ID"] != id {
+						t.Errorf("rows out of order, expected: %v, got: %v", id, row["ID"])
+					}
+					id++
+				}
+			}
+			if err := cur.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if err := cur.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(batches, tt.batches) {
+				t.Errorf("expected batches: %v, got: %v", tt.batches, batches)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestQueryColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		args    []driver.Value
+		wrapped string
+		// raw is the query run as it is when the wrapped one fails
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{"Wrapped", "SELECT Id, Name FROM Users WHERE Region = $1;", []driver.Value{"eu"},
+			`^SELECT \* FROM \(SELECT Id, Name FROM Users WHERE Region = \$1\n\) flock_describe WHERE 1 = 0$`, "", []string{"Id", "Name"}, false},
+		{"Comment", "SELECT Id FROM Users -- active only", nil,
+			`^SELECT \* FROM \(SELECT Id FROM Users -- active only\n\) flock_describe WHERE 1 = 0$`, "", []string{"Id"}, false},
+		{"OrderBy", "SELECT Rid, Name FROM Reviews ORDER BY Rid desc", nil,
+			"", `^SELECT Rid, Name FROM Reviews ORDER BY Rid desc$`, []string{"Rid", "Name"}, false},
+		{"CTE", "WITH u AS (SELECT 1 AS Id) SELECT * FROM u", nil,
+			"", `^WITH u AS \(SELECT 1 AS Id\) SELECT \* FROM u$`, []string{"Id"}, false},
+		{"Invalid", "SELEC Id", nil, "", `^SELEC Id$`, nil, true},
+	}
+
+	// The queries are matched as they are, the default matcher collapses the line breaks
+	raw := sqlmock.QueryMatcherFunc(func(expected, actual string) error {
+		if !regexp.MustCompile(expected).MatchString(actual) {
+			return fmt.Errorf("could not match actual sql: %q with expected regexp %q", actual, expected)
+		}
+		return nil
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			if tt.wrapped != "" {
+				mock.ExpectQuery(tt.wrapped).WithArgs(tt.args...).WillReturnRows(sqlmock.NewRows(tt.want))
+			} else {
+				mock.ExpectQuery(`flock_describe`).WillReturnError(fmt.Errorf("incorrect syntax"))
+				exp := mock.ExpectQuery(tt.raw)
+				if tt.wantErr {
+					exp.WillReturnError(fmt.Errorf("incorrect syntax"))
+				} else {
+					// The rows are never read
+					exp.WillReturnRows(sqlmock.NewRows(tt.want).AddRow(make([]driver.Value, len(tt.want))...)).RowsWillBeClosed()
+				}
+			}
+
+			args := make([]interface{}, len(tt.args))
+			for i, a := range tt.args {
+				args[i] = a
+			}
+			cols, err := flockSQL.QueryColumns(context.Background(), db, tt.query, args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("QueryColumns() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(cols, tt.want) {
+				t.Errorf("got %v, want %v", cols, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestForeignKeys(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// A foreign key of two columns is listed twice
+	mock.ExpectQuery(`^SELECT fks$`).WillReturnRows(sqlmock.NewRows([]string{"table", "referenced"}).
+		AddRow("orders", "users").
+		AddRow("order_lines", "orders").
+		AddRow("order_lines", "products").
+		AddRow("order_lines", "orders"))
+
+	keys, err := flockSQL.ForeignKeys(context.Background(), db, "SELECT fks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{"orders": {"users"}, "order_lines": {"orders", "products"}}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("got %v, want %v", keys, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}